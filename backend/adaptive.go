@@ -0,0 +1,94 @@
+package followercount
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default thresholds of the adaptive rate limit, above which the instance
+// counts as overloaded.
+const (
+	defaultAdaptiveMaxConcurrency = 8
+	defaultAdaptiveMaxAvg         = 5 * time.Second
+)
+
+// loadSmoothing is the weight of the newest request in the moving average of
+// processing times.
+const loadSmoothing = 0.2
+
+// loadTracker measures the requests being processed by this instance and
+// a moving average of how long they take.
+type loadTracker struct {
+	mu       sync.Mutex
+	inFlight int
+	avg      time.Duration
+	// divisor is the last reduction applied, so changes are logged once.
+	divisor int
+}
+
+var load = &loadTracker{}
+
+// begin records the start of a request and returns the function recording
+// its end.
+func (l *loadTracker) begin() func() {
+	start := clock.Now()
+	l.mu.Lock()
+	l.inFlight++
+	l.mu.Unlock()
+
+	return func() {
+		elapsed := clock.Now().Sub(start)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.inFlight--
+		if l.avg == 0 {
+			l.avg = elapsed
+		} else {
+			l.avg += time.Duration(loadSmoothing * float64(elapsed-l.avg))
+		}
+	}
+}
+
+// adaptiveThresholds reads ADAPTIVE_MAX_CONCURRENCY and ADAPTIVE_MAX_AVG_MS.
+func adaptiveThresholds() (concurrency int, avg time.Duration) {
+	concurrency, avg = defaultAdaptiveMaxConcurrency, defaultAdaptiveMaxAvg
+	if n, err := strconv.Atoi(getEnv("ADAPTIVE_MAX_CONCURRENCY")); err == nil && n > 0 {
+		concurrency = n
+	}
+	if ms, err := strconv.Atoi(getEnv("ADAPTIVE_MAX_AVG_MS")); err == nil && ms > 0 {
+		avg = time.Duration(ms) * time.Millisecond
+	}
+	return concurrency, avg
+}
+
+// effectiveMaxRequests returns the per-client request limit. With
+// RATE_LIMIT_MODE=adaptive it is halved while the instance processes more
+// concurrent requests than ADAPTIVE_MAX_CONCURRENCY or requests take longer
+// than ADAPTIVE_MAX_AVG_MS on average, and quartered while both hold, so a
+// traffic spike can't run up the deployment's bill. It never drops below one
+// request per window and recovers as the load eases.
+func effectiveMaxRequests() int {
+	if !strings.EqualFold(strings.TrimSpace(getEnv("RATE_LIMIT_MODE")), "adaptive") {
+		return maxRequests
+	}
+	maxConcurrency, maxAvg := adaptiveThresholds()
+
+	load.mu.Lock()
+	defer load.mu.Unlock()
+	divisor := 1
+	if load.inFlight > maxConcurrency {
+		divisor *= 2
+	}
+	if load.avg > maxAvg {
+		divisor *= 2
+	}
+	limit := max(maxRequests/divisor, 1)
+	if divisor != max(load.divisor, 1) {
+		log.Printf("Adaptive rate limit now %d requests per %s (%d in flight, %d ms average)", limit, windowDuration, load.inFlight, load.avg.Milliseconds())
+		load.divisor = divisor
+	}
+	return limit
+}
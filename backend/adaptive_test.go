@@ -0,0 +1,89 @@
+package followercount
+
+import (
+	"testing"
+	"time"
+)
+
+func withLoadTracker(t *testing.T) {
+	previous := load
+	load = &loadTracker{}
+	t.Cleanup(func() { load = previous })
+}
+
+func TestLoadTracker(t *testing.T) {
+	fake := withClock(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	tracker := &loadTracker{}
+
+	first := tracker.begin()
+	second := tracker.begin()
+	if tracker.inFlight != 2 {
+		t.Fatalf("Expected 2 requests in flight, got %d", tracker.inFlight)
+	}
+	fake.Advance(10 * time.Second)
+	first()
+	if tracker.avg != 10*time.Second {
+		t.Fatalf("Expected the first duration as the average, got %v", tracker.avg)
+	}
+	second()
+	if tracker.inFlight != 0 || tracker.avg != 10*time.Second {
+		t.Fatalf("Expected no requests in flight and a 10s average, got %d and %v", tracker.inFlight, tracker.avg)
+	}
+
+	done := tracker.begin()
+	done()
+	if want := 8 * time.Second; tracker.avg != want {
+		t.Fatalf("Expected a fast request to pull the average to %v, got %v", want, tracker.avg)
+	}
+}
+
+func TestEffectiveMaxRequests(t *testing.T) {
+	silenceLogs(t)
+	withLoadTracker(t)
+	load.inFlight = 100
+	load.avg = time.Minute
+
+	if got := effectiveMaxRequests(); got != maxRequests {
+		t.Fatalf("Expected the fixed limit outside adaptive mode, got %d", got)
+	}
+
+	withEnv(t, "RATE_LIMIT_MODE", "adaptive")
+	if got := effectiveMaxRequests(); got != maxRequests/4 {
+		t.Fatalf("Expected a quarter of the limit under high concurrency and latency, got %d", got)
+	}
+
+	load.avg = time.Second
+	if got := effectiveMaxRequests(); got != maxRequests/2 {
+		t.Fatalf("Expected half the limit under high concurrency, got %d", got)
+	}
+
+	load.inFlight = 1
+	if got := effectiveMaxRequests(); got != maxRequests {
+		t.Fatalf("Expected the full limit once the load eases, got %d", got)
+	}
+
+	withEnv(t, "ADAPTIVE_MAX_AVG_MS", "100")
+	withEnv(t, "ADAPTIVE_MAX_CONCURRENCY", "0")
+	if got := effectiveMaxRequests(); got != maxRequests/2 {
+		t.Fatalf("Expected the configured latency threshold to apply, got %d", got)
+	}
+}
+
+func TestCheckRateLimit_Adaptive(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+	withLoadTracker(t)
+	withEnv(t, "RATE_LIMIT_MODE", "adaptive")
+	load.avg = time.Hour
+
+	allowed := 0
+	for i := 0; i < maxRequests; i++ {
+		if checkRateLimit("203.0.113.7") {
+			allowed++
+		}
+	}
+	if allowed != maxRequests/2 {
+		t.Fatalf("Expected %d requests allowed while overloaded, got %d", maxRequests/2, allowed)
+	}
+}
@@ -0,0 +1,301 @@
+// Package analysis finds the accounts that don't follow an Instagram user
+// back, from the JSON data export Instagram provides. It has no HTTP or
+// deployment dependencies, so other Go tools can embed the analyzer:
+//
+//	f, err := os.Open("instagram-export.zip")
+//	...
+//	info, _ := f.Stat()
+//	result, err := analysis.Analyze(f, info.Size())
+//
+// Extracted exports can be analyzed from any fs.FS, such as os.DirFS:
+//
+//	result, err := analysis.AnalyzeFiles(os.DirFS("instagram-export"))
+package analysis
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"time"
+)
+
+// Errors returned when an export can't be analyzed. ErrNoFollowing and
+// ErrNoFollowers come wrapped in a *Diagnosis carrying a localized hint.
+var (
+	ErrCorruptZip  = errors.New("ZIP archive could not be read")
+	ErrNoFollowing = errors.New("no following data found")
+	ErrNoFollowers = errors.New("no followers data found")
+)
+
+// Result is the outcome of analyzing one export.
+type Result struct {
+	NonFollowers   []NonFollower  `json:"non_followers"`
+	TotalFollowing int            `json:"total_following"`
+	TotalFollowers int            `json:"total_followers"`
+	Quality        *QualityReport `json:"quality"`
+	Stats          *Stats         `json:"stats"`
+	Insights       []Insight      `json:"insights"`
+	Warnings       []Warning      `json:"warnings,omitempty"`
+	// PossibleDuplicates groups followed accounts that probably belong to
+	// the same person.
+	PossibleDuplicates []DuplicateGroup `json:"possible_duplicates,omitempty"`
+	// Receipt lists the files the analysis read and a hash of their
+	// contents.
+	Receipt *ProcessingReceipt `json:"processing_receipt,omitempty"`
+	// DataAsOf is the date of the newest activity in the export, as
+	// YYYY-MM-DD, when it records any.
+	DataAsOf string `json:"data_as_of,omitempty"`
+	// Milestones are notable moments in the account's history.
+	Milestones []Milestone `json:"milestones,omitempty"`
+	// OtherRelationships summarizes lists of accounts in files the analysis
+	// doesn't recognize.
+	OtherRelationships []OtherRelationship `json:"other_relationships,omitempty"`
+	// PendingRequests lists the follow requests still awaiting approval,
+	// oldest first.
+	PendingRequests []PendingRequest `json:"pending_requests,omitempty"`
+	// Partial is set when some relationship files couldn't be read, so the
+	// counts may be too low.
+	Partial bool `json:"partial,omitempty"`
+	// SkippedAnalyzers lists the optional analyzers that failed or lacked
+	// the files they need; their sections are missing from the result.
+	SkippedAnalyzers []SkippedAnalyzer `json:"skipped_analyzers,omitempty"`
+	// Platforms holds the results for the Facebook and Threads data of a
+	// combined Meta export when Analyzer.AllPlatforms is set.
+	Platforms map[string]*Result `json:"platforms,omitempty"`
+	// Mutuals lists the followed accounts that follow back when
+	// Analyzer.Mutuals is set.
+	Mutuals []Mutual `json:"mutuals,omitempty"`
+	// Fans lists the followers that aren't followed back when Analyzer.Fans
+	// is set.
+	Fans []Fan `json:"fans,omitempty"`
+	// StrongestConnections and WeakestConnections rank the mutuals by the
+	// strength of the relationship, strongest and weakest first.
+	StrongestConnections []Connection `json:"strongest_connections,omitempty"`
+	WeakestConnections   []Connection `json:"weakest_connections,omitempty"`
+}
+
+// Analyzer holds the settings of an analysis. The zero value has zero
+// quality baselines; use Default or copy it to change single settings.
+type Analyzer struct {
+	// SpamBaseline and BrandBaseline are the shares of followers, in
+	// percent, the quality report compares against.
+	SpamBaseline  float64
+	BrandBaseline float64
+	// Now returns the current time, which insights are relative to. Nil
+	// means time.Now.
+	Now func() time.Time
+	// PendingThreshold is how long a follow request may stay pending before
+	// it is flagged for cancelling. Zero means DefaultPendingThreshold.
+	PendingThreshold time.Duration
+	// StaleAfter is how old the newest activity in an export may be before
+	// a stale_export warning is raised. Zero means DefaultStaleAfter.
+	StaleAfter time.Duration
+	// TopConnections is how many strongest and weakest connections are
+	// listed. Zero means DefaultTopConnections.
+	TopConnections int
+	// AllPlatforms also analyzes the Facebook and Threads data of a combined
+	// Meta Accounts Center export. Otherwise only its Instagram data is read.
+	AllPlatforms bool
+	// Mutuals also lists the accounts that follow back, which are left out
+	// by default since there are usually many of them.
+	Mutuals bool
+	// Fans also lists the followers the owner doesn't follow back, which are
+	// left out by default for the same reason.
+	Fans bool
+	// Only restricts the optional analyzers run to those named, out of
+	// OptionalAnalyzers, so clients that want a single list don't pay for
+	// the rest. Nil runs all of them.
+	Only []string
+}
+
+// Default is the Analyzer used by Analyze and AnalyzeFiles.
+var Default = Analyzer{
+	SpamBaseline:  DefaultSpamBaseline,
+	BrandBaseline: DefaultBrandBaseline,
+}
+
+// Analyze analyzes an export ZIP of the given size using Default.
+func Analyze(r io.ReaderAt, size int64) (*Result, error) {
+	return Default.Analyze(r, size)
+}
+
+// AnalyzeFiles analyzes an export laid out in fsys using Default.
+func AnalyzeFiles(fsys fs.FS) (*Result, error) {
+	return Default.AnalyzeFiles(fsys)
+}
+
+// Analyze analyzes an export ZIP of the given size. It returns
+// ErrPasswordRequired for a password-protected ZIP; open those with
+// DecryptZip and pass the result to AnalyzeFiles.
+func (a Analyzer) Analyze(r io.ReaderAt, size int64) (*Result, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorruptZip, err)
+	}
+	if IsEncrypted(zipReader) {
+		return nil, ErrPasswordRequired
+	}
+	return a.AnalyzeFiles(zipReader)
+}
+
+// AnalyzeFiles analyzes an export laid out in fsys, such as a *zip.Reader
+// or an extracted export opened with os.DirFS. It returns an error wrapping
+// ErrNoFollowing or ErrNoFollowers when the export lacks either list.
+//
+// For a combined Meta Accounts Center export the result is that of its
+// Instagram data, which must be present.
+func (a Analyzer) AnalyzeFiles(fsys fs.FS) (*Result, error) {
+	subtrees := platformSubtrees(fsys)
+	if subtrees == nil {
+		return a.analyzeExport(fsys)
+	}
+
+	instagram := fsys
+	if dir, ok := subtrees[PlatformInstagram]; ok {
+		instagram = platformFS(fsys, dir)
+	}
+	result, err := a.analyzeExport(instagram)
+	if err != nil || !a.AllPlatforms {
+		return result, err
+	}
+
+	for _, platform := range []string{PlatformFacebook, PlatformThreads} {
+		dir, ok := subtrees[platform]
+		if !ok {
+			continue
+		}
+		platformResult, err := a.analyzeExport(platformFS(fsys, dir))
+		if err != nil {
+			result.Warnings = append(result.Warnings, Warning{
+				Code:    WarnPlatformSkipped,
+				Message: fmt.Sprintf("The %s data in this export could not be analyzed: %v.", platform, err),
+				File:    dir,
+			})
+			continue
+		}
+		if result.Platforms == nil {
+			result.Platforms = make(map[string]*Result)
+		}
+		result.Platforms[platform] = platformResult
+	}
+	return result, nil
+}
+
+// analyzeExport analyzes the relationships of a single platform's export.
+func (a Analyzer) analyzeExport(fsys fs.FS) (*Result, error) {
+	report := &Report{}
+
+	followerList := readFollowers(fsys, report)
+	followers := followerSet(followerList)
+	following := readFollowing(fsys, report)
+	following = reconcileUnfollowed(following, readUnfollowed(fsys, report), report)
+
+	if len(following) == 0 {
+		return nil, diagnoseExport(fsys, ErrNoFollowing)
+	}
+	if len(followers) == 0 {
+		return nil, diagnoseExport(fsys, ErrNoFollowers)
+	}
+
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	threshold := a.PendingThreshold
+	if threshold == 0 {
+		threshold = DefaultPendingThreshold
+	}
+
+	staleAfter := a.StaleAfter
+	if staleAfter == 0 {
+		staleAfter = DefaultStaleAfter
+	}
+	topConnections := a.TopConnections
+	if topConnections == 0 {
+		topConnections = DefaultTopConnections
+	}
+
+	result := &Result{
+		NonFollowers:   findNonFollowers(following, followers),
+		TotalFollowing: len(following),
+		TotalFollowers: len(followers),
+	}
+	if a.Mutuals {
+		result.Mutuals = findMutuals(following, followers)
+	}
+	if a.Fans {
+		result.Fans = findFans(followerList, following)
+	}
+
+	var skipped []SkippedAnalyzer
+	optional := func(name string, run func() error) {
+		if a.Only == nil || slices.Contains(a.Only, name) {
+			runOptional(name, &skipped, run)
+		}
+	}
+	var interactions map[string]*Interaction
+	optional(AnalyzerInteractions, func() error {
+		var err error
+		if interactions, err = readInteractions(fsys, report); err != nil {
+			return err
+		}
+		attachInteractions(result.NonFollowers, interactions)
+		return nil
+	})
+	var pending []Relationship
+	optional(AnalyzerPendingRequests, func() error {
+		var err error
+		if pending, err = readPendingRequests(fsys, report); err != nil {
+			return err
+		}
+		result.PendingRequests = buildPendingRequests(pending, threshold, now())
+		return nil
+	})
+	optional(AnalyzerOtherRelationships, func() error {
+		result.OtherRelationships = readOtherRelationships(fsys, report)
+		return nil
+	})
+	optional(AnalyzerFreshness, func() error {
+		result.DataAsOf = checkFreshness(newestTimestamp(following, followers, pending), staleAfter, now(), report)
+		return nil
+	})
+	optional(AnalyzerQuality, func() error {
+		result.Quality = buildQualityReport(followers, a.SpamBaseline, a.BrandBaseline)
+		return nil
+	})
+	optional(AnalyzerStats, func() error {
+		result.Stats = buildStats(following, followers)
+		return nil
+	})
+	optional(AnalyzerInsights, func() error {
+		result.Insights = buildInsights(following, followers, result.NonFollowers, now())
+		return nil
+	})
+	optional(AnalyzerMilestones, func() error {
+		result.Milestones = buildMilestones(following, followers)
+		return nil
+	})
+	optional(AnalyzerPossibleDuplicates, func() error {
+		result.PossibleDuplicates = findPossibleDuplicates(following)
+		return nil
+	})
+	optional(AnalyzerConnections, func() error {
+		// Engagement is only a bonus: exports without likes or comments
+		// are ranked by age and close friends alone.
+		if interactions == nil {
+			interactions, _ = readInteractions(fsys, report)
+		}
+		result.StrongestConnections, result.WeakestConnections = rankConnections(
+			following, followers, interactions, readCloseFriends(fsys, report), topConnections, now())
+		return nil
+	})
+
+	result.SkippedAnalyzers = skipped
+	result.Warnings = report.Warnings
+	result.Receipt = report.receipt()
+	result.Partial = report.Partial
+	return result, nil
+}
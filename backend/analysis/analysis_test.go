@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func silenceLogs(tb testing.TB) {
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+// mapFS builds an in-memory export from file contents.
+func mapFS(files map[string]string) fstest.MapFS {
+	fsys := make(fstest.MapFS, len(files))
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+func createTestZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create file in zip: %v", err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write to zip file: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close zip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+var basicExport = map[string]string{
+	"connections/followers_and_following/followers_1.json": `[
+		{"string_list_data": [{"href": "https://www.instagram.com/friend", "value": "friend", "timestamp": 1600000000}]}
+	]`,
+	"connections/followers_and_following/following.json": `{"relationships_following": [
+		{"title": "friend", "string_list_data": [{"href": "https://www.instagram.com/friend", "timestamp": 1500000000}]},
+		{"title": "The Celebrity", "string_list_data": [{"href": "https://www.instagram.com/_u/celeb", "timestamp": 1500000000}]}
+	]}`,
+	"media/posts/202001/1.jpg": "",
+}
+
+func TestAnalyze(t *testing.T) {
+	silenceLogs(t)
+	data := createTestZip(t, basicExport)
+
+	result, err := Analyze(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	if result.TotalFollowers != 1 || result.TotalFollowing != 2 {
+		t.Fatalf("Expected 1 follower and 2 following, got %d and %d", result.TotalFollowers, result.TotalFollowing)
+	}
+	if len(result.NonFollowers) != 1 || result.NonFollowers[0].Username != "celeb" || result.NonFollowers[0].DisplayName != "The Celebrity" {
+		t.Fatalf("Expected celeb as the only non-follower, got %+v", result.NonFollowers)
+	}
+	if result.Quality == nil || result.Stats == nil {
+		t.Fatal("Expected quality and stats to be filled in")
+	}
+}
+
+func TestAnalyze_NotAZip(t *testing.T) {
+	data := []byte("not a zip")
+	if _, err := Analyze(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrCorruptZip) {
+		t.Fatalf("Expected ErrCorruptZip, got %v", err)
+	}
+}
+
+func TestAnalyzeFiles_MatchesZip(t *testing.T) {
+	silenceLogs(t)
+	data := createTestZip(t, basicExport)
+	analyzer := Analyzer{Now: func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }}
+
+	fromZip, err := analyzer.Analyze(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	fromFS, err := analyzer.AnalyzeFiles(mapFS(basicExport))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+
+	if len(fromZip.NonFollowers) != len(fromFS.NonFollowers) || len(fromZip.Insights) != len(fromFS.Insights) {
+		t.Fatalf("Expected the same result from a ZIP and an fs.FS, got %+v and %+v", fromZip, fromFS)
+	}
+}
+
+func TestAnalyzeFiles_MultiPartFollowing(t *testing.T) {
+	silenceLogs(t)
+
+	result, err := AnalyzeFiles(mapFS(map[string]string{
+		"connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "a"}]}]`,
+		"connections/followers_and_following/following_1.json": `{"relationships_following": [{"title": "a"}, {"title": "b"}]}`,
+		"connections/followers_and_following/following_2.json": `{"relationships_following": [{"title": "c"}]}`,
+		"connections/followers_and_following/following_3.json": `[{"string_list_data": [{"value": "d"}]}]`,
+	}))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if result.TotalFollowing != 4 || len(result.NonFollowers) != 3 {
+		t.Fatalf("Expected all 4 parts' accounts and 3 non-followers, got %d and %+v", result.TotalFollowing, result.NonFollowers)
+	}
+}
+
+func TestAnalyzeFiles_MissingLists(t *testing.T) {
+	silenceLogs(t)
+
+	_, err := AnalyzeFiles(mapFS(map[string]string{
+		"connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "a"}]}]`,
+	}))
+	if !errors.Is(err, ErrNoFollowing) {
+		t.Fatalf("Expected ErrNoFollowing, got %v", err)
+	}
+	var diagnosis *Diagnosis
+	if !errors.As(err, &diagnosis) || diagnosis.Hint == "" {
+		t.Fatalf("Expected a diagnosis with a hint, got %v", err)
+	}
+
+	_, err = AnalyzeFiles(mapFS(map[string]string{
+		"connections/followers_and_following/following.json": `{"relationships_following": [{"title": "a"}]}`,
+	}))
+	if !errors.Is(err, ErrNoFollowers) {
+		t.Fatalf("Expected ErrNoFollowers, got %v", err)
+	}
+}
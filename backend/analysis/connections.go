@@ -0,0 +1,143 @@
+package analysis
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTopConnections is how many strongest and weakest connections are
+// listed when the Analyzer doesn't set a number.
+const DefaultTopConnections = 10
+
+// closeFriendsTitle is the title of the close friends list among the other
+// relationship lists.
+const closeFriendsTitle = "close_friends"
+
+// Weights of the signals in a connection's score, which add up to 100. Each
+// signal saturates: a mutual relationship counts fully from
+// fullStrengthAge on, and engagement from fullStrengthEngagement points,
+// where a like is one point and a comment two.
+const (
+	ageWeight              = 40
+	engagementWeight       = 40
+	closeFriendWeight      = 20
+	fullStrengthAge        = 5 * 365 * 24 * time.Hour
+	fullStrengthEngagement = 20
+)
+
+// Connection is a mutual ranked by the strength of the relationship.
+type Connection struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+	ProfileURL  string `json:"profile_url"`
+	// Score rates the relationship from 0 to 100 by how long it has been
+	// mutual, the owner's likes and comments on the account's posts and
+	// whether it is a close friend.
+	Score int `json:"score"`
+	// MutualSince is when the second of the two follows happened, when
+	// the export records both.
+	MutualSince int64        `json:"mutual_since,omitempty"`
+	Interaction *Interaction `json:"interaction,omitempty"`
+	CloseFriend bool         `json:"close_friend,omitempty"`
+}
+
+// readCloseFriends returns the lowercased usernames on the owner's close
+// friends list, which is empty when the export has none.
+func readCloseFriends(fsys fs.FS, report *Report) map[string]bool {
+	closeFriends := make(map[string]bool)
+	files, _ := candidateFiles(fsys, func(name string) bool {
+		return isOtherRelationshipFile(name) && otherTitle(name) == closeFriendsTitle
+	})
+	for _, name := range files {
+		content, err := readFile(fsys, name)
+		if err != nil {
+			report.unreadable(name, err)
+			continue
+		}
+		entries, err := decodeRelationshipList(content)
+		if err != nil {
+			debugf("readCloseFriends: %s is not a relationship list: %v", name, err)
+			continue
+		}
+		report.processed(name, kindOther, content)
+		for _, entry := range entries {
+			if rel := toRelationship(entry, name); rel.Username != "" {
+				closeFriends[strings.ToLower(rel.Username)] = true
+			}
+		}
+	}
+	return closeFriends
+}
+
+// connectionScore weighs the signals of a mutual relationship into a score
+// from 0 to 100.
+func connectionScore(mutualSince int64, interaction *Interaction, closeFriend bool, now time.Time) int {
+	var score float64
+	if mutualSince != 0 {
+		age := now.Sub(time.Unix(mutualSince, 0))
+		score += ageWeight * math.Min(math.Max(float64(age)/float64(fullStrengthAge), 0), 1)
+	}
+	if interaction != nil {
+		points := float64(interaction.Likes + 2*interaction.Comments)
+		score += engagementWeight * math.Min(points/fullStrengthEngagement, 1)
+	}
+	if closeFriend {
+		score += closeFriendWeight
+	}
+	return int(math.Round(score))
+}
+
+// rankConnections scores every mutual and returns the top strongest ones,
+// strongest first, and up to top of the remaining ones as the weakest,
+// weakest first. Ties go to the older relationship, then by username.
+func rankConnections(following []Relationship, followers map[string]int64, interactions map[string]*Interaction, closeFriends map[string]bool, top int, now time.Time) (strongest, weakest []Connection) {
+	var ranked []Connection
+	for _, rel := range following {
+		key := strings.ToLower(rel.Username)
+		followedYouAt, exists := followers[key]
+		if !exists {
+			continue
+		}
+		conn := Connection{
+			Username:    rel.Username,
+			DisplayName: rel.DisplayName,
+			ProfileURL:  fmt.Sprintf("https://instagram.com/%s", rel.Username),
+			Interaction: interactions[key],
+			CloseFriend: closeFriends[key],
+		}
+		if rel.FollowedAt != 0 && followedYouAt != 0 {
+			conn.MutualSince = max(rel.FollowedAt, followedYouAt)
+		}
+		conn.Score = connectionScore(conn.MutualSince, conn.Interaction, conn.CloseFriend, now)
+		ranked = append(ranked, conn)
+	}
+	if len(ranked) == 0 {
+		return nil, nil
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if (a.MutualSince == 0) != (b.MutualSince == 0) {
+			return b.MutualSince == 0
+		}
+		if a.MutualSince != b.MutualSince {
+			return a.MutualSince < b.MutualSince
+		}
+		return strings.ToLower(a.Username) < strings.ToLower(b.Username)
+	})
+
+	n := min(top, len(ranked))
+	strongest = ranked[:n]
+	rest := ranked[n:]
+	for i := len(rest) - 1; i >= 0 && len(weakest) < top; i-- {
+		weakest = append(weakest, rest[i])
+	}
+	return strongest, weakest
+}
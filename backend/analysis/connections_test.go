@@ -0,0 +1,111 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnectionScore(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	years := func(n float64) int64 { return now.Add(-time.Duration(n * float64(fullStrengthAge/5))).Unix() }
+
+	tests := []struct {
+		name        string
+		mutualSince int64
+		interaction *Interaction
+		closeFriend bool
+		want        int
+	}{
+		{"no signals", 0, nil, false, 0},
+		{"new", now.Unix(), nil, false, 0},
+		{"half as old as full strength", years(2.5), nil, false, 20},
+		{"older than full strength", years(12), nil, false, 40},
+		{"some engagement", 0, &Interaction{Likes: 4, Comments: 3}, false, 20},
+		{"heavy engagement", 0, &Interaction{Likes: 100}, false, 40},
+		{"close friend", 0, nil, true, 20},
+		{"everything", years(5), &Interaction{Comments: 10}, true, 100},
+		{"in the future", now.Add(time.Hour).Unix(), nil, false, 0},
+	}
+	for _, tt := range tests {
+		if got := connectionScore(tt.mutualSince, tt.interaction, tt.closeFriend, now); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestRankConnections(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	following := []Relationship{
+		{Username: "acquaintance", FollowedAt: 1700000000},
+		{Username: "Bestie", FollowedAt: 1500000000},
+		{Username: "celeb", FollowedAt: 1500000000},
+		{Username: "oldfriend", FollowedAt: 1400000000},
+		{Username: "stranger"},
+	}
+	followers := map[string]int64{"acquaintance": 1700000000, "bestie": 1500000000, "oldfriend": 1400000000, "stranger": 0}
+	interactions := map[string]*Interaction{"bestie": {Likes: 30}}
+	closeFriends := map[string]bool{"bestie": true}
+
+	strongest, weakest := rankConnections(following, followers, interactions, closeFriends, 2, now)
+	if len(strongest) != 2 || strongest[0].Username != "Bestie" || strongest[1].Username != "oldfriend" {
+		t.Fatalf("Expected Bestie then oldfriend as strongest, got %+v", strongest)
+	}
+	if b := strongest[0]; b.Score != 100 || !b.CloseFriend || b.Interaction == nil || b.MutualSince != 1500000000 {
+		t.Fatalf("Expected Bestie to score 100 with every signal, got %+v", b)
+	}
+	if len(weakest) != 2 || weakest[0].Username != "stranger" || weakest[1].Username != "acquaintance" {
+		t.Fatalf("Expected stranger then acquaintance as weakest, got %+v", weakest)
+	}
+	if weakest[0].MutualSince != 0 {
+		t.Fatalf("Expected no mutual_since without both timestamps, got %d", weakest[0].MutualSince)
+	}
+
+	// The weakest never repeat the strongest.
+	strongest, weakest = rankConnections(following, followers, nil, nil, 3, now)
+	if len(strongest) != 3 || len(weakest) != 1 {
+		t.Fatalf("Expected 3 strongest and the 1 remaining mutual, got %d and %d", len(strongest), len(weakest))
+	}
+
+	if strongest, weakest := rankConnections(following, map[string]int64{}, nil, nil, 2, now); strongest != nil || weakest != nil {
+		t.Fatal("Expected no connections without mutuals")
+	}
+}
+
+func TestReadCloseFriends(t *testing.T) {
+	silenceLogs(t)
+	fsys := mapFS(map[string]string{
+		"connections/followers_and_following/close_friends.json": `{"relationships_close_friends": [
+			{"string_list_data": [{"href": "https://www.instagram.com/Bestie", "value": "Bestie"}]}
+		]}`,
+		"connections/followers_and_following/blocked_accounts.json": `{"relationships_blocked_users": [
+			{"string_list_data": [{"href": "https://www.instagram.com/troll", "value": "troll"}]}
+		]}`,
+	})
+
+	report := &Report{}
+	closeFriends := readCloseFriends(fsys, report)
+	if len(closeFriends) != 1 || !closeFriends["bestie"] {
+		t.Fatalf("Expected bestie as the only close friend, got %v", closeFriends)
+	}
+	// Reading the list again, as the other relationships do, records it once.
+	readOtherRelationships(fsys, report)
+	if len(report.files) != 2 {
+		t.Fatalf("Expected each file in the receipt once, got %+v", report.files)
+	}
+}
+
+func TestAnalyzeFiles_Connections(t *testing.T) {
+	silenceLogs(t)
+	analyzer := Default
+	analyzer.Now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+	result, err := analyzer.AnalyzeFiles(mapFS(basicExport))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if len(result.StrongestConnections) != 1 || result.StrongestConnections[0].Username != "friend" || result.WeakestConnections != nil {
+		t.Fatalf("Expected friend as the only connection, got %+v and %+v", result.StrongestConnections, result.WeakestConnections)
+	}
+	if got := result.StrongestConnections[0].MutualSince; got != 1600000000 {
+		t.Fatalf("Expected friend to be mutual since they followed back, got %d", got)
+	}
+}
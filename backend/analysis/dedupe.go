@@ -0,0 +1,46 @@
+package analysis
+
+import "strings"
+
+// entryKey identifies an entry of the export across the files it is merged
+// from: the same account at the same time in the same kind of list. at is
+// zero for entries without a timestamp.
+type entryKey struct {
+	kind     string
+	username string
+	at       int64
+}
+
+// entrySet drops entries seen before, so an export that contains the same
+// file twice, e.g. both parts of a split export extracted twice into one
+// archive, isn't counted twice.
+type entrySet struct {
+	seen       map[entryKey]bool
+	duplicates map[string]int
+}
+
+func newEntrySet() *entrySet {
+	return &entrySet{seen: make(map[entryKey]bool), duplicates: make(map[string]int)}
+}
+
+// add records an entry and reports whether it is new. Entries without a
+// timestamp are keyed on the account alone, so an account repeated without
+// one counts once per kind of list.
+func (s *entrySet) add(kind, username string, at int64) bool {
+	key := entryKey{kind: kind, username: strings.ToLower(username), at: at}
+	if s.seen[key] {
+		s.duplicates[kind]++
+		return false
+	}
+	s.seen[key] = true
+	return true
+}
+
+// report warns about the duplicates dropped from each kind of list.
+func (s *entrySet) report(report *Report, kinds ...string) {
+	for _, kind := range kinds {
+		if n := s.duplicates[kind]; n > 0 {
+			report.warn(WarnDuplicateEntries, "", "%d duplicate %s %s found, probably from export files included more than once, and counted only once.", n, kind, plural(n, "entry was", "entries were"))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package analysis
+
+import "testing"
+
+func TestEntrySet(t *testing.T) {
+	seen := newEntrySet()
+	if !seen.add(kindFollowing, "Friend", 100) || seen.add(kindFollowing, "friend", 100) {
+		t.Fatal("Expected the same account and time to be a duplicate regardless of case")
+	}
+	if !seen.add(kindFollowing, "friend", 200) || !seen.add(kindLikes, "friend", 100) {
+		t.Fatal("Expected another time or kind to be a new entry")
+	}
+	if !seen.add(kindLikes, "friend", 0) || seen.add(kindLikes, "Friend", 0) {
+		t.Fatal("Expected an account repeated without a timestamp to be a duplicate")
+	}
+	if !seen.add(kindComments, "friend", 0) || !seen.add(kindLikes, "other", 0) {
+		t.Fatal("Expected another kind or account without a timestamp to be a new entry")
+	}
+
+	report := &Report{}
+	seen.report(report, kindFollowing, kindLikes, kindComments)
+	if len(report.Warnings) != 2 || report.Warnings[0].Code != WarnDuplicateEntries {
+		t.Fatalf("Expected duplicate_entries warnings for following and likes, got %+v", report.Warnings)
+	}
+}
+
+func TestAnalyzeFiles_DuplicateEntriesWithoutTimestamps(t *testing.T) {
+	silenceLogs(t)
+	fsys := mapFS(map[string]string{
+		"connections/followers_and_following/followers_1.json": basicExport["connections/followers_and_following/followers_1.json"],
+		"connections/followers_and_following/following.json": `{"relationships_following": [
+			{"title": "friend"},
+			{"title": "celeb"},
+			{"title": "Celeb"}
+		]}`,
+	})
+
+	result, err := AnalyzeFiles(fsys)
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if result.TotalFollowing != 2 || len(result.NonFollowers) != 1 {
+		t.Fatalf("Expected the repeated celeb counted once, got %d following, %+v", result.TotalFollowing, result.NonFollowers)
+	}
+}
+
+func TestAnalyzeFiles_DuplicateFiles(t *testing.T) {
+	silenceLogs(t)
+	following := `[
+		{"string_list_data": [{"href": "https://www.instagram.com/friend", "value": "friend", "timestamp": 1500000000}]},
+		{"string_list_data": [{"href": "https://www.instagram.com/celeb", "value": "celeb", "timestamp": 1500000001}]}
+	]`
+	likes := `{"likes_media_likes": [{"title": "celeb", "string_list_data": [{"timestamp": 1600000000}]}]}`
+	fsys := mapFS(map[string]string{
+		"part1/connections/followers_and_following/followers_1.json": basicExport["connections/followers_and_following/followers_1.json"],
+		"part1/connections/followers_and_following/following.json":   following,
+		"part1/your_instagram_activity/likes/liked_posts.json":       likes,
+		// The same export extracted a second time into the archive.
+		"part1 copy/connections/followers_and_following/followers_1.json": basicExport["connections/followers_and_following/followers_1.json"],
+		"part1 copy/connections/followers_and_following/following.json":   following,
+		"part1 copy/your_instagram_activity/likes/liked_posts.json":       likes,
+	})
+
+	result, err := AnalyzeFiles(fsys)
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if result.TotalFollowing != 2 || result.TotalFollowers != 1 || len(result.NonFollowers) != 1 {
+		t.Fatalf("Expected duplicates counted once, got %d following, %d followers, %+v", result.TotalFollowing, result.TotalFollowers, result.NonFollowers)
+	}
+	if i := result.NonFollowers[0].Interaction; i == nil || i.Likes != 1 {
+		t.Fatalf("Expected the duplicated like counted once, got %+v", i)
+	}
+	duplicates := 0
+	for _, w := range result.Warnings {
+		if w.Code == WarnDuplicateEntries {
+			duplicates++
+		}
+	}
+	if duplicates != 2 {
+		t.Fatalf("Expected duplicate warnings for following and likes, got %+v", result.Warnings)
+	}
+}
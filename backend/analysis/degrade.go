@@ -0,0 +1,67 @@
+package analysis
+
+import (
+	"log"
+	"runtime/debug"
+)
+
+// Optional analyzers. The follower and following lists are required; these
+// only add to the result, so when one fails or the files it needs are
+// missing it is skipped and the rest of the result is still returned.
+const (
+	AnalyzerQuality            = "quality"
+	AnalyzerStats              = "stats"
+	AnalyzerInsights           = "insights"
+	AnalyzerMilestones         = "milestones"
+	AnalyzerInteractions       = "interactions"
+	AnalyzerPendingRequests    = "pending_requests"
+	AnalyzerPossibleDuplicates = "possible_duplicates"
+	AnalyzerFreshness          = "freshness"
+	AnalyzerOtherRelationships = "other_relationships"
+	AnalyzerConnections        = "connections"
+)
+
+// SkippedAnalyzer names an optional analyzer left out of a result and why.
+type SkippedAnalyzer struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// missingFilesError reports that an export lacks the files an optional
+// analyzer needs.
+type missingFilesError struct {
+	files string
+}
+
+func (e *missingFilesError) Error() string {
+	return "the export has no " + e.files
+}
+
+// errAnalyzerFailed is the reason given for an analyzer that panicked; the
+// panic value itself may carry data from the export and isn't reported.
+const errAnalyzerFailed = "the analyzer failed unexpectedly"
+
+// OnPanic, when set, is called with the name of an optional analyzer that
+// panicked and the recovered value, e.g. to report it to a crash reporter.
+// It runs in the deferred recovery, so runtime.Callers still sees the
+// panicking stack. The value may carry export data and must not be sent
+// anywhere.
+var OnPanic func(analyzer string, recovered any)
+
+// runOptional runs the named optional analyzer, recording it in skipped
+// instead of failing the analysis when it returns an error or panics. Like
+// the HTTP recovery middleware, it logs only the panic type and stack.
+func runOptional(name string, skipped *[]SkippedAnalyzer, run func() error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("panic in %s analyzer: %T\n%s", name, rec, debug.Stack())
+			if OnPanic != nil {
+				OnPanic(name, rec)
+			}
+			*skipped = append(*skipped, SkippedAnalyzer{Name: name, Reason: errAnalyzerFailed})
+		}
+	}()
+	if err := run(); err != nil {
+		*skipped = append(*skipped, SkippedAnalyzer{Name: name, Reason: err.Error()})
+	}
+}
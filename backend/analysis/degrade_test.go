@@ -0,0 +1,83 @@
+package analysis
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunOptional(t *testing.T) {
+	silenceLogs(t)
+	var skipped []SkippedAnalyzer
+
+	runOptional("ok", &skipped, func() error { return nil })
+	runOptional("missing", &skipped, func() error { return &missingFilesError{files: "likes"} })
+	runOptional("broken", &skipped, func() error { panic("secret_username") })
+	runOptional("failing", &skipped, func() error { return errors.New("boom") })
+
+	want := []SkippedAnalyzer{
+		{Name: "missing", Reason: "the export has no likes"},
+		{Name: "broken", Reason: errAnalyzerFailed},
+		{Name: "failing", Reason: "boom"},
+	}
+	if len(skipped) != len(want) {
+		t.Fatalf("Expected %d skipped analyzers, got %+v", len(want), skipped)
+	}
+	for i := range want {
+		if skipped[i] != want[i] {
+			t.Errorf("Skipped %d: expected %+v, got %+v", i, want[i], skipped[i])
+		}
+	}
+}
+
+func TestRunOptional_OnPanic(t *testing.T) {
+	silenceLogs(t)
+	var got string
+	OnPanic = func(analyzer string, _ any) { got = analyzer }
+	t.Cleanup(func() { OnPanic = nil })
+
+	var skipped []SkippedAnalyzer
+	runOptional("ok", &skipped, func() error { return nil })
+	runOptional("broken", &skipped, func() error { panic("secret_username") })
+	if got != "broken" {
+		t.Errorf("Expected OnPanic to be called for broken, got %q", got)
+	}
+}
+
+func TestAnalyzeFiles_SkippedAnalyzers(t *testing.T) {
+	silenceLogs(t)
+	analyzer := Analyzer{Now: func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }}
+
+	result, err := analyzer.AnalyzeFiles(mapFS(basicExport))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, s := range result.SkippedAnalyzers {
+		if s.Reason == "" {
+			t.Errorf("Expected a reason for skipping %s", s.Name)
+		}
+		names[s.Name] = true
+	}
+	if len(names) != 2 || !names[AnalyzerInteractions] || !names[AnalyzerPendingRequests] {
+		t.Fatalf("Expected interactions and pending requests skipped for lack of files, got %+v", result.SkippedAnalyzers)
+	}
+	if len(result.NonFollowers) != 1 || result.Quality == nil || result.Stats == nil {
+		t.Fatalf("Expected the core sections despite skipped analyzers, got %+v", result)
+	}
+
+	files := map[string]string{
+		"connections/followers_and_following/pending_follow_requests.json": `{"relationships_follow_requests_sent": []}`,
+		"your_instagram_activity/likes/liked_posts.json":                   `{"likes_media_likes": []}`,
+	}
+	for name, content := range basicExport {
+		files[name] = content
+	}
+	result, err = analyzer.AnalyzeFiles(mapFS(files))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if len(result.SkippedAnalyzers) != 0 {
+		t.Fatalf("Expected no skipped analyzers when their files are present, got %+v", result.SkippedAnalyzers)
+	}
+}
@@ -0,0 +1,45 @@
+package analysis
+
+import "io/fs"
+
+// ofKind returns a matcher for the files of the given kind, for
+// candidateFiles.
+func ofKind(kind string) func(name string) bool {
+	return func(name string) bool { return matchesKind(kind, name) }
+}
+
+// isFollowersFile reports whether name looks like one of the followers
+// files: by default followers_N.json anywhere, or any other "followers" file
+// inside connections/followers_and_following/.
+func isFollowersFile(name string) bool {
+	return matchesKind(kindFollowers, name)
+}
+
+// isFollowingFile reports whether name looks like a following file. Unlike
+// followers, the file name alone is enough by default; the folder is not
+// checked.
+func isFollowingFile(name string) bool {
+	return matchesKind(kindFollowing, name)
+}
+
+// candidateFiles returns the paths of the files in fsys whose names satisfy
+// match, in lexical order, along with the total number of files. Only names
+// are consulted, so exports with thousands of media entries are narrowed
+// down without opening anything.
+func candidateFiles(fsys fs.FS, match func(name string) bool) ([]string, int) {
+	var matched []string
+	total := 0
+	fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			// Unreadable directories are skipped rather than failing the
+			// whole walk.
+			return nil
+		}
+		total++
+		if match(name) {
+			matched = append(matched, name)
+		}
+		return nil
+	})
+	return matched, total
+}
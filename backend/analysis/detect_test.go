@@ -0,0 +1,48 @@
+package analysis
+
+import "testing"
+
+func TestFileMatchers(t *testing.T) {
+	tests := []struct {
+		name      string
+		followers bool
+		following bool
+	}{
+		{"connections/followers_and_following/followers_1.json", true, false},
+		{"followers_2.json", true, false},
+		{"connections/followers_and_following/following.json", false, true},
+		{"following.json", false, true},
+		{"connections/followers_and_following/following_2.json", false, true},
+		{"connections/followers_and_following/close_friends.json", false, false},
+		{"media/posts/202301/photo.jpg", false, false},
+		{"followers_and_following.json", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFollowersFile(tt.name); got != tt.followers {
+				t.Errorf("isFollowersFile = %v, want %v", got, tt.followers)
+			}
+			if got := isFollowingFile(tt.name); got != tt.following {
+				t.Errorf("isFollowingFile = %v, want %v", got, tt.following)
+			}
+		})
+	}
+}
+
+func TestCandidateFiles(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"connections/followers_and_following/followers_1.json": "[]",
+		"connections/followers_and_following/following.json":   "{}",
+		"media/posts/1.jpg": "",
+		"media/posts/2.jpg": "",
+	})
+
+	files, total := candidateFiles(fsys, isFollowersFile)
+	if len(files) != 1 || files[0] != "connections/followers_and_following/followers_1.json" {
+		t.Fatalf("Expected only the followers file, got %v", files)
+	}
+	if total != 4 {
+		t.Fatalf("Expected 4 files in total, got %d", total)
+	}
+}
@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+)
+
+// minDuplicateBase is the shortest normalized handle that is grouped; short
+// bases such as "jo" match too many unrelated accounts.
+const minDuplicateBase = 4
+
+// duplicateSuffixes are handle parts commonly added to a person's second
+// account.
+var duplicateSuffixes = []string{
+	"backup", "bkp", "bk", "alt", "old", "new", "official", "real", "priv",
+	"private", "spam", "finsta", "second", "2nd", "main", "personal",
+}
+
+func isDuplicateSuffix(s string) bool {
+	for _, suffix := range duplicateSuffixes {
+		if s == suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// DuplicateGroup is a set of followed accounts that probably belong to the
+// same person.
+type DuplicateGroup struct {
+	// Base is the handle the accounts have in common once separators,
+	// numbers and suffixes such as "backup" are removed.
+	Base      string   `json:"base"`
+	Usernames []string `json:"usernames"`
+}
+
+// duplicateBase normalizes a handle for grouping: "jane.doe_backup",
+// "janedoe2" and "jane_doe" all become "janedoe".
+func duplicateBase(username string) string {
+	parts := strings.FieldsFunc(strings.ToLower(username), func(r rune) bool { return r == '.' || r == '_' })
+	for len(parts) > 1 {
+		last := parts[len(parts)-1]
+		if !isDuplicateSuffix(last) && strings.Trim(last, "0123456789") != "" {
+			break
+		}
+		parts = parts[:len(parts)-1]
+	}
+	base := strings.Join(parts, "")
+	for _, suffix := range duplicateSuffixes {
+		if trimmed := strings.TrimSuffix(base, suffix); trimmed != base && len(trimmed) >= minDuplicateBase {
+			base = trimmed
+			break
+		}
+	}
+	return strings.TrimRight(base, "0123456789")
+}
+
+// findPossibleDuplicates groups followed accounts whose handles only differ
+// by separators, trailing numbers or a second-account suffix. Groups are
+// sorted by base, and usernames within a group alphabetically.
+func findPossibleDuplicates(following []Relationship) []DuplicateGroup {
+	groups := make(map[string]map[string]bool)
+	for _, rel := range following {
+		base := duplicateBase(rel.Username)
+		if len(base) < minDuplicateBase {
+			continue
+		}
+		if groups[base] == nil {
+			groups[base] = make(map[string]bool)
+		}
+		groups[base][strings.ToLower(rel.Username)] = true
+	}
+
+	var duplicates []DuplicateGroup
+	for base, usernames := range groups {
+		if len(usernames) < 2 {
+			continue
+		}
+		group := DuplicateGroup{Base: base}
+		for username := range usernames {
+			group.Usernames = append(group.Usernames, username)
+		}
+		sort.Strings(group.Usernames)
+		duplicates = append(duplicates, group)
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Base < duplicates[j].Base })
+	return duplicates
+}
@@ -0,0 +1,54 @@
+package analysis
+
+import "testing"
+
+func TestDuplicateBase(t *testing.T) {
+	tests := map[string]string{
+		"jane.doe":        "janedoe",
+		"jane_doe_backup": "janedoe",
+		"JaneDoe2":        "janedoe",
+		"janedoe_2":       "janedoe",
+		"janedoebackup":   "janedoe",
+		"jane.doe.priv":   "janedoe",
+		"bob_2":           "bob",
+		"2000":            "",
+	}
+	for in, want := range tests {
+		if got := duplicateBase(in); got != want {
+			t.Errorf("duplicateBase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFindPossibleDuplicates(t *testing.T) {
+	following := []Relationship{
+		{Username: "jane.doe"},
+		{Username: "jane_doe_backup"},
+		{Username: "janedoe2"},
+		{Username: "marco_photo"},
+		{Username: "marcophoto.official"},
+		{Username: "bob"},
+		{Username: "bob_2"},
+		{Username: "unrelated"},
+	}
+
+	groups := findPossibleDuplicates(following)
+
+	want := []DuplicateGroup{
+		{Base: "janedoe", Usernames: []string{"jane.doe", "jane_doe_backup", "janedoe2"}},
+		{Base: "marcophoto", Usernames: []string{"marco_photo", "marcophoto.official"}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("Expected %d groups, got %+v", len(want), groups)
+	}
+	for i := range want {
+		if groups[i].Base != want[i].Base || len(groups[i].Usernames) != len(want[i].Usernames) {
+			t.Fatalf("Group %d: expected %+v, got %+v", i, want[i], groups[i])
+		}
+		for j := range want[i].Usernames {
+			if groups[i].Usernames[j] != want[i].Usernames[j] {
+				t.Fatalf("Group %d: expected %+v, got %+v", i, want[i], groups[i])
+			}
+		}
+	}
+}
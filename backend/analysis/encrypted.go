@@ -0,0 +1,320 @@
+package analysis
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"path"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Errors returned for password-protected exports.
+var (
+	ErrPasswordRequired = errors.New("ZIP archive is password-protected")
+	ErrWrongPassword    = errors.New("wrong ZIP password")
+)
+
+const (
+	// flagEncrypted is bit 0 of the general purpose flags of an entry.
+	flagEncrypted = 0x1
+	// flagDataDescriptor is bit 3; ZipCrypto then checks the password
+	// against the modification time instead of the CRC.
+	flagDataDescriptor = 0x8
+	// methodAES marks an entry encrypted with WinZip AES; the real
+	// compression method is in its AES extra field.
+	methodAES = 99
+	// aesExtraID identifies the WinZip AES extra field.
+	aesExtraID = 0x9901
+
+	zipCryptoHeaderLen = 12
+	aesVerifierLen     = 2
+	aesAuthCodeLen     = 10
+	aesIterations      = 1000
+	// maxEncryptionOverhead covers the largest encryption header and
+	// trailer, those of AES-256 entries.
+	maxEncryptionOverhead = 16 + aesVerifierLen + aesAuthCodeLen
+)
+
+// IsEncrypted reports whether any entry of r is password-protected.
+func IsEncrypted(r *zip.Reader) bool {
+	for _, f := range r.File {
+		if f.Flags&flagEncrypted != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// DecryptZip returns an fs.FS over r that decrypts password-protected
+// entries, encrypted with either ZipCrypto or WinZip AES, as they are
+// opened. Entries are decrypted in memory only. It returns ErrWrongPassword
+// when the password doesn't match the encryption header of the first
+// encrypted entry, which is checked without decrypting the entry itself.
+func DecryptZip(r *zip.Reader, password string) (fs.FS, error) {
+	d := &decryptingZip{r: r, password: []byte(password), files: make(map[string]*zip.File)}
+	var first *zip.File
+	for _, f := range r.File {
+		if f.Flags&flagEncrypted == 0 {
+			continue
+		}
+		d.files[path.Clean(f.Name)] = f
+		if first == nil {
+			first = f
+		}
+	}
+	if first != nil {
+		if err := d.checkPassword(first); errors.Is(err, ErrWrongPassword) {
+			return nil, ErrWrongPassword
+		}
+	}
+	return d, nil
+}
+
+type decryptingZip struct {
+	r        *zip.Reader
+	password []byte
+	// files are the encrypted entries by name.
+	files map[string]*zip.File
+}
+
+func (d *decryptingZip) Open(name string) (fs.File, error) {
+	f, ok := d.files[name]
+	if !ok {
+		return d.r.Open(name)
+	}
+	data, err := d.decrypt(f)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &memFile{info: memInfo{name: path.Base(name), size: int64(len(data))}, r: bytes.NewReader(data)}, nil
+}
+
+// checkPassword reads only the encryption header of f and returns
+// ErrWrongPassword when the password doesn't match it.
+func (d *decryptingZip) checkPassword(f *zip.File) error {
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return err
+	}
+	if f.Method == methodAES {
+		_, _, err = aesKeys(f, raw, d.password)
+	} else {
+		_, err = decryptZipCrypto(f, raw, d.password)
+	}
+	return err
+}
+
+// decrypt returns the decrypted, decompressed contents of f. Entries over
+// maxFileSize, or whose compressed data couldn't hold less, are rejected
+// before anything is allocated, as the sizes come from the archive.
+func (d *decryptingZip) decrypt(f *zip.File) ([]byte, error) {
+	// Deflate grows incompressible data by a few bytes per 64KB block.
+	maxCompressed := uint64(maxFileSize + maxFileSize/1024 + maxEncryptionOverhead)
+	if f.UncompressedSize64 > uint64(maxFileSize) || f.CompressedSize64 > maxCompressed {
+		return nil, errFileTooLarge
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	method := f.Method
+	var plain io.Reader
+	if f.Method == methodAES {
+		if method, plain, err = decryptAES(f, raw, d.password); err != nil {
+			return nil, err
+		}
+	} else if plain, err = decryptZipCrypto(f, raw, d.password); err != nil {
+		return nil, err
+	}
+
+	var content io.Reader
+	switch method {
+	case zip.Store:
+		content = plain
+	case zip.Deflate:
+		fr := flate.NewReader(plain)
+		defer fr.Close()
+		content = fr
+	default:
+		return nil, zip.ErrAlgorithm
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, int64(f.UncompressedSize64)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", zip.ErrFormat, err)
+	}
+	if uint64(len(data)) != f.UncompressedSize64 {
+		return nil, zip.ErrFormat
+	}
+	// AES entries are authenticated instead and usually carry no CRC.
+	if f.Method != methodAES && crc32.ChecksumIEEE(data) != f.CRC32 {
+		return nil, zip.ErrChecksum
+	}
+	return data, nil
+}
+
+// zipCryptoKeys is the state of the traditional PKWARE cipher.
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password []byte) *zipCryptoKeys {
+	k := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for _, b := range password {
+		k.update(b)
+	}
+	return k
+}
+
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ crc>>8
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32Update(k[0], b)
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) decryptByte(c byte) byte {
+	t := k[2] | 2
+	plain := c ^ byte(t*(t^1)>>8)
+	k.update(plain)
+	return plain
+}
+
+type zipCryptoReader struct {
+	r    io.Reader
+	keys *zipCryptoKeys
+}
+
+func (z *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	for i := range p[:n] {
+		p[i] = z.keys.decryptByte(p[i])
+	}
+	return n, err
+}
+
+// decryptZipCrypto checks the password against the entry's encryption
+// header and returns a reader of the decrypted, still compressed, data.
+func decryptZipCrypto(f *zip.File, raw io.Reader, password []byte) (io.Reader, error) {
+	keys := newZipCryptoKeys(password)
+	header := make([]byte, zipCryptoHeaderLen)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, fmt.Errorf("%w: %w", zip.ErrFormat, err)
+	}
+	for i := range header {
+		header[i] = keys.decryptByte(header[i])
+	}
+
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&flagDataDescriptor != 0 {
+		check = byte(f.ModifiedTime >> 8)
+	}
+	if header[zipCryptoHeaderLen-1] != check {
+		return nil, ErrWrongPassword
+	}
+	return &zipCryptoReader{r: raw, keys: keys}, nil
+}
+
+// aesKeyLengths maps the strength byte of the AES extra field to the key
+// length in bytes; the salt is half as long.
+var aesKeyLengths = map[byte]int{1: 16, 2: 24, 3: 32}
+
+// decryptAES checks the password of a WinZip AES entry and its
+// authentication code, and returns the real compression method and a
+// reader of the decrypted, still compressed, data.
+func decryptAES(f *zip.File, raw io.Reader, password []byte) (uint16, io.Reader, error) {
+	keys, method, err := aesKeys(f, raw, password)
+	if err != nil {
+		return 0, nil, err
+	}
+	keyLen := (len(keys) - aesVerifierLen) / 2
+	dataLen := int64(f.CompressedSize64) - int64(keyLen/2+aesVerifierLen+aesAuthCodeLen)
+	if dataLen < 0 {
+		return 0, nil, zip.ErrFormat
+	}
+
+	ciphertext := make([]byte, dataLen)
+	if _, err := io.ReadFull(raw, ciphertext); err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", zip.ErrFormat, err)
+	}
+	authCode := make([]byte, aesAuthCodeLen)
+	if _, err := io.ReadFull(raw, authCode); err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", zip.ErrFormat, err)
+	}
+	mac := hmac.New(sha1.New, keys[keyLen:2*keyLen])
+	mac.Write(ciphertext)
+	if !hmac.Equal(mac.Sum(nil)[:aesAuthCodeLen], authCode) {
+		return 0, nil, zip.ErrChecksum
+	}
+
+	block, err := aes.NewCipher(keys[:keyLen])
+	if err != nil {
+		return 0, nil, err
+	}
+	// WinZip AES runs CTR mode with a little-endian counter starting at 1,
+	// which crypto/cipher's big-endian CTR can't express.
+	var counter, stream [aes.BlockSize]byte
+	for i := range ciphertext {
+		if i%aes.BlockSize == 0 {
+			binary.LittleEndian.PutUint64(counter[:8], uint64(i/aes.BlockSize)+1)
+			block.Encrypt(stream[:], counter[:])
+		}
+		ciphertext[i] ^= stream[i%aes.BlockSize]
+	}
+	return method, bytes.NewReader(ciphertext), nil
+}
+
+// aesKeys reads the salt and password verifier of a WinZip AES entry, checks
+// the password against the verifier and returns the derived key material,
+// the encryption key, authentication key and verifier in turn, and the
+// entry's real compression method.
+func aesKeys(f *zip.File, raw io.Reader, password []byte) ([]byte, uint16, error) {
+	strength, method, ok := aesExtra(f.Extra)
+	keyLen, known := aesKeyLengths[strength]
+	if !ok || !known {
+		return nil, 0, zip.ErrFormat
+	}
+	saltLen := keyLen / 2
+
+	header := make([]byte, saltLen+aesVerifierLen)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", zip.ErrFormat, err)
+	}
+	keys := pbkdf2.Key(password, header[:saltLen], aesIterations, 2*keyLen+aesVerifierLen, sha1.New)
+	if subtle.ConstantTimeCompare(keys[2*keyLen:], header[saltLen:]) != 1 {
+		return nil, 0, ErrWrongPassword
+	}
+	return keys, method, nil
+}
+
+// aesExtra finds the WinZip AES extra field and returns its key strength and
+// the entry's real compression method.
+func aesExtra(extra []byte) (strength byte, method uint16, ok bool) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			return 0, 0, false
+		}
+		if id == aesExtraID && size >= 7 {
+			return extra[4], binary.LittleEndian.Uint16(extra[5:]), true
+		}
+		extra = extra[size:]
+	}
+	return 0, 0, false
+}
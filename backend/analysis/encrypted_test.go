@@ -0,0 +1,198 @@
+package analysis
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"sort"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// encryptZipCrypto encrypts compressed entry data with the traditional
+// PKWARE cipher, prefixed by its 12-byte header.
+func encryptZipCrypto(data []byte, crc uint32, password string) []byte {
+	keys := newZipCryptoKeys([]byte(password))
+	plain := append(make([]byte, zipCryptoHeaderLen-1), byte(crc>>24))
+	plain = append(plain, data...)
+	out := make([]byte, len(plain))
+	for i, p := range plain {
+		t := keys[2] | 2
+		out[i] = p ^ byte(t*(t^1)>>8)
+		keys.update(p)
+	}
+	return out
+}
+
+// encryptAES encrypts compressed entry data as a WinZip AES-256 entry.
+func encryptAES(data []byte, password string) []byte {
+	salt := bytes.Repeat([]byte{7}, 16)
+	keys := pbkdf2.Key([]byte(password), salt, aesIterations, 2*32+aesVerifierLen, sha1.New)
+	block, _ := aes.NewCipher(keys[:32])
+
+	ciphertext := make([]byte, len(data))
+	var counter, stream [aes.BlockSize]byte
+	for i := range data {
+		if i%aes.BlockSize == 0 {
+			binary.LittleEndian.PutUint64(counter[:8], uint64(i/aes.BlockSize)+1)
+			block.Encrypt(stream[:], counter[:])
+		}
+		ciphertext[i] = data[i] ^ stream[i%aes.BlockSize]
+	}
+	mac := hmac.New(sha1.New, keys[32:64])
+	mac.Write(ciphertext)
+
+	out := append(append([]byte{}, salt...), keys[64:]...)
+	out = append(out, ciphertext...)
+	return append(out, mac.Sum(nil)[:aesAuthCodeLen]...)
+}
+
+// createEncryptedZip builds a ZIP whose entries are encrypted with password,
+// using WinZip AES when useAES is set and ZipCrypto otherwise.
+func createEncryptedZip(t *testing.T, files map[string]string, password string, useAES bool) []byte {
+	t.Helper()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, name := range names {
+		var compressed bytes.Buffer
+		fw, _ := flate.NewWriter(&compressed, flate.DefaultCompression)
+		fw.Write([]byte(files[name]))
+		fw.Close()
+
+		header := &zip.FileHeader{
+			Name:               name,
+			Method:             zip.Deflate,
+			Flags:              flagEncrypted,
+			CRC32:              crc32.ChecksumIEEE([]byte(files[name])),
+			UncompressedSize64: uint64(len(files[name])),
+		}
+		var data []byte
+		if useAES {
+			header.Method = methodAES
+			header.CRC32 = 0
+			// AE-2, vendor "AE", AES-256, deflated.
+			header.Extra = []byte{0x01, 0x99, 7, 0, 2, 0, 'A', 'E', 3, 8, 0}
+			data = encryptAES(compressed.Bytes(), password)
+		} else {
+			data = encryptZipCrypto(compressed.Bytes(), header.CRC32, password)
+		}
+		header.CompressedSize64 = uint64(len(data))
+
+		entry, err := w.CreateRaw(header)
+		if err != nil {
+			t.Fatalf("Failed to create entry: %v", err)
+		}
+		entry.Write(data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close zip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecryptZip(t *testing.T) {
+	silenceLogs(t)
+
+	for _, useAES := range []bool{false, true} {
+		data := createEncryptedZip(t, basicExport, "s3cret", useAES)
+		zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("Failed to open zip: %v", err)
+		}
+		if !IsEncrypted(zipReader) {
+			t.Fatal("Expected the archive to be reported as encrypted")
+		}
+		if _, err := Analyze(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrPasswordRequired) {
+			t.Fatalf("Expected ErrPasswordRequired (AES %v), got %v", useAES, err)
+		}
+		if _, err := DecryptZip(zipReader, "wrong"); !errors.Is(err, ErrWrongPassword) {
+			t.Fatalf("Expected ErrWrongPassword (AES %v), got %v", useAES, err)
+		}
+
+		fsys, err := DecryptZip(zipReader, "s3cret")
+		if err != nil {
+			t.Fatalf("DecryptZip (AES %v) failed: %v", useAES, err)
+		}
+		result, err := AnalyzeFiles(fsys)
+		if err != nil {
+			t.Fatalf("AnalyzeFiles (AES %v) failed: %v", useAES, err)
+		}
+		if len(result.NonFollowers) != 1 || result.NonFollowers[0].Username != "celeb" {
+			t.Fatalf("Expected celeb as the only non-follower (AES %v), got %+v", useAES, result.NonFollowers)
+		}
+	}
+}
+
+func TestDecryptZip_Tampered(t *testing.T) {
+	silenceLogs(t)
+	data := createEncryptedZip(t, map[string]string{"a.json": "[]"}, "s3cret", true)
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open zip: %v", err)
+	}
+	f := zipReader.File[0]
+	offset, _ := f.DataOffset()
+	// Flip a byte of the ciphertext, after the salt and password verifier.
+	data[offset+16+aesVerifierLen] ^= 0xff
+
+	fsys, err := DecryptZip(zipReader, "s3cret")
+	if err != nil {
+		t.Fatalf("DecryptZip failed: %v", err)
+	}
+	if _, err := readFile(fsys, "a.json"); !errors.Is(err, zip.ErrChecksum) {
+		t.Fatalf("Expected a checksum error for tampered data, got %v", err)
+	}
+}
+
+func TestDecryptZip_Bounds(t *testing.T) {
+	silenceLogs(t)
+	defer func(limit int64) { maxFileSize = limit }(maxFileSize)
+	maxFileSize = 64
+
+	for _, useAES := range []bool{false, true} {
+		data := createEncryptedZip(t, map[string]string{
+			"a.json":    "[]",
+			"media.mp4": string(bytes.Repeat([]byte{'x'}, 1000)),
+		}, "s3cret", useAES)
+		zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("Failed to open zip: %v", err)
+		}
+		// Put the oversized entry first, as the one the password is checked on.
+		zipReader.File[0], zipReader.File[1] = zipReader.File[1], zipReader.File[0]
+
+		if _, err := DecryptZip(zipReader, "wrong"); !errors.Is(err, ErrWrongPassword) {
+			t.Fatalf("Expected ErrWrongPassword (AES %v), got %v", useAES, err)
+		}
+		fsys, err := DecryptZip(zipReader, "s3cret")
+		if err != nil {
+			t.Fatalf("DecryptZip (AES %v) failed: %v", useAES, err)
+		}
+		if _, err := readFile(fsys, "media.mp4"); !errors.Is(err, errFileTooLarge) {
+			t.Fatalf("Expected errFileTooLarge (AES %v), got %v", useAES, err)
+		}
+		if content, err := readFile(fsys, "a.json"); err != nil || string(content) != "[]" {
+			t.Fatalf("Expected a.json to decrypt (AES %v), got %q, %v", useAES, content, err)
+		}
+
+		// A small entry claiming a large compressed size is rejected
+		// before its data is read.
+		zipReader.File[1].CompressedSize64 = 1 << 40
+		if _, err := readFile(fsys, "a.json"); !errors.Is(err, errFileTooLarge) {
+			t.Fatalf("Expected errFileTooLarge for a huge compressed size (AES %v), got %v", useAES, err)
+		}
+	}
+}
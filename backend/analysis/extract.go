@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"encoding/json"
+	"io/fs"
+	"strings"
+)
+
+// ReadFollowers returns the lowercased usernames of everyone following the
+// account, mapped to the timestamp at which they followed (0 if unknown).
+// Problems with individual files are recorded in report.
+func ReadFollowers(fsys fs.FS, report *Report) map[string]int64 {
+	return followerSet(readFollowers(fsys, report))
+}
+
+// followerSet maps the lowercased usernames of followers to the timestamp at
+// which they followed.
+func followerSet(followers []Relationship) map[string]int64 {
+	set := make(map[string]int64, len(followers))
+	for _, rel := range followers {
+		set[strings.ToLower(rel.Username)] = rel.FollowedAt
+	}
+	return set
+}
+
+// readFollowers returns everyone following the account, once each in the
+// order of the export.
+func readFollowers(fsys fs.FS, report *Report) []Relationship {
+	var followers []Relationship
+	seen := make(map[string]bool)
+	add := func(rel Relationship) {
+		key := strings.ToLower(rel.Username)
+		if rel.Username == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		followers = append(followers, rel)
+	}
+	files, total := candidateFiles(fsys, isFollowersFile)
+
+	debugf("extractFollowers: %d of %d files are followers candidates", len(files), total)
+
+	for _, fileName := range files {
+		content, err := readFile(fsys, fileName)
+		if err != nil {
+			report.unreadable(fileName, err)
+			continue
+		}
+		report.processed(fileName, kindFollowers, content)
+
+		var relationships []InstagramRelationship
+		if err := json.Unmarshal(content, &relationships); err == nil {
+			debugf("extractFollowers: parsed %s as []InstagramRelationship with %d items", fileName, len(relationships))
+			for _, entry := range relationships {
+				add(toRelationship(entry, fileName))
+			}
+			continue
+		} else {
+			debugf("extractFollowers: failed to parse %s as []InstagramRelationship: %v", fileName, err)
+		}
+
+		var singleRel InstagramRelationship
+		if err := json.Unmarshal(content, &singleRel); err == nil {
+			debugf("extractFollowers: parsed %s as single InstagramRelationship", fileName)
+			add(toRelationship(singleRel, fileName))
+		} else {
+			debugf("extractFollowers: failed to parse %s as single InstagramRelationship: %v", fileName, err)
+			debugf("extractFollowers: content preview: %.500s", string(content))
+		}
+	}
+
+	debugf("extractFollowers: found %d total followers", len(followers))
+	return followers
+}
+
+func readFollowing(fsys fs.FS, report *Report) []Relationship {
+	var following []Relationship
+	seen := newEntrySet()
+	files, total := candidateFiles(fsys, isFollowingFile)
+
+	debugf("extractFollowing: %d of %d files are following candidates", len(files), total)
+
+	for _, fileName := range files {
+		content, err := readFile(fsys, fileName)
+		if err != nil {
+			report.unreadable(fileName, err)
+			continue
+		}
+		report.processed(fileName, kindFollowing, content)
+
+		var followingData FollowingData
+		if err := json.Unmarshal(content, &followingData); err == nil {
+			debugf("extractFollowing: parsed %s as FollowingData with %d relationships", fileName, len(followingData.RelationshipsFollowing))
+			for _, entry := range followingData.RelationshipsFollowing {
+				if rel := toRelationship(entry, fileName); rel.Username != "" && seen.add(kindFollowing, rel.Username, rel.FollowedAt) {
+					following = append(following, rel)
+				}
+			}
+			// Large accounts get following_1.json, following_2.json, etc.;
+			// keep going to merge every part.
+			continue
+		} else {
+			debugf("extractFollowing: failed to parse %s as FollowingData: %v", fileName, err)
+		}
+
+		var relationships []InstagramRelationship
+		if err := json.Unmarshal(content, &relationships); err == nil {
+			debugf("extractFollowing: parsed %s as []InstagramRelationship with %d items", fileName, len(relationships))
+			for _, entry := range relationships {
+				if rel := toRelationship(entry, fileName); rel.Username != "" && seen.add(kindFollowing, rel.Username, rel.FollowedAt) {
+					following = append(following, rel)
+				}
+			}
+		} else {
+			debugf("extractFollowing: failed to parse %s as []InstagramRelationship: %v", fileName, err)
+			debugf("extractFollowing: content preview: %.500s", string(content))
+		}
+	}
+
+	seen.report(report, kindFollowing)
+	debugf("extractFollowing: found %d total following", len(following))
+	return following
+}
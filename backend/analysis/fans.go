@@ -0,0 +1,42 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fan is a follower the owner doesn't follow back.
+type Fan struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+	ProfileURL  string `json:"profile_url"`
+	// FollowedAt is when the account followed the owner, when the export
+	// records it.
+	FollowedAt int64 `json:"followed_at,omitempty"`
+	// Source is the path of the export file the entry was read from.
+	Source string `json:"source,omitempty"`
+}
+
+// findFans returns the followers that aren't in the following list, in the
+// order of the followers list.
+func findFans(followers, following []Relationship) []Fan {
+	followed := make(map[string]bool, len(following))
+	for _, rel := range following {
+		followed[strings.ToLower(rel.Username)] = true
+	}
+
+	var fans []Fan
+	for _, rel := range followers {
+		if followed[strings.ToLower(rel.Username)] {
+			continue
+		}
+		fans = append(fans, Fan{
+			Username:    rel.Username,
+			DisplayName: rel.DisplayName,
+			ProfileURL:  fmt.Sprintf("https://instagram.com/%s", rel.Username),
+			FollowedAt:  rel.FollowedAt,
+			Source:      rel.Source,
+		})
+	}
+	return fans
+}
@@ -0,0 +1,71 @@
+package analysis
+
+import "testing"
+
+func TestReadFollowers_Records(t *testing.T) {
+	silenceLogs(t)
+	fsys := mapFS(map[string]string{
+		"connections/followers_and_following/followers_1.json": `[
+			{"string_list_data": [{"href": "https://www.instagram.com/friend", "value": "friend", "timestamp": 1600000000}]},
+			{"string_list_data": [{"href": "https://www.instagram.com/fan", "value": "A Fan", "timestamp": 1650000000}]}
+		]`,
+		"connections/followers_and_following/followers_2.json": `[
+			{"string_list_data": [{"href": "https://www.instagram.com/Friend", "value": "friend", "timestamp": 1600000000}]}
+		]`,
+	})
+
+	followers := readFollowers(fsys, &Report{})
+	if len(followers) != 2 {
+		t.Fatalf("Expected 2 followers once each, got %+v", followers)
+	}
+	fan := followers[1]
+	if fan.Username != "fan" || fan.DisplayName != "A Fan" || fan.FollowedAt != 1650000000 || fan.Href == "" {
+		t.Fatalf("Expected the full record of fan, got %+v", fan)
+	}
+	if set := followerSet(followers); len(set) != 2 || set["friend"] != 1600000000 {
+		t.Fatalf("Unexpected follower set %v", set)
+	}
+}
+
+func TestFindFans(t *testing.T) {
+	followers := []Relationship{
+		{Username: "Friend", FollowedAt: 1600000000},
+		{Username: "fan", DisplayName: "A Fan", FollowedAt: 1650000000, Source: "followers_1.json"},
+	}
+	following := []Relationship{{Username: "friend"}, {Username: "celeb"}}
+
+	fans := findFans(followers, following)
+	want := Fan{Username: "fan", DisplayName: "A Fan", ProfileURL: "https://instagram.com/fan", FollowedAt: 1650000000, Source: "followers_1.json"}
+	if len(fans) != 1 || fans[0] != want {
+		t.Fatalf("Expected %+v as the only fan, got %+v", want, fans)
+	}
+}
+
+func TestAnalyzeFiles_Fans(t *testing.T) {
+	silenceLogs(t)
+	export := map[string]string{
+		"connections/followers_and_following/followers_1.json": `[
+			{"string_list_data": [{"href": "https://www.instagram.com/friend", "value": "friend", "timestamp": 1600000000}]},
+			{"string_list_data": [{"href": "https://www.instagram.com/fan", "value": "fan", "timestamp": 1650000000}]}
+		]`,
+		"connections/followers_and_following/following.json": basicExport["connections/followers_and_following/following.json"],
+	}
+
+	result, err := AnalyzeFiles(mapFS(export))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if result.Fans != nil {
+		t.Fatalf("Expected no fans unless asked for, got %+v", result.Fans)
+	}
+
+	analyzer := Default
+	analyzer.Fans = true
+	result, err = analyzer.AnalyzeFiles(mapFS(export))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if len(result.Fans) != 1 || result.Fans[0].Username != "fan" || result.TotalFollowers != 2 {
+		t.Fatalf("Expected fan as the only fan of 2 followers, got %+v", result.Fans)
+	}
+}
@@ -0,0 +1,41 @@
+package analysis
+
+import "time"
+
+// DefaultStaleAfter is how old the newest follow in an export may be before
+// the export is reported as stale when the Analyzer doesn't set a limit.
+const DefaultStaleAfter = 30 * 24 * time.Hour
+
+// newestTimestamp returns the most recent follow time recorded anywhere in
+// the export, which is the best available estimate of when it was taken.
+func newestTimestamp(following []Relationship, followers map[string]int64, pending []Relationship) int64 {
+	var newest int64
+	for _, rel := range following {
+		newest = max(newest, rel.FollowedAt)
+	}
+	for _, followedAt := range followers {
+		newest = max(newest, followedAt)
+	}
+	for _, rel := range pending {
+		newest = max(newest, rel.FollowedAt)
+	}
+	return newest
+}
+
+// checkFreshness warns when the newest activity in the export is older than
+// staleAfter, since results from a months-old export are easily mistaken for
+// the current state of the account. It returns the "data as of" date, or an
+// empty string when the export has no timestamps.
+func checkFreshness(newest int64, staleAfter time.Duration, now time.Time, report *Report) string {
+	if newest == 0 {
+		return ""
+	}
+	asOf := time.Unix(newest, 0).UTC()
+	date := asOf.Format("2006-01-02")
+	if age := now.Sub(asOf); age > staleAfter {
+		report.warn(WarnStaleExport, "",
+			"This export contains no activity after %s (%d days ago), so the results may be out of date. Request a new export from Instagram for current results.",
+			date, int(age/(24*time.Hour)))
+	}
+	return date
+}
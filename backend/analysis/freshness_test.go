@@ -0,0 +1,46 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewestTimestamp(t *testing.T) {
+	following := []Relationship{{FollowedAt: unix(2023, 5)}, {}}
+	followers := map[string]int64{"a": unix(2024, 2), "b": 0}
+	pending := []Relationship{{FollowedAt: unix(2024, 6)}}
+
+	if got := newestTimestamp(following, followers, nil); got != unix(2024, 2) {
+		t.Fatalf("Expected the newest follower timestamp, got %d", got)
+	}
+	if got := newestTimestamp(following, followers, pending); got != unix(2024, 6) {
+		t.Fatalf("Expected the pending request timestamp, got %d", got)
+	}
+	if got := newestTimestamp(nil, nil, nil); got != 0 {
+		t.Fatalf("Expected 0 without timestamps, got %d", got)
+	}
+}
+
+func TestCheckFreshness(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	report := &Report{}
+	if date := checkFreshness(unix(2024, 12), DefaultStaleAfter, now.AddDate(0, 0, -14), report); date != "2024-12-01" || len(report.Warnings) != 0 {
+		t.Fatalf("Expected a fresh export as of 2024-12-01, got %q and %+v", date, report.Warnings)
+	}
+
+	report = &Report{}
+	if date := checkFreshness(unix(2024, 6), DefaultStaleAfter, now, report); date != "2024-06-01" || len(report.Warnings) != 1 || report.Warnings[0].Code != WarnStaleExport {
+		t.Fatalf("Expected a stale_export warning, got %q and %+v", date, report.Warnings)
+	}
+
+	report = &Report{}
+	if date := checkFreshness(unix(2024, 6), 365*24*time.Hour, now, report); len(report.Warnings) != 0 {
+		t.Fatalf("Expected a longer limit to accept the export, got %q and %+v", date, report.Warnings)
+	}
+
+	report = &Report{}
+	if date := checkFreshness(0, DefaultStaleAfter, now, report); date != "" || len(report.Warnings) != 0 {
+		t.Fatalf("Expected no date or warning without timestamps, got %q and %+v", date, report.Warnings)
+	}
+}
@@ -0,0 +1,191 @@
+package analysis
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MemFS returns a read-only fs.FS over files, which maps slash-separated
+// paths such as "connections/followers_and_following/following.json" to
+// their contents. Directories are implied by the paths. It is how exports
+// that don't arrive as a ZIP, such as tar archives or individually uploaded
+// files, are fed to AnalyzeFiles.
+func MemFS(files map[string][]byte) (fs.FS, error) {
+	m := memFS{files: make(map[string][]byte, len(files)), dirs: map[string][]string{}}
+	for name, data := range files {
+		if !fs.ValidPath(name) || name == "." {
+			return nil, fmt.Errorf("invalid file name %q", name)
+		}
+		m.files[name] = data
+	}
+	for name := range m.files {
+		for child := name; child != "."; {
+			parent := path.Dir(child)
+			if _, isFile := m.files[parent]; isFile {
+				return nil, fmt.Errorf("%q is both a file and a directory", parent)
+			}
+			known := m.dirs[parent] != nil
+			m.dirs[parent] = append(m.dirs[parent], path.Base(child))
+			if known {
+				break
+			}
+			child = parent
+		}
+	}
+	for dir, entries := range m.dirs {
+		sort.Strings(entries)
+		m.dirs[dir] = compactStrings(entries)
+	}
+	if len(m.dirs) == 0 {
+		m.dirs["."] = []string{}
+	}
+	return m, nil
+}
+
+func compactStrings(sorted []string) []string {
+	out := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+type memFS struct {
+	files map[string][]byte
+	// dirs maps each directory to the sorted names of its children.
+	dirs map[string][]string
+}
+
+func (m memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if data, ok := m.files[name]; ok {
+		return &memFile{info: memInfo{name: path.Base(name), size: int64(len(data))}, r: bytes.NewReader(data)}, nil
+	}
+	if _, ok := m.dirs[name]; ok {
+		return &memDir{fsys: m, name: name}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+type memInfo struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.isDir }
+func (i memInfo) Sys() any           { return nil }
+func (i memInfo) Mode() fs.FileMode {
+	if i.isDir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+type memFile struct {
+	info memInfo
+	r    *bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Read(p []byte) (int, error) { return f.r.Read(p) }
+func (f *memFile) Close() error               { return nil }
+
+type memDir struct {
+	fsys   memFS
+	name   string
+	offset int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) {
+	return memInfo{name: path.Base(d.name), isDir: true}, nil
+}
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *memDir) Close() error { return nil }
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	names := d.fsys.dirs[d.name][d.offset:]
+	if n > 0 && len(names) > n {
+		names = names[:n]
+	}
+	if n > 0 && len(names) == 0 {
+		return nil, io.EOF
+	}
+	d.offset += len(names)
+
+	entries := make([]fs.DirEntry, 0, len(names))
+	for _, base := range names {
+		full := path.Join(d.name, base)
+		info := memInfo{name: base, isDir: true}
+		if data, ok := d.fsys.files[full]; ok {
+			info = memInfo{name: base, size: int64(len(data))}
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	return entries, nil
+}
+
+// FromTar reads a tar archive, optionally gzip-compressed, into an fs.FS.
+// Only regular files are kept, and only the first limit bytes of content
+// are accepted in total, so a small compressed archive can't expand without
+// bound.
+func FromTar(r io.Reader, limit int64) (fs.FS, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	files := make(map[string][]byte)
+	remaining := limit
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > remaining {
+			return nil, fmt.Errorf("tar archive exceeds %d bytes", limit)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, header.Size))
+		if err != nil {
+			return nil, err
+		}
+		remaining -= int64(len(data))
+		files[strings.TrimPrefix(path.Clean(header.Name), "./")] = data
+	}
+	return MemFS(files)
+}
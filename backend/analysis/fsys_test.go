@@ -0,0 +1,120 @@
+package analysis
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMemFS(t *testing.T) {
+	fsys, err := MemFS(map[string][]byte{
+		"connections/followers_and_following/followers_1.json": []byte("[]"),
+		"connections/followers_and_following/following.json":   []byte("{}"),
+		"media/1.jpg": nil,
+	})
+	if err != nil {
+		t.Fatalf("MemFS failed: %v", err)
+	}
+	if err := fstest.TestFS(fsys,
+		"connections/followers_and_following/followers_1.json",
+		"connections/followers_and_following/following.json",
+		"media/1.jpg",
+	); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemFS_InvalidNames(t *testing.T) {
+	for _, files := range []map[string][]byte{
+		{"../escape.json": nil},
+		{"/absolute.json": nil},
+		{"a": nil, "a/b": nil},
+	} {
+		if _, err := MemFS(files); err == nil {
+			t.Errorf("Expected an error for %v", files)
+		}
+	}
+}
+
+func createTestTar(t *testing.T, files map[string]string, compress bool) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	var gz *gzip.Writer
+	var tw *tar.Writer
+	if compress {
+		gz = gzip.NewWriter(buf)
+		tw = tar.NewWriter(gz)
+	} else {
+		tw = tar.NewWriter(buf)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "./connections/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatalf("Failed to write tar header: %v", err)
+	}
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: "./" + name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatalf("Failed to close gzip writer: %v", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestFromTar(t *testing.T) {
+	silenceLogs(t)
+
+	for _, compress := range []bool{false, true} {
+		fsys, err := FromTar(bytes.NewReader(createTestTar(t, basicExport, compress)), 1<<20)
+		if err != nil {
+			t.Fatalf("FromTar (gzip %v) failed: %v", compress, err)
+		}
+		result, err := AnalyzeFiles(fsys)
+		if err != nil {
+			t.Fatalf("AnalyzeFiles (gzip %v) failed: %v", compress, err)
+		}
+		if len(result.NonFollowers) != 1 || result.NonFollowers[0].Username != "celeb" {
+			t.Fatalf("Expected celeb as the only non-follower, got %+v", result.NonFollowers)
+		}
+	}
+}
+
+func TestFromTar_Limit(t *testing.T) {
+	if _, err := FromTar(bytes.NewReader(createTestTar(t, basicExport, true)), 100); err == nil {
+		t.Fatal("Expected an error for a tar archive over the limit")
+	}
+}
+
+func TestAnalyzeFiles_DirFS(t *testing.T) {
+	silenceLogs(t)
+	dir := t.TempDir()
+	for name, content := range basicExport {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := AnalyzeFiles(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if result.TotalFollowers != 1 || result.TotalFollowing != 2 {
+		t.Fatalf("Expected 1 follower and 2 following, got %d and %d", result.TotalFollowers, result.TotalFollowing)
+	}
+}
@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Insight is a human-readable finding about the account, for the frontend
+// to render as a card.
+type Insight struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+// insightInput is what the insight rules work from.
+type insightInput struct {
+	following    []Relationship
+	followers    map[string]int64
+	nonFollowers []NonFollower
+	now          time.Time
+}
+
+// insightRule produces one insight, or false when it has nothing to say
+// about this account.
+type insightRule func(in insightInput) (Insight, bool)
+
+// insightRules run in order; the order of the insights array follows it.
+var insightRules = []insightRule{
+	staleNonFollowersInsight,
+	followBackRateInsight,
+	mutualsFirstInsight,
+	fansInsight,
+}
+
+// buildInsights runs every rule against the analysis results.
+func buildInsights(following []Relationship, followers map[string]int64, nonFollowers []NonFollower, now time.Time) []Insight {
+	in := insightInput{following: following, followers: followers, nonFollowers: nonFollowers, now: now}
+	insights := []Insight{}
+	for _, rule := range insightRules {
+		if insight, ok := rule(in); ok {
+			insights = append(insights, insight)
+		}
+	}
+	return insights
+}
+
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}
+
+func staleNonFollowersInsight(in insightInput) (Insight, bool) {
+	stale := 0
+	for _, nf := range in.nonFollowers {
+		if nf.FollowedAt != 0 && time.Unix(nf.FollowedAt, 0).Before(in.now.AddDate(-2, 0, 0)) {
+			stale++
+		}
+	}
+	if stale == 0 {
+		return Insight{}, false
+	}
+	return Insight{
+		Code:    "stale_non_followers",
+		Message: fmt.Sprintf("You follow %d %s that %s followed you back in over 2 years.", stale, plural(stale, "account", "accounts"), plural(stale, "hasn't", "haven't")),
+		Count:   stale,
+	}, true
+}
+
+func followBackRateInsight(in insightInput) (Insight, bool) {
+	if len(in.following) == 0 {
+		return Insight{}, false
+	}
+	mutuals := len(in.following) - len(in.nonFollowers)
+	percent := mutuals * 100 / len(in.following)
+	return Insight{
+		Code:    "follow_back_rate",
+		Message: fmt.Sprintf("%d%% of the accounts you follow follow you back.", percent),
+		Count:   mutuals,
+	}, true
+}
+
+// mutualsFirstInsight looks at who made the first move among mutuals whose
+// follow dates are both known.
+func mutualsFirstInsight(in insightInput) (Insight, bool) {
+	theyFirst, known := 0, 0
+	for _, rel := range in.following {
+		followedYou, ok := in.followers[strings.ToLower(rel.Username)]
+		if !ok || followedYou == 0 || rel.FollowedAt == 0 || followedYou == rel.FollowedAt {
+			continue
+		}
+		known++
+		if followedYou < rel.FollowedAt {
+			theyFirst++
+		}
+	}
+
+	switch {
+	case known == 0 || theyFirst*2 == known:
+		return Insight{}, false
+	case theyFirst*2 > known:
+		return Insight{
+			Code:    "mutuals_followed_first",
+			Message: fmt.Sprintf("Most of your mutuals followed you first (%d of %d).", theyFirst, known),
+			Count:   theyFirst,
+		}, true
+	default:
+		return Insight{
+			Code:    "you_followed_first",
+			Message: fmt.Sprintf("You followed most of your mutuals first (%d of %d).", known-theyFirst, known),
+			Count:   known - theyFirst,
+		}, true
+	}
+}
+
+func fansInsight(in insightInput) (Insight, bool) {
+	followed := make(map[string]bool, len(in.following))
+	for _, rel := range in.following {
+		followed[strings.ToLower(rel.Username)] = true
+	}
+	fans := 0
+	for username := range in.followers {
+		if !followed[username] {
+			fans++
+		}
+	}
+	if fans == 0 {
+		return Insight{}, false
+	}
+	return Insight{
+		Code:    "fans",
+		Message: fmt.Sprintf("%d %s you that you don't follow back.", fans, plural(fans, "person follows", "people follow")),
+		Count:   fans,
+	}, true
+}
@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildInsights(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	following := []Relationship{
+		{Username: "old_crush", FollowedAt: unix(2020, 1)},
+		{Username: "recent", FollowedAt: unix(2024, 11)},
+		{Username: "friend_a", FollowedAt: unix(2021, 6)},
+		{Username: "friend_b", FollowedAt: unix(2021, 6)},
+	}
+	followers := map[string]int64{
+		"friend_a": unix(2021, 1),
+		"friend_b": unix(2021, 2),
+		"fan":      unix(2022, 1),
+	}
+	nonFollowers := findNonFollowers(following, followers)
+
+	insights := buildInsights(following, followers, nonFollowers, now)
+
+	want := []Insight{
+		{Code: "stale_non_followers", Count: 1},
+		{Code: "follow_back_rate", Count: 2},
+		{Code: "mutuals_followed_first", Count: 2},
+		{Code: "fans", Count: 1},
+	}
+	if len(insights) != len(want) {
+		t.Fatalf("Expected %d insights, got %+v", len(want), insights)
+	}
+	for i, w := range want {
+		if insights[i].Code != w.Code || insights[i].Count != w.Count || insights[i].Message == "" {
+			t.Errorf("Insight %d: expected %s with count %d, got %+v", i, w.Code, w.Count, insights[i])
+		}
+	}
+	if got := insights[1].Message; got != "50% of the accounts you follow follow you back." {
+		t.Errorf("Unexpected follow-back message %q", got)
+	}
+}
+
+func TestBuildInsights_NothingToSay(t *testing.T) {
+	following := []Relationship{{Username: "friend"}}
+	followers := map[string]int64{"friend": 0}
+
+	insights := buildInsights(following, followers, findNonFollowers(following, followers), time.Now())
+
+	if len(insights) != 1 || insights[0].Code != "follow_back_rate" {
+		t.Fatalf("Expected only the follow-back rate without timestamps, got %+v", insights)
+	}
+}
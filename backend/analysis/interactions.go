@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"io/fs"
+	"log"
+	"strings"
+)
+
+// Interaction summarizes how the account owner engaged with another
+// account's content, from the likes and comments in the export. Instagram
+// exports don't record other people's likes or comments on the owner's
+// posts, so only the owner's side is known.
+type Interaction struct {
+	Likes           int   `json:"likes"`
+	Comments        int   `json:"comments"`
+	LastLikedAt     int64 `json:"last_liked_at,omitempty"`
+	LastCommentedAt int64 `json:"last_commented_at,omitempty"`
+	// LastInteractionAt is the later of LastLikedAt and LastCommentedAt.
+	LastInteractionAt int64 `json:"last_interaction_at,omitempty"`
+}
+
+func (i *Interaction) like(at int64) {
+	i.Likes++
+	i.LastLikedAt = max(i.LastLikedAt, at)
+	i.LastInteractionAt = max(i.LastInteractionAt, at)
+}
+
+func (i *Interaction) comment(at int64) {
+	i.Comments++
+	i.LastCommentedAt = max(i.LastCommentedAt, at)
+	i.LastInteractionAt = max(i.LastInteractionAt, at)
+}
+
+// commentEntry is one entry of a comments file. Its fields are labelled
+// with display strings rather than keys.
+type commentEntry struct {
+	StringMapData map[string]struct {
+		Value     string `json:"value"`
+		Timestamp int64  `json:"timestamp"`
+	} `json:"string_map_data"`
+}
+
+// readInteractions returns the owner's likes and comments on other accounts'
+// content, keyed by lowercased username. It returns a missingFilesError when
+// the export has neither likes nor comments.
+func readInteractions(fsys fs.FS, report *Report) (map[string]*Interaction, error) {
+	interactions := make(map[string]*Interaction)
+	get := func(username string) *Interaction {
+		key := strings.ToLower(username)
+		if interactions[key] == nil {
+			interactions[key] = &Interaction{}
+		}
+		return interactions[key]
+	}
+
+	seen := newEntrySet()
+	likes, _ := candidateFiles(fsys, ofKind(kindLikes))
+	comments, _ := candidateFiles(fsys, ofKind(kindComments))
+	if len(likes) == 0 && len(comments) == 0 {
+		return nil, &missingFilesError{files: "liked posts or comments"}
+	}
+
+	for _, name := range likes {
+		content, err := readFile(fsys, name)
+		if err != nil {
+			report.unreadable(name, err)
+			continue
+		}
+		report.processed(name, kindLikes, content)
+		entries, err := decodeList[InstagramRelationship](content)
+		if err != nil {
+			log.Printf("Error parsing %s: %v", logPath(name), err)
+			continue
+		}
+		// The title of a like is the owner of the liked post; its link
+		// points at the post, not the profile.
+		for _, entry := range entries {
+			if entry.Title == "" || !isHandle(entry.Title) {
+				continue
+			}
+			var at int64
+			if len(entry.StringListData) > 0 {
+				at = entry.StringListData[0].Timestamp
+			}
+			if seen.add(kindLikes, entry.Title, at) {
+				get(entry.Title).like(at)
+			}
+		}
+	}
+
+	for _, name := range comments {
+		content, err := readFile(fsys, name)
+		if err != nil {
+			report.unreadable(name, err)
+			continue
+		}
+		report.processed(name, kindComments, content)
+		entries, err := decodeList[commentEntry](content)
+		if err != nil {
+			log.Printf("Error parsing %s: %v", logPath(name), err)
+			continue
+		}
+		for _, entry := range entries {
+			owner := entry.StringMapData["Media Owner"].Value
+			at := entry.StringMapData["Time"].Timestamp
+			if owner == "" || !isHandle(owner) || !seen.add(kindComments, owner, at) {
+				continue
+			}
+			get(owner).comment(at)
+		}
+	}
+
+	seen.report(report, kindLikes, kindComments)
+	return interactions, nil
+}
+
+// attachInteractions sets the interaction summary of each non-follower the
+// owner has engaged with.
+func attachInteractions(nonFollowers []NonFollower, interactions map[string]*Interaction) {
+	for i := range nonFollowers {
+		nonFollowers[i].Interaction = interactions[strings.ToLower(nonFollowers[i].Username)]
+	}
+}
@@ -0,0 +1,61 @@
+package analysis
+
+import "testing"
+
+func TestReadInteractions(t *testing.T) {
+	silenceLogs(t)
+	fsys := mapFS(map[string]string{
+		"your_instagram_activity/likes/liked_posts.json": `{"likes_media_likes": [
+			{"title": "celeb", "string_list_data": [{"href": "https://www.instagram.com/p/abc/", "value": "👍", "timestamp": 1600000000}]},
+			{"title": "Celeb", "string_list_data": [{"href": "https://www.instagram.com/p/def/", "value": "👍", "timestamp": 1700000000}]},
+			{"title": "", "string_list_data": [{"href": "https://www.instagram.com/p/ghi/", "timestamp": 1700000000}]}
+		]}`,
+		"your_instagram_activity/comments/post_comments_1.json": `[
+			{"string_map_data": {"Comment": {"value": "nice"}, "Media Owner": {"value": "celeb"}, "Time": {"timestamp": 1650000000}}},
+			{"string_map_data": {"Comment": {"value": "wow"}, "Media Owner": {"value": "friend"}, "Time": {"timestamp": 1660000000}}}
+		]`,
+	})
+
+	report := &Report{}
+	interactions, err := readInteractions(fsys, report)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	celeb := interactions["celeb"]
+	if celeb == nil || celeb.Likes != 2 || celeb.Comments != 1 {
+		t.Fatalf("Expected 2 likes and 1 comment for celeb, got %+v", celeb)
+	}
+	if celeb.LastLikedAt != 1700000000 || celeb.LastCommentedAt != 1650000000 || celeb.LastInteractionAt != 1700000000 {
+		t.Fatalf("Expected the latest timestamps for celeb, got %+v", celeb)
+	}
+	if friend := interactions["friend"]; friend == nil || friend.Likes != 0 || friend.LastInteractionAt != 1660000000 {
+		t.Fatalf("Expected one comment for friend, got %+v", friend)
+	}
+	if len(interactions) != 2 {
+		t.Fatalf("Expected entries without an owner to be skipped, got %v", interactions)
+	}
+	if len(report.files) != 2 {
+		t.Fatalf("Expected both files in the receipt, got %+v", report.files)
+	}
+}
+
+func TestAnalyzeFiles_Interactions(t *testing.T) {
+	silenceLogs(t)
+	files := map[string]string{
+		"your_instagram_activity/likes/liked_posts.json": `{"likes_media_likes": [
+			{"title": "celeb", "string_list_data": [{"href": "https://www.instagram.com/p/abc/", "timestamp": 1600000000}]}
+		]}`,
+	}
+	for name, content := range basicExport {
+		files[name] = content
+	}
+
+	result, err := AnalyzeFiles(mapFS(files))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if len(result.NonFollowers) != 1 || result.NonFollowers[0].Interaction == nil || result.NonFollowers[0].Interaction.Likes != 1 {
+		t.Fatalf("Expected celeb's like to be attached, got %+v", result.NonFollowers)
+	}
+}
@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Diagnosis is attached to ErrNoFollowing and ErrNoFollowers so the user can
+// be shown guidance in the export's own language. Locale is empty when the
+// export's language couldn't be told.
+type Diagnosis struct {
+	err    error
+	Locale string
+	Hint   string
+}
+
+func (d *Diagnosis) Error() string { return d.err.Error() }
+func (d *Diagnosis) Unwrap() error { return d.err }
+
+// Hint keys.
+const (
+	HintHTMLFormat  = "html_format"
+	HintMissingData = "missing_connections"
+)
+
+// LocalizedHints holds the guidance for each hint key per language. English
+// is the fallback for languages without a translation.
+var LocalizedHints = map[string]map[string]string{
+	"en": {
+		HintHTMLFormat:  "It looks like your export is in HTML format. Request a new export and choose JSON as the format.",
+		HintMissingData: "Your export doesn't contain the followers and following lists. Request a new export with \"Followers and following\" selected and JSON as the format.",
+	},
+	"es": {
+		HintHTMLFormat:  "Parece que tu exportación está en español y en formato HTML. Solicita una nueva exportación y elige JSON como formato.",
+		HintMissingData: "Parece que tu exportación está en español, pero no contiene las listas de seguidores y seguidos. Solicita una nueva exportación con \"Seguidores y seguidos\" seleccionado y JSON como formato.",
+	},
+	"pt": {
+		HintHTMLFormat:  "Parece que sua exportação está em português e no formato HTML. Solicite uma nova exportação e escolha JSON como formato.",
+		HintMissingData: "Parece que sua exportação está em português, mas não contém as listas de seguidores e seguindo. Solicite uma nova exportação com \"Seguidores e seguindo\" selecionado e JSON como formato.",
+	},
+	"fr": {
+		HintHTMLFormat:  "Votre exportation semble être en français et au format HTML. Demandez une nouvelle exportation et choisissez le format JSON.",
+		HintMissingData: "Votre exportation semble être en français, mais elle ne contient pas les listes d'abonnés et d'abonnements. Demandez une nouvelle exportation avec « Abonnés et abonnements » sélectionné et le format JSON.",
+	},
+	"de": {
+		HintHTMLFormat:  "Dein Export scheint auf Deutsch und im HTML-Format zu sein. Fordere einen neuen Export an und wähle JSON als Format.",
+		HintMissingData: "Dein Export scheint auf Deutsch zu sein, enthält aber keine Listen der Follower und gefolgten Konten. Fordere einen neuen Export mit „Follower und Gefolgt“ und JSON als Format an.",
+	},
+	"it": {
+		HintHTMLFormat:  "Sembra che la tua esportazione sia in italiano e in formato HTML. Richiedi una nuova esportazione e scegli JSON come formato.",
+		HintMissingData: "Sembra che la tua esportazione sia in italiano, ma non contiene gli elenchi di follower e persone seguite. Richiedi una nuova esportazione selezionando \"Follower e persone seguite\" e JSON come formato.",
+	},
+}
+
+// localeFileNameHints maps words that only appear in localized export file
+// and folder names to the language they indicate.
+var localeFileNameHints = map[string]string{
+	"seguidores":  "es",
+	"seguidos":    "es",
+	"seguindo":    "pt",
+	"abonnes":     "fr",
+	"abonnés":     "fr",
+	"abonnements": "fr",
+	"abonnenten":  "de",
+	"gefolgt":     "de",
+	"seguiti":     "it",
+}
+
+var htmlLangPattern = regexp.MustCompile(`(?i)<html[^>]*\slang=["']?([a-z]{2})`)
+
+// htmlSniffSize bounds how much of an HTML file is read to find its lang
+// attribute.
+const htmlSniffSize = 4096
+
+// isHTMLRelationshipFile reports whether name is a followers or following
+// file from an HTML-format export.
+func isHTMLRelationshipFile(name string) bool {
+	base := strings.ToLower(path.Base(name))
+	return strings.HasSuffix(base, ".html") && (strings.Contains(base, "follower") || strings.Contains(base, "following"))
+}
+
+// detectExportLocale guesses the export's language from the lang attribute
+// of its HTML files, then from localized file names. It returns "" when
+// nothing points away from the default.
+func detectExportLocale(fsys fs.FS) string {
+	html, _ := candidateFiles(fsys, func(name string) bool {
+		return strings.HasSuffix(strings.ToLower(name), ".html")
+	})
+	if len(html) > 0 {
+		if lang := sniffHTMLLang(fsys, html[0]); lang != "" {
+			return lang
+		}
+	}
+
+	names, _ := candidateFiles(fsys, func(string) bool { return true })
+	for _, name := range names {
+		for _, part := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+			return r == '/' || r == '_' || r == '.' || r == '-'
+		}) {
+			if lang, ok := localeFileNameHints[part]; ok {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+func sniffHTMLLang(fsys fs.FS, name string) string {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	head, _ := io.ReadAll(io.LimitReader(f, htmlSniffSize))
+	if m := htmlLangPattern.FindSubmatch(head); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	return ""
+}
+
+// diagnoseExport wraps err, an ErrNoFollowing or ErrNoFollowers, with a hint
+// explaining the likely cause in the export's language.
+func diagnoseExport(fsys fs.FS, err error) error {
+	hint := HintMissingData
+	if html, _ := candidateFiles(fsys, isHTMLRelationshipFile); len(html) > 0 {
+		hint = HintHTMLFormat
+	}
+
+	locale := detectExportLocale(fsys)
+	messages, ok := LocalizedHints[locale]
+	if !ok {
+		messages = LocalizedHints["en"]
+	}
+	return &Diagnosis{err: err, Locale: locale, Hint: messages[hint]}
+}
@@ -0,0 +1,23 @@
+package analysis
+
+import "testing"
+
+func TestDetectExportLocale(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"html lang", map[string]string{"connections/followers_and_following/followers_1.html": `<!DOCTYPE html><html lang="es"><head>`}, "es"},
+		{"localized file name", map[string]string{"conexiones/seguidores_1.json": "[]"}, "es"},
+		{"english", map[string]string{"connections/followers_and_following/followers_1.json": "[]"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectExportLocale(mapFS(tt.files)); got != tt.want {
+				t.Fatalf("Expected locale %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package analysis
+
+import (
+	"log"
+	"path"
+	"strings"
+)
+
+// DebugLogs enables verbose logging of how each export file is parsed,
+// including the start of files that fail to parse. Export contents and
+// folder names are personal data, so leave it off outside local debugging;
+// without it only counts and bare file names are logged.
+var DebugLogs bool
+
+// debugf logs only when DebugLogs is set.
+func debugf(format string, args ...any) {
+	if DebugLogs {
+		log.Printf("[DEBUG] "+format, args...)
+	}
+}
+
+// logPath returns name fit for logs. The folders of an export path can carry
+// the account's username (Instagram names the archive's top folder after
+// it), so only the file name, which is one of Instagram's standard names, is
+// kept unless DebugLogs is set.
+func logPath(name string) string {
+	if DebugLogs || !strings.Contains(name, "/") {
+		return name
+	}
+	return ".../" + path.Base(name)
+}
+
+// logError returns the message of err, which was raised reading name, with
+// name shortened as by logPath.
+func logError(err error, name string) string {
+	return strings.ReplaceAll(err.Error(), name, logPath(name))
+}
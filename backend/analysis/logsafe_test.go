@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureLogs collects the standard logger's output for the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func withDebugLogs(t *testing.T, enabled bool) {
+	previous := DebugLogs
+	DebugLogs = enabled
+	t.Cleanup(func() { DebugLogs = previous })
+}
+
+func TestLogPath(t *testing.T) {
+	name := "instagram-jane.doe-2024-01-01/connections/followers_and_following/followers_1.json"
+
+	withDebugLogs(t, false)
+	if got := logPath(name); got != ".../followers_1.json" {
+		t.Fatalf("Expected only the file name, got %q", got)
+	}
+	if got := logPath("followers_1.json"); got != "followers_1.json" {
+		t.Fatalf("Expected a bare file name unchanged, got %q", got)
+	}
+	err := &fs.PathError{Op: "open", Path: name, Err: errors.New("boom")}
+	if got := logError(err, name); strings.Contains(got, "jane.doe") || !strings.Contains(got, "followers_1.json") {
+		t.Fatalf("Expected the folders stripped from the error, got %q", got)
+	}
+
+	withDebugLogs(t, true)
+	if got := logPath(name); got != name {
+		t.Fatalf("Expected the full path in debug mode, got %q", got)
+	}
+}
+
+func TestReadFollowers_NoContentInLogs(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"instagram-jane.doe/connections/followers_and_following/followers_1.json": `{"not": "a list", "secret_username": 1`,
+	})
+
+	withDebugLogs(t, false)
+	logs := captureLogs(t)
+	ReadFollowers(fsys, &Report{})
+	if out := logs.String(); strings.Contains(out, "secret_username") || strings.Contains(out, "jane.doe") {
+		t.Fatalf("Expected no export contents or folder names in the logs, got:\n%s", out)
+	}
+
+	withDebugLogs(t, true)
+	ReadFollowers(fsys, &Report{})
+	if !strings.Contains(logs.String(), "content preview") {
+		t.Fatal("Expected the content preview in debug mode")
+	}
+}
@@ -0,0 +1,126 @@
+package analysis
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// OptionalAnalyzers lists the optional analyzers in the order they run.
+var OptionalAnalyzers = []string{
+	AnalyzerInteractions,
+	AnalyzerPendingRequests,
+	AnalyzerOtherRelationships,
+	AnalyzerFreshness,
+	AnalyzerQuality,
+	AnalyzerStats,
+	AnalyzerInsights,
+	AnalyzerMilestones,
+	AnalyzerPossibleDuplicates,
+	AnalyzerConnections,
+}
+
+// analyzerInputs lists the file kinds optional analyzers need besides the
+// follower and following lists, any one of which is enough, and how to
+// describe them when all are missing.
+var analyzerInputs = map[string]struct {
+	kinds []string
+	files string
+}{
+	AnalyzerInteractions:       {[]string{kindLikes, kindComments}, "liked posts or comments"},
+	AnalyzerPendingRequests:    {[]string{kindPendingRequests}, "pending follow requests"},
+	AnalyzerOtherRelationships: {[]string{kindOther}, "other relationship lists"},
+}
+
+// ManifestFile is an export file the analysis would read.
+type ManifestFile struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Bytes int64  `json:"bytes"`
+}
+
+// AnalyzerStatus tells whether an optional analyzer can run on an export.
+type AnalyzerStatus struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	// Reason explains why an analyzer isn't available.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Manifest describes what an export contains without analyzing it, so
+// clients can choose the analyzers to run.
+type Manifest struct {
+	Files     []ManifestFile   `json:"files"`
+	Analyzers []AnalyzerStatus `json:"analyzers"`
+	// Analyzable is set when the export has both a follower and a following
+	// list, which every analysis needs.
+	Analyzable bool `json:"analyzable"`
+}
+
+// fileKind returns the kind of export file name is, or "" for files the
+// analysis doesn't read. Like the readers, it only looks at the name.
+func fileKind(name string) string {
+	switch {
+	case isFollowersFile(name):
+		return kindFollowers
+	case isFollowingFile(name):
+		return kindFollowing
+	case matchesKind(kindUnfollowed, name):
+		return kindUnfollowed
+	case matchesKind(kindPendingRequests, name):
+		return kindPendingRequests
+	case matchesKind(kindLikes, name):
+		return kindLikes
+	case matchesKind(kindComments, name):
+		return kindComments
+	case isOtherRelationshipFile(name):
+		return kindOther
+	}
+	return ""
+}
+
+// BuildManifest lists the files in fsys the analysis would read and the
+// optional analyzers they allow. Only file names and sizes are consulted,
+// so it is much cheaper than an analysis. For a combined Meta Accounts
+// Center export it describes the Instagram data, like AnalyzeFiles.
+func BuildManifest(fsys fs.FS) *Manifest {
+	if subtrees := platformSubtrees(fsys); subtrees != nil {
+		if dir, ok := subtrees[PlatformInstagram]; ok {
+			fsys = platformFS(fsys, dir)
+		}
+	}
+
+	manifest := &Manifest{Files: []ManifestFile{}}
+	kinds := make(map[string]bool)
+	names, _ := candidateFiles(fsys, func(name string) bool { return fileKind(name) != "" })
+	for _, name := range names {
+		file := ManifestFile{Name: name, Kind: fileKind(name)}
+		if info, err := fs.Stat(fsys, name); err == nil {
+			file.Bytes = info.Size()
+		}
+		manifest.Files = append(manifest.Files, file)
+		kinds[file.Kind] = true
+	}
+	manifest.Analyzable = kinds[kindFollowers] && kinds[kindFollowing]
+
+	for _, name := range OptionalAnalyzers {
+		status := AnalyzerStatus{Name: name, Available: true}
+		if inputs, ok := analyzerInputs[name]; ok && !slices.ContainsFunc(inputs.kinds, func(kind string) bool { return kinds[kind] }) {
+			status.Available = false
+			status.Reason = (&missingFilesError{files: inputs.files}).Error()
+		}
+		manifest.Analyzers = append(manifest.Analyzers, status)
+	}
+	return manifest
+}
+
+// ValidateAnalyzers returns an error naming the first entry of names that
+// isn't an optional analyzer.
+func ValidateAnalyzers(names []string) error {
+	for _, name := range names {
+		if !slices.Contains(OptionalAnalyzers, name) {
+			return fmt.Errorf("unknown analyzer %q", name)
+		}
+	}
+	return nil
+}
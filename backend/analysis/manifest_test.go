@@ -0,0 +1,78 @@
+package analysis
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestBuildManifest(t *testing.T) {
+	files := maps.Clone(basicExport)
+	files["connections/followers_and_following/pending_follow_requests.json"] = `[]`
+	manifest := BuildManifest(mapFS(files))
+
+	if !manifest.Analyzable {
+		t.Fatal("Expected an export with both lists to be analyzable")
+	}
+	kinds := make(map[string]string)
+	for _, file := range manifest.Files {
+		kinds[file.Name] = file.Kind
+	}
+	want := map[string]string{
+		"connections/followers_and_following/followers_1.json":             kindFollowers,
+		"connections/followers_and_following/following.json":               kindFollowing,
+		"connections/followers_and_following/pending_follow_requests.json": kindPendingRequests,
+	}
+	if !maps.Equal(kinds, want) {
+		t.Fatalf("Expected files %v, got %v", want, kinds)
+	}
+
+	if len(manifest.Analyzers) != len(OptionalAnalyzers) {
+		t.Fatalf("Expected a status for each of %d analyzers, got %d", len(OptionalAnalyzers), len(manifest.Analyzers))
+	}
+	for _, status := range manifest.Analyzers {
+		wantAvailable := status.Name != AnalyzerInteractions && status.Name != AnalyzerOtherRelationships
+		if status.Available != wantAvailable {
+			t.Errorf("%s: expected available=%v, got %+v", status.Name, wantAvailable, status)
+		}
+		if !status.Available && status.Reason == "" {
+			t.Errorf("%s: expected a reason", status.Name)
+		}
+	}
+
+	delete(files, "connections/followers_and_following/following.json")
+	if BuildManifest(mapFS(files)).Analyzable {
+		t.Fatal("Expected an export without a following list not to be analyzable")
+	}
+}
+
+func TestAnalyzeFiles_Only(t *testing.T) {
+	silenceLogs(t)
+	analyzer := Default
+	analyzer.Only = []string{AnalyzerStats}
+
+	result, err := analyzer.AnalyzeFiles(mapFS(basicExport))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if result.Stats == nil {
+		t.Fatal("Expected the selected stats analyzer to run")
+	}
+	if result.Quality != nil || result.Insights != nil || result.DataAsOf != "" {
+		t.Fatalf("Expected unselected analyzers not to run, got %+v", result)
+	}
+	if len(result.SkippedAnalyzers) != 0 {
+		t.Fatalf("Expected unselected analyzers not to be reported as skipped, got %+v", result.SkippedAnalyzers)
+	}
+	if len(result.NonFollowers) != 1 {
+		t.Fatalf("Expected the non-followers regardless of the selection, got %+v", result.NonFollowers)
+	}
+}
+
+func TestValidateAnalyzers(t *testing.T) {
+	if err := ValidateAnalyzers([]string{AnalyzerStats, AnalyzerMilestones}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ValidateAnalyzers([]string{"stats", "horoscope"}); err == nil {
+		t.Fatal("Expected an error for an unknown analyzer")
+	}
+}
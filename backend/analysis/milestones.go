@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Milestone is a notable moment in the account's history, for the frontend
+// to celebrate or share.
+type Milestone struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Username is the account the milestone is about.
+	Username string `json:"username,omitempty"`
+	// At is when the milestone was reached, in Unix seconds.
+	At int64 `json:"at,omitempty"`
+}
+
+// followMilestones are the follow counts worth celebrating.
+var followMilestones = []int{100, 1000, 10000}
+
+// milestoneRule produces the milestones of one kind, in the order they were
+// reached.
+type milestoneRule func(in insightInput) []Milestone
+
+// milestoneRules run in order; the order of the milestones array follows it.
+var milestoneRules = []milestoneRule{
+	followCountMilestones,
+	longestMutualMilestone,
+	firstFollowerMilestone,
+}
+
+// buildMilestones runs every milestone rule against the analysis results.
+// Relationships without a timestamp can't be placed in time and are left
+// out.
+func buildMilestones(following []Relationship, followers map[string]int64) []Milestone {
+	in := insightInput{following: following, followers: followers}
+	milestones := []Milestone{}
+	for _, rule := range milestoneRules {
+		milestones = append(milestones, rule(in)...)
+	}
+	return milestones
+}
+
+func milestoneDate(at int64) string {
+	return time.Unix(at, 0).UTC().Format("January 2, 2006")
+}
+
+// ordinal returns n with its English ordinal suffix, e.g. 100th.
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// followCountMilestones finds the accounts that were the 100th, 1000th and
+// 10000th the owner followed, among those still followed.
+func followCountMilestones(in insightInput) []Milestone {
+	var dated []Relationship
+	for _, rel := range in.following {
+		if rel.FollowedAt != 0 {
+			dated = append(dated, rel)
+		}
+	}
+	sort.SliceStable(dated, func(i, j int) bool { return dated[i].FollowedAt < dated[j].FollowedAt })
+
+	var milestones []Milestone
+	for _, n := range followMilestones {
+		if len(dated) < n {
+			break
+		}
+		rel := dated[n-1]
+		milestones = append(milestones, Milestone{
+			Code:     fmt.Sprintf("follow_%d", n),
+			Message:  fmt.Sprintf("Your %s follow was @%s on %s.", ordinal(n), rel.Username, milestoneDate(rel.FollowedAt)),
+			Username: rel.Username,
+			At:       rel.FollowedAt,
+		})
+	}
+	return milestones
+}
+
+// longestMutualMilestone finds the mutual who has followed the owner back
+// the longest. A mutual relationship starts when the second of the two
+// follows happened.
+func longestMutualMilestone(in insightInput) []Milestone {
+	var best Relationship
+	var since int64
+	for _, rel := range in.following {
+		followedYou := in.followers[strings.ToLower(rel.Username)]
+		if followedYou == 0 || rel.FollowedAt == 0 {
+			continue
+		}
+		start := max(followedYou, rel.FollowedAt)
+		if since == 0 || start < since || start == since && rel.Username < best.Username {
+			best, since = rel, start
+		}
+	}
+	if since == 0 {
+		return nil
+	}
+	return []Milestone{{
+		Code:     "longest_mutual",
+		Message:  fmt.Sprintf("You and @%s have followed each other since %s, your longest mutual relationship.", best.Username, milestoneDate(since)),
+		Username: best.Username,
+		At:       since,
+	}}
+}
+
+// firstFollowerMilestone finds the earliest follower who still follows the
+// owner.
+func firstFollowerMilestone(in insightInput) []Milestone {
+	var first string
+	var at int64
+	for username, followedAt := range in.followers {
+		if followedAt == 0 {
+			continue
+		}
+		if at == 0 || followedAt < at || followedAt == at && username < first {
+			first, at = username, followedAt
+		}
+	}
+	if at == 0 {
+		return nil
+	}
+	return []Milestone{{
+		Code:     "first_follower",
+		Message:  fmt.Sprintf("@%s has followed you since %s, longer than any other follower.", first, milestoneDate(at)),
+		Username: first,
+		At:       at,
+	}}
+}
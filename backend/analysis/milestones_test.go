@@ -0,0 +1,58 @@
+package analysis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBuildMilestones(t *testing.T) {
+	var following []Relationship
+	for i := 1; i <= 150; i++ {
+		following = append(following, Relationship{Username: fmt.Sprintf("user%d", i), FollowedAt: unix(2020, 1) + int64(i)*86400})
+	}
+	// Undated follows can't be placed and don't count.
+	following = append(following, Relationship{Username: "undated"})
+	followers := map[string]int64{
+		"user5":    unix(2019, 6), // mutual since the owner followed back
+		"user3":    unix(2021, 1), // mutual since they followed
+		"longtime": unix(2018, 3),
+		"nodate":   0,
+	}
+
+	milestones := buildMilestones(following, followers)
+
+	want := []Milestone{
+		{Code: "follow_100", Username: "user100", At: unix(2020, 1) + 100*86400},
+		{Code: "longest_mutual", Username: "user5", At: unix(2020, 1) + 5*86400},
+		{Code: "first_follower", Username: "longtime", At: unix(2018, 3)},
+	}
+	if len(milestones) != len(want) {
+		t.Fatalf("Expected %d milestones, got %+v", len(want), milestones)
+	}
+	for i, w := range want {
+		m := milestones[i]
+		if m.Code != w.Code || m.Username != w.Username || m.At != w.At || m.Message == "" {
+			t.Errorf("Milestone %d: expected %+v, got %+v", i, w, m)
+		}
+	}
+	if got := milestones[0].Message; got != "Your 100th follow was @user100 on April 10, 2020." {
+		t.Errorf("Unexpected follow milestone message %q", got)
+	}
+}
+
+func TestBuildMilestones_NoTimestamps(t *testing.T) {
+	following := []Relationship{{Username: "friend"}}
+	followers := map[string]int64{"friend": 0}
+
+	if milestones := buildMilestones(following, followers); len(milestones) != 0 {
+		t.Fatalf("Expected no milestones without timestamps, got %+v", milestones)
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, want := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 11: "11th", 12: "12th", 100: "100th", 101: "101st", 1000: "1000th"} {
+		if got := ordinal(n); got != want {
+			t.Errorf("ordinal(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mutual is a followed account that follows back.
+type Mutual struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+	ProfileURL  string `json:"profile_url"`
+	// FollowedAt is when the owner followed the account and FollowedYouAt
+	// when it followed the owner, when the export records them.
+	FollowedAt    int64 `json:"followed_at,omitempty"`
+	FollowedYouAt int64 `json:"followed_you_at,omitempty"`
+}
+
+// findMutuals returns the followed accounts that are also followers, in the
+// order of the following list.
+func findMutuals(following []Relationship, followers map[string]int64) []Mutual {
+	var mutuals []Mutual
+	for _, rel := range following {
+		followedYouAt, exists := followers[strings.ToLower(rel.Username)]
+		if !exists {
+			continue
+		}
+		mutuals = append(mutuals, Mutual{
+			Username:      rel.Username,
+			DisplayName:   rel.DisplayName,
+			ProfileURL:    fmt.Sprintf("https://instagram.com/%s", rel.Username),
+			FollowedAt:    rel.FollowedAt,
+			FollowedYouAt: followedYouAt,
+		})
+	}
+	return mutuals
+}
@@ -0,0 +1,50 @@
+package analysis
+
+import "testing"
+
+func TestFindMutuals(t *testing.T) {
+	following := []Relationship{
+		{Username: "Friend", DisplayName: "A Friend", FollowedAt: 1500000000},
+		{Username: "celeb", FollowedAt: 1500000001},
+		{Username: "pal"},
+	}
+	followers := map[string]int64{"friend": 1600000000, "pal": 0, "fan": 1700000000}
+
+	want := []Mutual{
+		{Username: "Friend", DisplayName: "A Friend", ProfileURL: "https://instagram.com/Friend", FollowedAt: 1500000000, FollowedYouAt: 1600000000},
+		{Username: "pal", ProfileURL: "https://instagram.com/pal"},
+	}
+	got := findMutuals(following, followers)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d mutuals, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Mutual %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestAnalyzeFiles_Mutuals(t *testing.T) {
+	silenceLogs(t)
+	result, err := AnalyzeFiles(mapFS(basicExport))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if result.Mutuals != nil {
+		t.Fatalf("Expected no mutuals unless asked for, got %+v", result.Mutuals)
+	}
+
+	analyzer := Default
+	analyzer.Mutuals = true
+	result, err = analyzer.AnalyzeFiles(mapFS(basicExport))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if len(result.Mutuals) != 1 || result.Mutuals[0].Username != "friend" || result.Mutuals[0].FollowedYouAt != 1600000000 {
+		t.Fatalf("Expected friend as the only mutual, got %+v", result.Mutuals)
+	}
+	if len(result.NonFollowers) != 1 || result.NonFollowers[0].Username != "celeb" {
+		t.Fatalf("Expected celeb to stay a non-follower, got %+v", result.NonFollowers)
+	}
+}
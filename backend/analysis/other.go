@@ -0,0 +1,117 @@
+package analysis
+
+import (
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// otherSampleSize is how many usernames of an unrecognized list are shown.
+const otherSampleSize = 5
+
+var partSuffixPattern = regexp.MustCompile(`_\d+$`)
+
+// knownRelationshipTitles are the other relationship lists the analysis
+// knows: those read for their own sections, such as close friends for the
+// connection scores, and those Instagram has long exported. They are still
+// summarized, but without the warning meant to flag new kinds of lists.
+var knownRelationshipTitles = map[string]bool{
+	closeFriendsTitle:                 true,
+	"blocked_accounts":                true,
+	"blocked_profiles":                true,
+	"restricted_accounts":             true,
+	"restricted_profiles":             true,
+	"hide_story_from":                 true,
+	"removed_suggestions":             true,
+	"recent_follow_requests":          true,
+	"follow_requests_you've_received": true,
+}
+
+// OtherRelationship summarizes a list of accounts in the export's
+// relationships folder that the analysis doesn't otherwise use, such as close
+// friends, blocked accounts or lists added by new Instagram features.
+type OtherRelationship struct {
+	// Title is the file name without its extension or part number, e.g.
+	// "close_friends".
+	Title  string   `json:"title"`
+	Count  int      `json:"count"`
+	Sample []string `json:"sample"`
+}
+
+// isOtherRelationshipFile reports whether name is a JSON file in the
+// relationships folder that none of the specific readers handle.
+func isOtherRelationshipFile(name string) bool {
+	if !matchesKind(kindOther, name) {
+		return false
+	}
+	return !isFollowersFile(name) && !isFollowingFile(name) &&
+		!matchesKind(kindUnfollowed, name) && !matchesKind(kindPendingRequests, name)
+}
+
+// otherTitle returns the title of the list in file name, merging the parts of
+// a split list.
+func otherTitle(name string) string {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	return strings.ToLower(partSuffixPattern.ReplaceAllString(base, ""))
+}
+
+// readOtherRelationships parses unrecognized relationship files generically.
+// Files that turn out to list accounts are summarized, in file order, and
+// unless they are knownRelationshipTitles reported with a warning so new
+// kinds of lists get noticed; anything else is ignored.
+func readOtherRelationships(fsys fs.FS, report *Report) []OtherRelationship {
+	var others []OtherRelationship
+	index := make(map[string]int)
+	seen := make(map[string]map[string]bool)
+
+	files, _ := candidateFiles(fsys, isOtherRelationshipFile)
+	for _, name := range files {
+		content, err := readFile(fsys, name)
+		if err != nil {
+			debugf("readOtherRelationships: skipping %s: %v", name, err)
+			continue
+		}
+		entries, err := decodeRelationshipList(content)
+		if err != nil {
+			debugf("readOtherRelationships: %s is not a relationship list: %v", name, err)
+			continue
+		}
+
+		var usernames []string
+		for _, entry := range entries {
+			rel := toRelationship(entry, name)
+			if rel.Username != "" && isHandle(strings.ToLower(rel.Username)) {
+				usernames = append(usernames, rel.Username)
+			}
+		}
+		if len(usernames) == 0 {
+			continue
+		}
+		report.processed(name, kindOther, content)
+		title := otherTitle(name)
+		if !knownRelationshipTitles[title] {
+			report.warn(WarnUnknownRelationshipFile, name, "This file lists accounts but isn't one we analyze yet; a summary is included under other_relationships.")
+		}
+
+		i, ok := index[title]
+		if !ok {
+			i = len(others)
+			index[title] = i
+			others = append(others, OtherRelationship{Title: title, Sample: []string{}})
+			seen[title] = make(map[string]bool)
+		}
+		for _, username := range usernames {
+			key := strings.ToLower(username)
+			if seen[title][key] {
+				continue
+			}
+			seen[title][key] = true
+			others[i].Count++
+			if len(others[i].Sample) < otherSampleSize {
+				others[i].Sample = append(others[i].Sample, username)
+			}
+		}
+	}
+	return others
+}
@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadOtherRelationships(t *testing.T) {
+	silenceLogs(t)
+	fsys := mapFS(map[string]string{
+		"connections/followers_and_following/close_friends.json": `{"relationships_close_friends": [
+			{"string_list_data": [{"href": "https://www.instagram.com/bestie", "value": "bestie", "timestamp": 1600000000}]},
+			{"string_list_data": [{"href": "https://www.instagram.com/sibling", "value": "sibling", "timestamp": 1600000000}]}
+		]}`,
+		"connections/followers_and_following/new_feature_1.json": `[
+			{"string_list_data": [{"href": "https://www.instagram.com/a", "value": "a"}]},
+			{"string_list_data": [{"href": "https://www.instagram.com/b", "value": "b"}]}
+		]`,
+		"connections/followers_and_following/new_feature_2.json": `[
+			{"string_list_data": [{"href": "https://www.instagram.com/b", "value": "b"}]},
+			{"string_list_data": [{"href": "https://www.instagram.com/c", "value": "c"}]}
+		]`,
+		// Not a list of accounts.
+		"connections/followers_and_following/settings.json": `{"enabled": true}`,
+		// Handled by their own readers.
+		"connections/followers_and_following/pending_follow_requests.json": `{"relationships_follow_requests_sent": [
+			{"string_list_data": [{"href": "https://www.instagram.com/private", "value": "private"}]}
+		]}`,
+		"connections/followers_and_following/following.json": `{"relationships_following": []}`,
+		// Outside the relationships folder.
+		"your_instagram_activity/other.json": `[{"string_list_data": [{"href": "https://www.instagram.com/x", "value": "x"}]}]`,
+	})
+
+	report := &Report{}
+	others := readOtherRelationships(fsys, report)
+
+	want := []OtherRelationship{
+		{Title: "close_friends", Count: 2, Sample: []string{"bestie", "sibling"}},
+		{Title: "new_feature", Count: 3, Sample: []string{"a", "b", "c"}},
+	}
+	if len(others) != len(want) {
+		t.Fatalf("Expected %d lists, got %+v", len(want), others)
+	}
+	for i, w := range want {
+		got := others[i]
+		if got.Title != w.Title || got.Count != w.Count || len(got.Sample) != len(w.Sample) {
+			t.Fatalf("List %d: expected %+v, got %+v", i, w, got)
+		}
+		for j := range w.Sample {
+			if got.Sample[j] != w.Sample[j] {
+				t.Fatalf("List %d: expected sample %v, got %v", i, w.Sample, got.Sample)
+			}
+		}
+	}
+
+	// close_friends is read for the connections, so only the new list's
+	// parts are flagged.
+	if len(report.Warnings) != 2 {
+		t.Fatalf("Expected a warning per part of the unknown list, got %+v", report.Warnings)
+	}
+	for _, w := range report.Warnings {
+		if w.Code != WarnUnknownRelationshipFile || !strings.Contains(w.File, "new_feature") {
+			t.Errorf("Unexpected warning %+v", w)
+		}
+	}
+	if len(report.files) != 3 || report.Partial {
+		t.Fatalf("Expected the three lists in the receipt without a partial result, got %+v", report.files)
+	}
+}
+
+func TestReadOtherRelationships_SampleSize(t *testing.T) {
+	list := "["
+	for i := 0; i < 8; i++ {
+		if i > 0 {
+			list += ","
+		}
+		list += `{"title": "user` + string(rune('a'+i)) + `"}`
+	}
+	list += "]"
+	fsys := mapFS(map[string]string{"connections/followers_and_following/blocked_accounts.json": list})
+
+	others := readOtherRelationships(fsys, &Report{})
+
+	if len(others) != 1 || others[0].Count != 8 || len(others[0].Sample) != otherSampleSize {
+		t.Fatalf("Expected 8 accounts with a sample of %d, got %+v", otherSampleSize, others)
+	}
+}
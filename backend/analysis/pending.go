@@ -0,0 +1,98 @@
+package analysis
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultPendingThreshold is how long a follow request may stay pending
+// before it is flagged for cancelling when the Analyzer doesn't set one.
+const DefaultPendingThreshold = 90 * 24 * time.Hour
+
+// PendingRequest is a follow request sent to a private account that hasn't
+// accepted it yet.
+type PendingRequest struct {
+	Username    string `json:"username"`
+	ProfileURL  string `json:"profile_url"`
+	RequestedAt int64  `json:"requested_at,omitempty"`
+	// PendingDays is how long the request has been waiting, when the
+	// export records when it was sent.
+	PendingDays int `json:"pending_days,omitempty"`
+	// ConsiderCancelling is set once the request has been pending for
+	// longer than the threshold.
+	ConsiderCancelling bool `json:"consider_cancelling"`
+}
+
+// readPendingRequests returns the follow requests listed in
+// pending_follow_requests.json, one per account. It returns a
+// missingFilesError when the export has no such file.
+func readPendingRequests(fsys fs.FS, report *Report) ([]Relationship, error) {
+	var pending []Relationship
+	seen := make(map[string]bool)
+	files, _ := candidateFiles(fsys, ofKind(kindPendingRequests))
+	if len(files) == 0 {
+		return nil, &missingFilesError{files: "pending follow requests"}
+	}
+	for _, name := range files {
+		content, err := readFile(fsys, name)
+		if err != nil {
+			report.unreadable(name, err)
+			continue
+		}
+		report.processed(name, kindPendingRequests, content)
+		entries, err := decodeRelationshipList(content)
+		if err != nil {
+			log.Printf("Error parsing %s: %v", logPath(name), err)
+			continue
+		}
+		for _, entry := range entries {
+			rel := toRelationship(entry, name)
+			key := strings.ToLower(rel.Username)
+			if rel.Username == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			pending = append(pending, rel)
+		}
+	}
+	return pending, nil
+}
+
+// buildPendingRequests ages the pending requests relative to now, oldest
+// first, and flags those pending for longer than threshold. Requests without
+// a timestamp can't be aged and are listed last, unflagged.
+func buildPendingRequests(pending []Relationship, threshold time.Duration, now time.Time) []PendingRequest {
+	if len(pending) == 0 {
+		return nil
+	}
+
+	requests := make([]PendingRequest, 0, len(pending))
+	for _, rel := range pending {
+		req := PendingRequest{
+			Username:    rel.Username,
+			ProfileURL:  fmt.Sprintf("https://instagram.com/%s", rel.Username),
+			RequestedAt: rel.FollowedAt,
+		}
+		if rel.FollowedAt != 0 {
+			age := now.Sub(time.Unix(rel.FollowedAt, 0))
+			if age > 0 {
+				req.PendingDays = int(age / (24 * time.Hour))
+			}
+			req.ConsiderCancelling = age > threshold
+		}
+		requests = append(requests, req)
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool {
+		ai, aj := requests[i].RequestedAt, requests[j].RequestedAt
+		if (ai == 0) != (aj == 0) {
+			return aj == 0
+		}
+		return ai < aj
+	})
+	return requests
+}
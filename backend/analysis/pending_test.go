@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildPendingRequests(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	pending := []Relationship{
+		{Username: "recent", FollowedAt: unix(2024, 12)},
+		{Username: "nodate"},
+		{Username: "old", FollowedAt: unix(2024, 1)},
+	}
+
+	requests := buildPendingRequests(pending, 90*24*time.Hour, now)
+
+	want := []PendingRequest{
+		{Username: "old", ProfileURL: "https://instagram.com/old", RequestedAt: unix(2024, 1), PendingDays: 366, ConsiderCancelling: true},
+		{Username: "recent", ProfileURL: "https://instagram.com/recent", RequestedAt: unix(2024, 12), PendingDays: 31},
+		{Username: "nodate", ProfileURL: "https://instagram.com/nodate"},
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("Expected %d requests, got %+v", len(want), requests)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Errorf("Request %d: expected %+v, got %+v", i, want[i], requests[i])
+		}
+	}
+
+	if requests := buildPendingRequests(pending, 7*24*time.Hour, now); !requests[1].ConsiderCancelling {
+		t.Fatal("Expected a shorter threshold to flag the recent request too")
+	}
+}
+
+func TestAnalyzeFiles_PendingRequests(t *testing.T) {
+	silenceLogs(t)
+	files := map[string]string{
+		"connections/followers_and_following/pending_follow_requests.json": `{"relationships_follow_requests_sent": [
+			{"string_list_data": [{"href": "https://www.instagram.com/private.one", "value": "private.one", "timestamp": 1600000000}]},
+			{"string_list_data": [{"href": "https://www.instagram.com/private.one", "value": "private.one", "timestamp": 1600000000}]}
+		]}`,
+	}
+	for name, content := range basicExport {
+		files[name] = content
+	}
+	analyzer := Analyzer{Now: func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }}
+
+	result, err := analyzer.AnalyzeFiles(mapFS(files))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if len(result.PendingRequests) != 1 || result.PendingRequests[0].Username != "private.one" || !result.PendingRequests[0].ConsiderCancelling {
+		t.Fatalf("Expected private.one flagged for cancelling, got %+v", result.PendingRequests)
+	}
+	if result.TotalFollowing != 2 {
+		t.Fatalf("Expected pending requests not to count as following, got %d", result.TotalFollowing)
+	}
+}
@@ -0,0 +1,63 @@
+package analysis
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Platforms of a Meta Accounts Center export.
+const (
+	PlatformInstagram = "instagram"
+	PlatformFacebook  = "facebook"
+	PlatformThreads   = "threads"
+)
+
+// platformSubtrees finds the per-platform folders of a combined Meta
+// Accounts Center export, which bundles Instagram, Facebook and Threads data
+// under top-level folders named after each platform, possibly inside a
+// single wrapping folder. It returns the folder of each platform found, or
+// nil for a plain Instagram export.
+func platformSubtrees(fsys fs.FS) map[string]string {
+	if found := platformDirs(fsys, "."); found != nil {
+		return found
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return nil
+	}
+	return platformDirs(fsys, entries[0].Name())
+}
+
+// platformDirs returns the platform folders directly inside dir.
+func platformDirs(fsys fs.FS, dir string) map[string]string {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil
+	}
+
+	var found map[string]string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		switch name := strings.ToLower(entry.Name()); name {
+		case PlatformInstagram, PlatformFacebook, PlatformThreads:
+			if found == nil {
+				found = make(map[string]string)
+			}
+			found[name] = path.Join(dir, entry.Name())
+		}
+	}
+	return found
+}
+
+// platformFS returns the part of fsys holding the given platform's data.
+func platformFS(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return fsys
+	}
+	return sub
+}
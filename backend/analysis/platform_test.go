@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+// metaExport is a combined Meta Accounts Center export. Its Threads data
+// uses the Instagram file format; its Facebook data has no files the
+// analyzer recognizes.
+var metaExport = map[string]string{
+	"meta-export/instagram/connections/followers_and_following/followers_1.json": basicExport["connections/followers_and_following/followers_1.json"],
+	"meta-export/instagram/connections/followers_and_following/following.json":   basicExport["connections/followers_and_following/following.json"],
+	"meta-export/threads/followers.json": `[
+		{"string_list_data": [{"value": "a"}]},
+		{"string_list_data": [{"value": "b"}]}
+	]`,
+	"meta-export/threads/following.json": `{"relationships_following": [
+		{"title": "a"}, {"title": "c"}
+	]}`,
+	"meta-export/facebook/connections/friends/your_friends.json": `{"friends_v2": []}`,
+}
+
+func TestPlatformSubtrees(t *testing.T) {
+	if got := platformSubtrees(mapFS(basicExport)); got != nil {
+		t.Fatalf("Expected no platforms in a plain Instagram export, got %v", got)
+	}
+
+	got := platformSubtrees(mapFS(metaExport))
+	want := map[string]string{
+		PlatformInstagram: "meta-export/instagram",
+		PlatformFacebook:  "meta-export/facebook",
+		PlatformThreads:   "meta-export/threads",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for platform, dir := range want {
+		if got[platform] != dir {
+			t.Errorf("Expected %s in %q, got %q", platform, dir, got[platform])
+		}
+	}
+}
+
+func TestAnalyzeFiles_MetaExport(t *testing.T) {
+	silenceLogs(t)
+
+	result, err := AnalyzeFiles(mapFS(metaExport))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if result.TotalFollowers != 1 || result.TotalFollowing != 2 || result.Platforms != nil {
+		t.Fatalf("Expected only the Instagram data to be analyzed, got %+v", result)
+	}
+}
+
+func TestAnalyzeFiles_MetaExportAllPlatforms(t *testing.T) {
+	silenceLogs(t)
+	analyzer := Default
+	analyzer.AllPlatforms = true
+	analyzer.Now = func() time.Time { return time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC) }
+
+	result, err := analyzer.AnalyzeFiles(mapFS(metaExport))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if result.TotalFollowers != 1 || result.TotalFollowing != 2 {
+		t.Fatalf("Expected the Instagram counts at the top level, got %+v", result)
+	}
+
+	threads := result.Platforms[PlatformThreads]
+	if threads == nil || len(threads.NonFollowers) != 1 || threads.NonFollowers[0].Username != "c" {
+		t.Fatalf("Expected c as the only Threads non-follower, got %+v", threads)
+	}
+	if _, ok := result.Platforms[PlatformFacebook]; ok {
+		t.Fatal("Expected the unrecognized Facebook data to be skipped")
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnPlatformSkipped || result.Warnings[0].File != "meta-export/facebook" {
+		t.Fatalf("Expected a platform_skipped warning for Facebook, got %+v", result.Warnings)
+	}
+}
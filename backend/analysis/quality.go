@@ -0,0 +1,93 @@
+package analysis
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Default baselines, in percent of followers, for the quality report.
+const (
+	DefaultSpamBaseline  = 5.0
+	DefaultBrandBaseline = 10.0
+)
+
+// QualityMetric is one share of the followers with the baseline it is
+// compared against.
+type QualityMetric struct {
+	Count    int     `json:"count"`
+	Percent  float64 `json:"percent"`
+	Baseline float64 `json:"baseline"`
+	// Comparison is "higher", "typical" or "lower" than the baseline.
+	Comparison string `json:"comparison"`
+}
+
+// QualityReport estimates how much of the audience is likely spam or
+// business accounts. The export has no activity data, so these are username
+// heuristics rather than measurements.
+type QualityReport struct {
+	SpamLikely QualityMetric `json:"spam_likely"`
+	Brand      QualityMetric `json:"brand"`
+}
+
+var (
+	// Long trailing digit runs (jane84920213) and digit-heavy handles are
+	// typical of generated accounts.
+	spamTrailingDigits = regexp.MustCompile(`\d{5,}$`)
+	brandKeywords      = []string{"shop", "store", "official", "boutique", "brand", "clothing", "studio", "agency", "market", "beauty", "design", "fashion", "deals"}
+)
+
+func isSpamLikely(username string) bool {
+	if spamTrailingDigits.MatchString(username) {
+		return true
+	}
+	digits := 0
+	for _, r := range username {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return len(username) > 0 && float64(digits)/float64(len(username)) > 0.5
+}
+
+func isBrandLike(username string) bool {
+	lower := strings.ToLower(username)
+	for _, keyword := range brandKeywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func newQualityMetric(count, total int, baseline float64) QualityMetric {
+	m := QualityMetric{Count: count, Baseline: baseline, Comparison: "typical"}
+	if total > 0 {
+		m.Percent = math.Round(float64(count)/float64(total)*1000) / 10
+	}
+	switch {
+	case m.Percent > baseline*1.5:
+		m.Comparison = "higher"
+	case m.Percent < baseline*0.5:
+		m.Comparison = "lower"
+	}
+	return m
+}
+
+// buildQualityReport scores the follower set against the given baselines.
+func buildQualityReport(followers map[string]int64, spamBaseline, brandBaseline float64) *QualityReport {
+	var spam, brand int
+	for username := range followers {
+		if isSpamLikely(username) {
+			spam++
+		}
+		if isBrandLike(username) {
+			brand++
+		}
+	}
+
+	return &QualityReport{
+		SpamLikely: newQualityMetric(spam, len(followers), spamBaseline),
+		Brand:      newQualityMetric(brand, len(followers), brandBaseline),
+	}
+}
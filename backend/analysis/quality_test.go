@@ -0,0 +1,39 @@
+package analysis
+
+import "testing"
+
+func TestIsSpamLikely(t *testing.T) {
+	tests := map[string]bool{
+		"jane.doe":      false,
+		"jane_1998":     false,
+		"jane84920213":  true,
+		"u2839481":      true,
+		"photos.by.sam": false,
+	}
+	for username, want := range tests {
+		if got := isSpamLikely(username); got != want {
+			t.Errorf("isSpamLikely(%q) = %v, want %v", username, got, want)
+		}
+	}
+}
+
+func TestBuildQualityReport(t *testing.T) {
+	followers := map[string]int64{
+		"jane.doe":        1,
+		"bob":             2,
+		"x99381234":       3,
+		"coffee.shop.nyc": 4,
+	}
+
+	report := buildQualityReport(followers, 10, DefaultBrandBaseline)
+
+	if report.SpamLikely.Count != 1 || report.SpamLikely.Percent != 25 || report.SpamLikely.Baseline != 10 {
+		t.Errorf("Unexpected spam metric: %+v", report.SpamLikely)
+	}
+	if report.SpamLikely.Comparison != "higher" {
+		t.Errorf("Expected spam share to be higher than baseline, got %s", report.SpamLikely.Comparison)
+	}
+	if report.Brand.Count != 1 || report.Brand.Baseline != DefaultBrandBaseline {
+		t.Errorf("Unexpected brand metric: %+v", report.Brand)
+	}
+}
@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// File kinds recorded in a processing receipt.
+const (
+	kindFollowers       = "followers"
+	kindFollowing       = "following"
+	kindUnfollowed      = "unfollowed"
+	kindPendingRequests = "pending_requests"
+	kindLikes           = "likes"
+	kindComments        = "comments"
+	kindOther           = "other_relationships"
+)
+
+// parserVersions identifies the parser used for each file kind. Bump a
+// version whenever a parser changes how it reads its files, so receipts show
+// which results are comparable.
+var parserVersions = map[string]string{
+	kindFollowers:       "1",
+	kindFollowing:       "1",
+	kindUnfollowed:      "1",
+	kindPendingRequests: "1",
+	kindLikes:           "1",
+	kindComments:        "1",
+	kindOther:           "1",
+}
+
+// ReceiptFile is one export file that was read.
+type ReceiptFile struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// ProcessingReceipt records what an analysis read, so a result can be tied
+// to the exact export data it came from.
+type ProcessingReceipt struct {
+	Files          []ReceiptFile `json:"files"`
+	BytesProcessed int64         `json:"bytes_processed"`
+	// Parsers maps each file kind read to its parser version.
+	Parsers map[string]string `json:"parsers"`
+	// ContentHash is the SHA-256 over the names and hashes of all files read,
+	// in name order. It is the same for a ZIP, a slim bundle or a directory
+	// holding the same files.
+	ContentHash string `json:"content_hash"`
+}
+
+// processed records a file that was read successfully. Files read by
+// several analyzers are recorded once.
+func (p *Report) processed(name, kind string, content []byte) {
+	for _, f := range p.files {
+		if f.Name == name {
+			return
+		}
+	}
+	sum := sha256.Sum256(content)
+	p.files = append(p.files, ReceiptFile{Name: name, Kind: kind, Bytes: int64(len(content)), SHA256: hex.EncodeToString(sum[:])})
+}
+
+// receipt summarizes the files recorded by processed.
+func (p *Report) receipt() *ProcessingReceipt {
+	files := make([]ReceiptFile, len(p.files))
+	copy(files, p.files)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	receipt := &ProcessingReceipt{Files: files, Parsers: make(map[string]string)}
+	h := sha256.New()
+	for _, f := range files {
+		receipt.BytesProcessed += f.Bytes
+		receipt.Parsers[f.Kind] = parserVersions[f.Kind]
+		h.Write([]byte(f.Name + "\x00" + f.SHA256 + "\n"))
+	}
+	receipt.ContentHash = hex.EncodeToString(h.Sum(nil))
+	return receipt
+}
@@ -0,0 +1,55 @@
+package analysis
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestProcessingReceipt(t *testing.T) {
+	silenceLogs(t)
+
+	result, err := AnalyzeFiles(mapFS(basicExport))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	receipt := result.Receipt
+
+	if len(receipt.Files) != 2 {
+		t.Fatalf("Expected the 2 relationship files, got %+v", receipt.Files)
+	}
+	if receipt.Files[0].Name != "connections/followers_and_following/followers_1.json" || receipt.Files[0].Kind != kindFollowers {
+		t.Fatalf("Expected the followers file first, got %+v", receipt.Files[0])
+	}
+	var total int64
+	for _, name := range []string{"connections/followers_and_following/followers_1.json", "connections/followers_and_following/following.json"} {
+		total += int64(len(basicExport[name]))
+	}
+	if receipt.BytesProcessed != total {
+		t.Fatalf("Expected %d bytes processed, got %d", total, receipt.BytesProcessed)
+	}
+	if receipt.Parsers[kindFollowers] == "" || receipt.Parsers[kindFollowing] == "" || len(receipt.Parsers) != 2 {
+		t.Fatalf("Expected the followers and following parser versions, got %v", receipt.Parsers)
+	}
+
+	data := createTestZip(t, basicExport)
+	fromZip, err := Analyze(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if fromZip.Receipt.ContentHash != receipt.ContentHash {
+		t.Fatal("Expected the same content hash for a ZIP and an fs.FS with the same files")
+	}
+
+	changed := map[string]string{}
+	for name, content := range basicExport {
+		changed[name] = content
+	}
+	changed["connections/followers_and_following/followers_1.json"] += " "
+	other, err := AnalyzeFiles(mapFS(changed))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+	if other.Receipt.ContentHash == receipt.ContentHash {
+		t.Fatal("Expected the content hash to change with the file contents")
+	}
+}
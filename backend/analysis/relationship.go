@@ -0,0 +1,132 @@
+package analysis
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// InstagramRelationship is one entry of a followers or following file as it
+// appears in the export.
+type InstagramRelationship struct {
+	Title     string `json:"title"`
+	MediaList []struct {
+		Title string `json:"title"`
+	} `json:"media_list_data"`
+	StringListData []struct {
+		Href      string `json:"href"`
+		Value     string `json:"value"`
+		Timestamp int64  `json:"timestamp"`
+	} `json:"string_list_data"`
+}
+
+// FollowingData is the layout of following.json.
+type FollowingData struct {
+	RelationshipsFollowing []InstagramRelationship `json:"relationships_following"`
+}
+
+// NonFollower is a followed account that doesn't follow back.
+type NonFollower struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+	ProfileURL  string `json:"profile_url"`
+	FollowedAt  int64  `json:"followed_at,omitempty"`
+	// Source is the path of the export file the entry was read from.
+	Source string `json:"source,omitempty"`
+	// Interaction is the owner's engagement with the account's content,
+	// when the export includes any.
+	Interaction *Interaction `json:"interaction,omitempty"`
+}
+
+// handlePattern matches a valid Instagram handle once lowercased.
+var handlePattern = regexp.MustCompile(`^[a-z0-9._]+$`)
+
+func isHandle(s string) bool {
+	return handlePattern.MatchString(strings.ToLower(s))
+}
+
+// handleFromHref extracts the handle from a profile link such as
+// https://www.instagram.com/<handle> or https://www.instagram.com/_u/<handle>.
+func handleFromHref(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	for _, segment := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+		if segment == "_u" {
+			continue
+		}
+		if isHandle(segment) {
+			return segment
+		}
+		break
+	}
+	return ""
+}
+
+// Relationship is one account in the followers or following list of an
+// export. It is the internal representation; results convert it to the
+// section-specific types such as NonFollower.
+type Relationship struct {
+	Username    string
+	DisplayName string
+	Href        string
+	FollowedAt  int64
+	// Source is the path of the export file the entry was read from.
+	Source string
+}
+
+// toNonFollower converts rel to its result representation.
+func (rel Relationship) toNonFollower() NonFollower {
+	return NonFollower{
+		Username:    rel.Username,
+		DisplayName: rel.DisplayName,
+		ProfileURL:  fmt.Sprintf("https://instagram.com/%s", rel.Username),
+		FollowedAt:  rel.FollowedAt,
+		Source:      rel.Source,
+	}
+}
+
+// toRelationship resolves the handle, display name and follow time of an
+// export entry. The handle in the profile link is the source of truth: the
+// title/value text sometimes holds a display name (which may contain spaces
+// or emoji) or a stale handle, which made the same account look different in
+// the followers and following lists. The text is only used as the handle when
+// there is no usable link, and is kept as display name when it isn't a handle.
+func toRelationship(entry InstagramRelationship, source string) Relationship {
+	rel := Relationship{Source: source}
+
+	var text string
+	if len(entry.StringListData) > 0 {
+		text = entry.StringListData[0].Value
+		rel.Href = entry.StringListData[0].Href
+		rel.FollowedAt = entry.StringListData[0].Timestamp
+	}
+	if text == "" {
+		text = entry.Title
+	}
+
+	if fromHref := handleFromHref(rel.Href); fromHref != "" {
+		rel.Username = fromHref
+		if text != "" && !isHandle(text) {
+			rel.DisplayName = text
+		}
+		return rel
+	}
+	rel.Username = text
+	return rel
+}
+
+func findNonFollowers(following []Relationship, followers map[string]int64) []NonFollower {
+	var nonFollowers []NonFollower
+
+	for _, rel := range following {
+		username := strings.ToLower(rel.Username)
+		if _, exists := followers[username]; !exists {
+			nonFollowers = append(nonFollowers, rel.toNonFollower())
+		}
+	}
+
+	return nonFollowers
+}
@@ -0,0 +1,83 @@
+package analysis
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFindNonFollowers(t *testing.T) {
+	followers := map[string]int64{
+		"user1": 1234567890,
+		"user2": 1234567891,
+	}
+
+	following := []Relationship{
+		{Username: "user1"},
+		{Username: "user3"},
+		{Username: "USER2"}, // Test case insensitivity
+	}
+
+	nonFollowers := findNonFollowers(following, followers)
+
+	if len(nonFollowers) != 1 {
+		t.Fatalf("Expected 1 non-follower, got %d", len(nonFollowers))
+	}
+
+	if nonFollowers[0].Username != "user3" {
+		t.Fatalf("Expected user3 to be non-follower, got %s", nonFollowers[0].Username)
+	}
+
+	if nonFollowers[0].ProfileURL != "https://instagram.com/user3" {
+		t.Fatalf("Expected profile URL for user3, got %s", nonFollowers[0].ProfileURL)
+	}
+}
+
+func TestToRelationship(t *testing.T) {
+	tests := []struct {
+		name        string
+		json        string
+		username    string
+		displayName string
+	}{
+		{
+			name:     "handle in value",
+			json:     `{"string_list_data": [{"href": "https://www.instagram.com/user1", "value": "user1"}]}`,
+			username: "user1",
+		},
+		{
+			name:     "handle in title",
+			json:     `{"title": "user.name_2", "string_list_data": [{"href": "https://www.instagram.com/user.name_2"}]}`,
+			username: "user.name_2",
+		},
+		{
+			name:        "display name in title",
+			json:        `{"title": "Jane Doe 🌸", "string_list_data": [{"href": "https://www.instagram.com/_u/jane.doe"}]}`,
+			username:    "jane.doe",
+			displayName: "Jane Doe 🌸",
+		},
+		{
+			name:     "href wins over stale handle",
+			json:     `{"string_list_data": [{"href": "https://www.instagram.com/new_name/", "value": "old_name"}]}`,
+			username: "new_name",
+		},
+		{
+			name:     "display name without href",
+			json:     `{"title": "Jane Doe"}`,
+			username: "Jane Doe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rel InstagramRelationship
+			if err := json.Unmarshal([]byte(tt.json), &rel); err != nil {
+				t.Fatalf("Failed to parse fixture: %v", err)
+			}
+
+			got := toRelationship(rel, "following.json")
+			if got.Username != tt.username || got.DisplayName != tt.displayName {
+				t.Errorf("Expected (%q, %q), got (%q, %q)", tt.username, tt.displayName, got.Username, got.DisplayName)
+			}
+		})
+	}
+}
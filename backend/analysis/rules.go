@@ -0,0 +1,155 @@
+package analysis
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"sync/atomic"
+)
+
+// defaultRulesJSON is the ruleset shipped with the code.
+//
+//go:embed rules.json
+var defaultRulesJSON []byte
+
+// Rules decide which kind of export file each file is from its path, so a
+// new export layout can be followed by overriding them with SetRules before
+// a release with updated defaults ships. The defaults are in rules.json.
+type Rules struct {
+	// Version identifies the ruleset in logs.
+	Version string `json:"version"`
+	// Kinds holds the rule of each file kind: followers, following,
+	// unfollowed, pending_requests, likes, comments and
+	// other_relationships.
+	Kinds map[string]KindRule `json:"kinds"`
+}
+
+// KindRule matches the files of one kind. A path matches when it matches any
+// of the Match patterns and none of the Exclude patterns. Patterns are Go
+// regular expressions applied to the slash-separated path within the export.
+type KindRule struct {
+	Match   []string `json:"match"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+type compiledRule struct {
+	match, exclude []*regexp.Regexp
+}
+
+type compiledRules struct {
+	version string
+	kinds   map[string]compiledRule
+}
+
+var (
+	defaultRules *compiledRules
+	activeRules  atomic.Pointer[compiledRules]
+)
+
+func init() {
+	r, err := ParseRules(defaultRulesJSON)
+	if err == nil {
+		defaultRules, err = r.compile(nil)
+	}
+	if err != nil {
+		panic(fmt.Sprintf("analysis: invalid rules.json: %v", err))
+	}
+	activeRules.Store(defaultRules)
+}
+
+// ParseRules decodes a JSON ruleset in the format of rules.json.
+func ParseRules(data []byte) (Rules, error) {
+	var r Rules
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&r); err != nil {
+		return Rules{}, fmt.Errorf("decoding rules: %w", err)
+	}
+	return r, nil
+}
+
+// compile compiles the kinds in r on top of base, which may be nil.
+func (r Rules) compile(base *compiledRules) (*compiledRules, error) {
+	compiled := &compiledRules{version: r.Version, kinds: make(map[string]compiledRule)}
+	if base != nil {
+		maps.Copy(compiled.kinds, base.kinds)
+	}
+	for kind, rule := range r.Kinds {
+		if _, ok := parserVersions[kind]; !ok {
+			return nil, fmt.Errorf("unknown file kind %q", kind)
+		}
+		if len(rule.Match) == 0 {
+			return nil, fmt.Errorf("%s: no match patterns", kind)
+		}
+		var c compiledRule
+		var err error
+		if c.match, err = compilePatterns(rule.Match); err != nil {
+			return nil, fmt.Errorf("%s: %w", kind, err)
+		}
+		if c.exclude, err = compilePatterns(rule.Exclude); err != nil {
+			return nil, fmt.Errorf("%s: %w", kind, err)
+		}
+		compiled.kinds[kind] = c
+	}
+	for kind := range parserVersions {
+		if _, ok := compiled.kinds[kind]; !ok {
+			return nil, fmt.Errorf("no rule for file kind %q", kind)
+		}
+	}
+	return compiled, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// SetRules replaces the rules of the kinds in r, keeping the defaults for
+// the others, so an override only needs the kinds it fixes. It returns an
+// error and leaves the rules in use unchanged when a kind is unknown or a
+// pattern doesn't compile.
+func SetRules(r Rules) error {
+	compiled, err := r.compile(defaultRules)
+	if err != nil {
+		return err
+	}
+	activeRules.Store(compiled)
+	return nil
+}
+
+// ResetRules restores the embedded default rules.
+func ResetRules() {
+	activeRules.Store(defaultRules)
+}
+
+// RulesVersion returns the version of the rules in use.
+func RulesVersion() string {
+	return activeRules.Load().version
+}
+
+// matchesKind reports whether name is a file of the given kind under the
+// rules in use.
+func matchesKind(kind, name string) bool {
+	rule := activeRules.Load().kinds[kind]
+	for _, re := range rule.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	for _, re := range rule.match {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
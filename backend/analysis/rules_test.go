@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"testing"
+)
+
+func TestParseRules_Defaults(t *testing.T) {
+	rules, err := ParseRules(defaultRulesJSON)
+	if err != nil {
+		t.Fatalf("ParseRules failed: %v", err)
+	}
+	for kind := range parserVersions {
+		if len(rules.Kinds[kind].Match) == 0 {
+			t.Errorf("Expected a default rule for %s", kind)
+		}
+	}
+	if RulesVersion() != rules.Version {
+		t.Fatalf("Expected version %q in use, got %q", rules.Version, RulesVersion())
+	}
+}
+
+func TestSetRules(t *testing.T) {
+	silenceLogs(t)
+	t.Cleanup(ResetRules)
+
+	// A hypothetical new layout that renames the following list.
+	export := mapFS(map[string]string{
+		"connections/followers_and_following/followers_1.json":         `[{"string_list_data": [{"value": "a"}]}]`,
+		"connections/followers_and_following/accounts_you_follow.json": `{"relationships_following": [{"title": "a"}, {"title": "b"}]}`,
+	})
+	if _, err := AnalyzeFiles(export); err == nil {
+		t.Fatal("Expected the default rules not to find the renamed list")
+	}
+
+	rules, err := ParseRules([]byte(`{"version": "hotfix-1", "kinds": {
+		"following": {"match": ["(?i)(following|accounts_you_follow)[^/]*$"], "exclude": ["(?i)followers[^/]*$"]}
+	}}`))
+	if err != nil {
+		t.Fatalf("ParseRules failed: %v", err)
+	}
+	if err := SetRules(rules); err != nil {
+		t.Fatalf("SetRules failed: %v", err)
+	}
+	result, err := AnalyzeFiles(export)
+	if err != nil {
+		t.Fatalf("Expected the override to find the renamed list, got %v", err)
+	}
+	if result.TotalFollowing != 2 || result.TotalFollowers != 1 {
+		t.Fatalf("Expected 2 following and the default followers rule to still apply, got %d and %d", result.TotalFollowing, result.TotalFollowers)
+	}
+	if RulesVersion() != "hotfix-1" {
+		t.Fatalf("Expected version hotfix-1, got %q", RulesVersion())
+	}
+}
+
+func TestSetRules_Invalid(t *testing.T) {
+	t.Cleanup(ResetRules)
+
+	tests := map[string]Rules{
+		"unknown kind":  {Kinds: map[string]KindRule{"stories": {Match: []string{"x"}}}},
+		"no patterns":   {Kinds: map[string]KindRule{kindLikes: {}}},
+		"bad pattern":   {Kinds: map[string]KindRule{kindLikes: {Match: []string{"("}}}},
+		"bad exclusion": {Kinds: map[string]KindRule{kindLikes: {Match: []string{"x"}, Exclude: []string{"["}}}},
+	}
+	for name, rules := range tests {
+		if err := SetRules(rules); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if !isFollowingFile("following.json") {
+		t.Fatal("Expected rejected rules to leave the defaults in place")
+	}
+
+	if _, err := ParseRules([]byte(`{"kinds": {}, "typo": 1}`)); err == nil {
+		t.Fatal("Expected an error for unknown fields")
+	}
+}
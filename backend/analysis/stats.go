@@ -0,0 +1,105 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReciprocityBucket is the follow-back rate of the accounts followed in one
+// calendar year.
+type ReciprocityBucket struct {
+	Period       string  `json:"period"`
+	Followed     int     `json:"followed"`
+	FollowedBack int     `json:"followed_back"`
+	Rate         float64 `json:"rate"`
+}
+
+// Heatmap counts follow events by weekday (rows, Sunday first) and hour of
+// day (columns), in UTC.
+type Heatmap [7][24]int
+
+// ActivityHeatmaps shows when the user follows people and when their
+// followers followed them.
+type ActivityHeatmaps struct {
+	Following Heatmap `json:"following"`
+	Followers Heatmap `json:"followers"`
+}
+
+// Stats holds aggregate figures derived from the relationship timestamps.
+type Stats struct {
+	Timeline []ReciprocityBucket `json:"timeline"`
+	Heatmap  ActivityHeatmaps    `json:"heatmap"`
+}
+
+// roundRate rounds a ratio to three decimals.
+func roundRate(numerator, denominator int) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return math.Round(float64(numerator)/float64(denominator)*1000) / 1000
+}
+
+// reciprocityTimeline buckets the followed accounts by the year they were
+// followed and computes how many of each year follow back, oldest year
+// first. Entries without a timestamp are left out.
+func reciprocityTimeline(following []Relationship, followers map[string]int64) []ReciprocityBucket {
+	buckets := make(map[int]*ReciprocityBucket)
+	for _, rel := range following {
+		if rel.FollowedAt == 0 {
+			continue
+		}
+		year := time.Unix(rel.FollowedAt, 0).UTC().Year()
+		bucket, ok := buckets[year]
+		if !ok {
+			bucket = &ReciprocityBucket{Period: strconv.Itoa(year)}
+			buckets[year] = bucket
+		}
+		bucket.Followed++
+		if _, followsBack := followers[strings.ToLower(rel.Username)]; followsBack {
+			bucket.FollowedBack++
+		}
+	}
+
+	years := make([]int, 0, len(buckets))
+	for year := range buckets {
+		years = append(years, year)
+	}
+	sort.Ints(years)
+
+	timeline := make([]ReciprocityBucket, 0, len(years))
+	for _, year := range years {
+		bucket := buckets[year]
+		bucket.Rate = roundRate(bucket.FollowedBack, bucket.Followed)
+		timeline = append(timeline, *bucket)
+	}
+	return timeline
+}
+
+func (h *Heatmap) add(timestamp int64) {
+	if timestamp == 0 {
+		return
+	}
+	t := time.Unix(timestamp, 0).UTC()
+	h[t.Weekday()][t.Hour()]++
+}
+
+func activityHeatmaps(following []Relationship, followers map[string]int64) ActivityHeatmaps {
+	var heatmaps ActivityHeatmaps
+	for _, rel := range following {
+		heatmaps.Following.add(rel.FollowedAt)
+	}
+	for _, followedAt := range followers {
+		heatmaps.Followers.add(followedAt)
+	}
+	return heatmaps
+}
+
+func buildStats(following []Relationship, followers map[string]int64) *Stats {
+	return &Stats{
+		Timeline: reciprocityTimeline(following, followers),
+		Heatmap:  activityHeatmaps(following, followers),
+	}
+}
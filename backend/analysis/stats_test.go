@@ -0,0 +1,55 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func unix(year int, month time.Month) int64 {
+	return time.Date(year, month, 1, 12, 0, 0, 0, time.UTC).Unix()
+}
+
+func TestReciprocityTimeline(t *testing.T) {
+	following := []Relationship{
+		{Username: "a", FollowedAt: unix(2024, 3)},
+		{Username: "B", FollowedAt: unix(2019, 5)},
+		{Username: "c", FollowedAt: unix(2019, 7)},
+		{Username: "d", FollowedAt: unix(2024, 1)},
+		{Username: "e", FollowedAt: unix(2024, 2)},
+		{Username: "nodate"},
+	}
+	followers := map[string]int64{"a": 1, "b": 1, "d": 1, "nodate": 1}
+
+	timeline := reciprocityTimeline(following, followers)
+
+	want := []ReciprocityBucket{
+		{Period: "2019", Followed: 2, FollowedBack: 1, Rate: 0.5},
+		{Period: "2024", Followed: 3, FollowedBack: 2, Rate: 0.667},
+	}
+	if len(timeline) != len(want) {
+		t.Fatalf("Expected %d buckets, got %+v", len(want), timeline)
+	}
+	for i := range want {
+		if timeline[i] != want[i] {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, want[i], timeline[i])
+		}
+	}
+}
+
+func TestActivityHeatmaps(t *testing.T) {
+	// 2024-06-03 was a Monday.
+	monday9 := time.Date(2024, 6, 3, 9, 30, 0, 0, time.UTC).Unix()
+	sunday23 := time.Date(2024, 6, 2, 23, 0, 0, 0, time.UTC).Unix()
+
+	heatmaps := activityHeatmaps(
+		[]Relationship{{Username: "a", FollowedAt: monday9}, {Username: "b", FollowedAt: monday9}, {Username: "c"}},
+		map[string]int64{"d": sunday23},
+	)
+
+	if heatmaps.Following[time.Monday][9] != 2 {
+		t.Errorf("Expected 2 follows on Monday 09:00, got %d", heatmaps.Following[time.Monday][9])
+	}
+	if heatmaps.Followers[time.Sunday][23] != 1 {
+		t.Errorf("Expected 1 follower on Sunday 23:00, got %d", heatmaps.Followers[time.Sunday][23])
+	}
+}
@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"strings"
+)
+
+var errNoList = errors.New("no list found")
+
+// decodeList decodes a file that is either a bare array of T or an object
+// wrapping one.
+func decodeList[T any](content []byte) ([]T, error) {
+	var list []T
+	if err := json.Unmarshal(content, &list); err == nil {
+		return list, nil
+	}
+
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal(content, &wrapped); err != nil {
+		return nil, err
+	}
+	for _, raw := range wrapped {
+		if err := json.Unmarshal(raw, &list); err == nil {
+			return list, nil
+		}
+	}
+	return nil, errNoList
+}
+
+// decodeRelationshipList decodes a relationships file that is either a bare
+// array of entries or an object wrapping one, such as
+// {"relationships_unfollowed_users": [...]}.
+func decodeRelationshipList(content []byte) ([]InstagramRelationship, error) {
+	return decodeList[InstagramRelationship](content)
+}
+
+// readUnfollowed returns the accounts listed in
+// recently_unfollowed_profiles.json, keyed by lowercased username, with the
+// time they were unfollowed.
+func readUnfollowed(fsys fs.FS, report *Report) map[string]int64 {
+	unfollowed := make(map[string]int64)
+	files, _ := candidateFiles(fsys, ofKind(kindUnfollowed))
+	for _, name := range files {
+		content, err := readFile(fsys, name)
+		if err != nil {
+			report.unreadable(name, err)
+			continue
+		}
+		report.processed(name, kindUnfollowed, content)
+		entries, err := decodeRelationshipList(content)
+		if err != nil {
+			log.Printf("Error parsing %s: %v", logPath(name), err)
+			continue
+		}
+		for _, entry := range entries {
+			if rel := toRelationship(entry, name); rel.Username != "" {
+				unfollowed[strings.ToLower(rel.Username)] = rel.FollowedAt
+			}
+		}
+	}
+	return unfollowed
+}
+
+// reconcileUnfollowed removes accounts that Instagram lists both as followed
+// and as recently unfollowed, which happens when the two files were exported
+// at slightly different times. Precedence: the later event wins. An account
+// unfollowed after (or at) the time it was followed is dropped; one followed
+// again after being unfollowed is kept. Without both timestamps the
+// following list is trusted, since it is the authoritative file.
+func reconcileUnfollowed(following []Relationship, unfollowed map[string]int64, report *Report) []Relationship {
+	if len(unfollowed) == 0 {
+		return following
+	}
+
+	kept := following[:0:0]
+	dropped := 0
+	for _, rel := range following {
+		unfollowedAt, ok := unfollowed[strings.ToLower(rel.Username)]
+		if ok && unfollowedAt != 0 && rel.FollowedAt != 0 && unfollowedAt >= rel.FollowedAt {
+			dropped++
+			continue
+		}
+		kept = append(kept, rel)
+	}
+
+	if dropped > 0 {
+		report.warn(WarnUnfollowedStillListed, "",
+			"%d %s you recently unfollowed %s still listed as followed and %s been excluded.",
+			dropped, plural(dropped, "account", "accounts"), plural(dropped, "was", "were"), plural(dropped, "has", "have"))
+	}
+	return kept
+}
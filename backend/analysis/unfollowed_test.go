@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeRelationshipList(t *testing.T) {
+	for _, content := range []string{
+		`[{"string_list_data": [{"value": "a"}]}]`,
+		`{"relationships_unfollowed_users": [{"string_list_data": [{"value": "a"}]}]}`,
+	} {
+		list, err := decodeRelationshipList([]byte(content))
+		if err != nil || len(list) != 1 {
+			t.Errorf("decodeRelationshipList(%s) = %v, %v", content, list, err)
+		}
+	}
+
+	if _, err := decodeRelationshipList([]byte(`{"unrelated": 1}`)); err == nil {
+		t.Error("Expected an error for a file without a relationship list")
+	}
+}
+
+func TestAnalyzeFiles_RecentlyUnfollowed(t *testing.T) {
+	silenceLogs(t)
+
+	// Pin the clock near the timestamps so the export isn't stale.
+	analyzer := Analyzer{Now: func() time.Time { return time.Unix(1000, 0) }}
+	result, err := analyzer.AnalyzeFiles(mapFS(map[string]string{
+		"connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "fan"}]}]`,
+		"connections/followers_and_following/following.json": `{"relationships_following": [
+			{"title": "gone", "string_list_data": [{"href": "https://www.instagram.com/gone", "timestamp": 100}]},
+			{"title": "refollowed", "string_list_data": [{"href": "https://www.instagram.com/refollowed", "timestamp": 300}]},
+			{"title": "kept", "string_list_data": [{"href": "https://www.instagram.com/kept", "timestamp": 100}]}
+		]}`,
+		"connections/followers_and_following/recently_unfollowed_profiles.json": `{"relationships_unfollowed_users": [
+			{"string_list_data": [{"href": "https://www.instagram.com/gone", "value": "gone", "timestamp": 200}]},
+			{"string_list_data": [{"href": "https://www.instagram.com/refollowed", "value": "refollowed", "timestamp": 200}]}
+		]}`,
+	}))
+	if err != nil {
+		t.Fatalf("Failed to analyze export: %v", err)
+	}
+
+	if result.TotalFollowing != 2 || len(result.NonFollowers) != 2 {
+		t.Fatalf("Expected 2 following and 2 non-followers, got %d and %d", result.TotalFollowing, len(result.NonFollowers))
+	}
+	for _, nf := range result.NonFollowers {
+		if nf.Username == "gone" {
+			t.Fatal("Expected the unfollowed account to be excluded")
+		}
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnUnfollowedStillListed {
+		t.Fatalf("Expected an unfollowed_still_listed warning, got %+v", result.Warnings)
+	}
+}
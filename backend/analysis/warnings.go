@@ -0,0 +1,118 @@
+package analysis
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"time"
+)
+
+// Warning describes a problem that didn't stop the analysis but may affect
+// the results.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+}
+
+// Warning codes.
+const (
+	WarnUnreadableFile        = "unreadable_file"
+	WarnOversizedFile         = "oversized_file"
+	WarnUnfollowedStillListed = "unfollowed_still_listed"
+	WarnPlatformSkipped       = "platform_skipped"
+	WarnStaleExport           = "stale_export"
+	// WarnUnknownRelationshipFile flags a list of accounts in a file the
+	// analysis doesn't recognize, often from a new Instagram feature.
+	WarnUnknownRelationshipFile = "unknown_relationship_file"
+	// WarnDuplicateEntries reports entries merged from export files that
+	// repeat each other.
+	WarnDuplicateEntries = "duplicate_entries"
+)
+
+// Report collects the warnings raised while reading an export.
+type Report struct {
+	Warnings []Warning
+	Partial  bool
+
+	// files are the files read, for the processing receipt.
+	files []ReceiptFile
+}
+
+func (p *Report) warn(code, file, format string, args ...interface{}) {
+	p.Warnings = append(p.Warnings, Warning{Code: code, Message: fmt.Sprintf(format, args...), File: file})
+}
+
+// unreadable records a matched relationship file that couldn't be read and
+// marks the result as partial.
+func (p *Report) unreadable(file string, err error) {
+	log.Printf("Error reading %s: %s", logPath(file), logError(err, file))
+	p.Partial = true
+	if errors.Is(err, errFileTooLarge) {
+		p.warn(WarnOversizedFile, file, "This file is larger than %d MB and was skipped; the export may be corrupted, so some accounts may be missing from the results.", maxFileSize>>20)
+		return
+	}
+	p.warn(WarnUnreadableFile, file, "This file could not be read, so some accounts may be missing from the results.")
+}
+
+// maxFileSize caps the decompressed size of a single relationships file.
+// Real files stay well below it even for very large accounts; anything
+// bigger is more likely a corrupted or hostile export than data worth
+// unmarshalling.
+var maxFileSize int64 = 20 << 20
+
+var errFileTooLarge = errors.New("export file exceeds size limit")
+
+const (
+	readAttempts = 3
+	retryDelay   = 10 * time.Millisecond
+)
+
+// readFile reads a file of the export, retrying failures that may be
+// transient. Format and checksum errors mean the file itself is damaged and
+// are returned straight away, as are files over maxFileSize.
+func readFile(fsys fs.FS, name string) ([]byte, error) {
+	var err error
+	for attempt := 1; attempt <= readAttempts; attempt++ {
+		var content []byte
+		if content, err = readFileOnce(fsys, name); err == nil {
+			return content, nil
+		}
+		if errors.Is(err, zip.ErrFormat) || errors.Is(err, zip.ErrAlgorithm) || errors.Is(err, zip.ErrChecksum) || errors.Is(err, errFileTooLarge) || errors.Is(err, ErrWrongPassword) {
+			return nil, err
+		}
+		if attempt < readAttempts {
+			time.Sleep(retryDelay * time.Duration(attempt))
+		}
+	}
+	return nil, err
+}
+
+// readFileOnce reads a file, refusing it up front when it declares itself
+// too large. For ZIP archives the declared size comes from the central
+// directory, and archive/zip fails with ErrFormat if an entry decompresses to
+// more than that, so the check can't be dodged by lying in the header. For
+// other file systems the read is bounded as well.
+func readFileOnce(fsys fs.FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > maxFileSize {
+		return nil, fmt.Errorf("%w: %s declares %d bytes", errFileTooLarge, name, info.Size())
+	}
+
+	content, err := io.ReadAll(io.LimitReader(f, maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxFileSize {
+		return nil, fmt.Errorf("%w: %s", errFileTooLarge, name)
+	}
+	return content, nil
+}
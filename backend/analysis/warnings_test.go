@@ -0,0 +1,112 @@
+package analysis
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAnalyze_UnreadableFileIsPartial(t *testing.T) {
+	silenceLogs(t)
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	files := map[string]string{
+		"connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "user1"}]}]`,
+		"connections/followers_and_following/following.json":   `{"relationships_following": [{"title": "user2"}]}`,
+	}
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	// A second followers file whose CRC doesn't match its content.
+	damaged := []byte(`[{"string_list_data": [{"value": "user2"}]}]`)
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "connections/followers_and_following/followers_2.json",
+		Method:             zip.Store,
+		CRC32:              1,
+		CompressedSize64:   uint64(len(damaged)),
+		UncompressedSize64: uint64(len(damaged)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create entry: %v", err)
+	}
+	w.Write(damaged)
+	zw.Close()
+
+	result, err := Analyze(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Expected the analysis to succeed, got %v", err)
+	}
+
+	if !result.Partial {
+		t.Error("Expected the result to be marked partial")
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnUnreadableFile ||
+		result.Warnings[0].File != "connections/followers_and_following/followers_2.json" {
+		t.Fatalf("Expected one unreadable_file warning for followers_2.json, got %+v", result.Warnings)
+	}
+}
+
+func TestAnalyzeFiles_OversizedFileIsSkipped(t *testing.T) {
+	silenceLogs(t)
+	defer func(limit int64) { maxFileSize = limit }(maxFileSize)
+	maxFileSize = 64
+
+	result, err := AnalyzeFiles(mapFS(map[string]string{
+		"connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "user1"}]}]`,
+		"connections/followers_and_following/followers_2.json": `[{"string_list_data": [{"value": "user2"}]}, {"string_list_data": [{"value": "user3"}]}]`,
+		"connections/followers_and_following/following.json":   `{"relationships_following": [{"title": "user2"}]}`,
+	}))
+	if err != nil {
+		t.Fatalf("Expected the analysis to succeed, got %v", err)
+	}
+
+	if !result.Partial {
+		t.Error("Expected the result to be marked partial")
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnOversizedFile ||
+		result.Warnings[0].File != "connections/followers_and_following/followers_2.json" {
+		t.Fatalf("Expected one oversized_file warning for followers_2.json, got %+v", result.Warnings)
+	}
+}
+
+func TestReadFile_SizeLieIsCaught(t *testing.T) {
+	defer func(limit int64) { maxFileSize = limit }(maxFileSize)
+	maxFileSize = 8
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	content := []byte("much more than eight bytes")
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "followers_1.json",
+		Method:             zip.Store,
+		CompressedSize64:   uint64(len(content)),
+		UncompressedSize64: 4,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create entry: %v", err)
+	}
+	w.Write(content)
+	zw.Close()
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	if _, err := readFile(zipReader, "followers_1.json"); err == nil {
+		t.Fatal("Expected an entry larger than its declared size to fail")
+	}
+}
+
+func TestReadFile_TooLarge(t *testing.T) {
+	defer func(limit int64) { maxFileSize = limit }(maxFileSize)
+	maxFileSize = 8
+
+	fsys := fstest.MapFS{"followers_1.json": &fstest.MapFile{Data: []byte("much more than eight bytes")}}
+	if _, err := readFile(fsys, "followers_1.json"); !errors.Is(err, errFileTooLarge) {
+		t.Fatalf("Expected errFileTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,385 @@
+package followercount
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Principal is the caller identified by an Authenticator.
+type Principal struct {
+	Subject string
+	Claims  map[string]any
+	// guest is set for embedded widgets identified by a guest token.
+	guest bool
+}
+
+// Authenticator identifies the caller of a request. Implementations return
+// an error wrapping ErrUnauthenticated when the credentials are missing or
+// invalid, and ErrAuthUnavailable when they could not be checked.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+type principalKey struct{}
+
+// principalFromContext returns the caller identified by withAuth, or nil when
+// authentication is disabled.
+func principalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// authenticatorFromEnv builds the Authenticator selected by AUTH_MODE. An
+// empty mode (or "none") disables authentication, which is the default for
+// the public app.
+func authenticatorFromEnv() (Authenticator, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(getEnv("AUTH_MODE"))); mode {
+	case "", "none":
+		return nil, nil
+	case "jwt":
+		secret := getSecret("AUTH_JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("AUTH_JWT_SECRET is not set")
+		}
+		if secret == getSecret("GUEST_TOKEN_SECRET") {
+			return nil, errors.New("AUTH_JWT_SECRET and GUEST_TOKEN_SECRET must differ")
+		}
+		return &jwtAuthenticator{
+			secret:   []byte(secret),
+			issuer:   getEnv("AUTH_JWT_ISSUER"),
+			audience: getEnv("AUTH_JWT_AUDIENCE"),
+		}, nil
+	case "oidc":
+		endpoint := getEnv("AUTH_OIDC_INTROSPECTION_URL")
+		if endpoint == "" {
+			return nil, errors.New("AUTH_OIDC_INTROSPECTION_URL is not set")
+		}
+		return &introspectionAuthenticator{
+			endpoint:     endpoint,
+			clientID:     getEnv("AUTH_OIDC_CLIENT_ID"),
+			clientSecret: getSecret("AUTH_OIDC_CLIENT_SECRET"),
+		}, nil
+	case "hmac":
+		keys := parseHMACKeys(getSecret("AUTH_HMAC_KEYS"))
+		if len(keys) == 0 {
+			return nil, errors.New("AUTH_HMAC_KEYS is not set")
+		}
+		return &hmacAuthenticator{keys: keys}, nil
+	default:
+		return nil, fmt.Errorf("unknown AUTH_MODE %q", mode)
+	}
+}
+
+// withAuth rejects requests the configured Authenticator doesn't accept and
+// stores the caller in the request context. Guests already identified by
+// withGuestToken pass through. A misconfigured deployment fails closed rather
+// than serving unauthenticated traffic.
+func withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principalFromContext(r.Context()) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		auth, err := authenticatorFromEnv()
+		if err != nil {
+			log.Printf("Authentication misconfigured: %v", err)
+			sendDomainError(w, ErrAuthUnavailable)
+			return
+		}
+		if auth == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := auth.Authenticate(r)
+		if err != nil {
+			log.Printf("Authentication failed: %v", err)
+			sendDomainError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+func bearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return "", fmt.Errorf("%w: missing bearer token", ErrUnauthenticated)
+	}
+	return token, nil
+}
+
+// jwtAuthenticator accepts HS256-signed bearer tokens issued by the
+// deployment's own identity service. Guest tokens are never accepted, even
+// when they carry a valid signature.
+type jwtAuthenticator struct {
+	secret   []byte
+	issuer   string
+	audience string
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, err := bearerToken(r)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := verifyHS256(token, a.secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClaims(claims, a.issuer, a.audience, clock.Now()); err != nil {
+		return nil, err
+	}
+	if hasAudience(claims["aud"], guestTokenAudience) {
+		return nil, fmt.Errorf("%w: guest token", ErrUnauthenticated)
+	}
+
+	subject, _ := claims["sub"].(string)
+	return &Principal{Subject: subject, Claims: claims}, nil
+}
+
+// verifyHS256 checks the signature of an HS256 JWT and returns its claims,
+// which the caller still has to validate.
+func verifyHS256(token string, secret []byte) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrUnauthenticated)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: unsupported token header", ErrUnauthenticated)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, fmt.Errorf("%w: bad token signature", ErrUnauthenticated)
+	}
+
+	var claims map[string]any
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed token claims", ErrUnauthenticated)
+	}
+	return claims, nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// validateClaims checks the registered exp, nbf, iss and aud claims. Tokens
+// without exp are rejected, since they would never expire; empty issuer or
+// audience settings skip the corresponding check.
+func validateClaims(claims map[string]any, issuer, audience string, now time.Time) error {
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: token has no expiry", ErrUnauthenticated)
+	}
+	if now.Unix() >= int64(exp) {
+		return fmt.Errorf("%w: token expired", ErrUnauthenticated)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Unix() < int64(nbf) {
+		return fmt.Errorf("%w: token not yet valid", ErrUnauthenticated)
+	}
+	if issuer != "" && claims["iss"] != issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrUnauthenticated)
+	}
+	if audience != "" && !hasAudience(claims["aud"], audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrUnauthenticated)
+	}
+	return nil
+}
+
+// hasAudience reports whether aud, a string or array of strings, contains
+// want.
+func hasAudience(aud any, want string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == want
+	case []any:
+		for _, a := range aud {
+			if a == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// introspectionAuthenticator validates opaque bearer tokens against an OAuth
+// 2.0 token introspection endpoint (RFC 7662), as exposed by most OIDC
+// providers.
+type introspectionAuthenticator struct {
+	endpoint     string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+}
+
+func (a *introspectionAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, err := bearerToken(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuthUnavailable, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if a.clientID != "" {
+		req.SetBasicAuth(a.clientID, a.clientSecret)
+	}
+
+	client := a.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuthUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: introspection returned %d", ErrAuthUnavailable, resp.StatusCode)
+	}
+
+	var claims map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuthUnavailable, err)
+	}
+	if active, _ := claims["active"].(bool); !active {
+		return nil, fmt.Errorf("%w: token is not active", ErrUnauthenticated)
+	}
+
+	subject, _ := claims["sub"].(string)
+	return &Principal{Subject: subject, Claims: claims}, nil
+}
+
+// Headers carrying an HMAC request signature.
+const (
+	signatureKeyHeader       = "X-Signature-Key"
+	signatureTimestampHeader = "X-Signature-Timestamp"
+	signatureHeader          = "X-Signature"
+)
+
+// maxSignatureSkew bounds how old (or how far in the future) a signed
+// request's timestamp may be, limiting the window for replays.
+const maxSignatureSkew = 5 * time.Minute
+
+// hmacAuthenticator accepts requests signed with a shared secret, for
+// partner integrations calling the API server to server. The signature is the
+// hex HMAC-SHA256 of the string to sign built by hmacStringToSign.
+type hmacAuthenticator struct {
+	keys map[string][]byte
+}
+
+// parseHMACKeys parses AUTH_HMAC_KEYS, a comma-separated list of
+// keyID=secret entries. Malformed entries are logged and ignored.
+func parseHMACKeys(value string) map[string][]byte {
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || secret == "" {
+			log.Printf("Warning: ignoring malformed AUTH_HMAC_KEYS entry")
+			continue
+		}
+		keys[id] = []byte(secret)
+	}
+	return keys
+}
+
+// hmacStringToSign joins the timestamp, method, path, canonical query and
+// body hash with newlines. Signing the query keeps options such as format or
+// analyzers from being changed in transit.
+func hmacStringToSign(timestamp, method, path, query string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{timestamp, method, path, query, hex.EncodeToString(sum[:])}, "\n")
+}
+
+// canonicalQuery puts a raw query string in the form that is signed:
+// parameters sorted by name, repeated ones in their original order, and
+// percent-encoded as url.Values.Encode does, so "b=2&a=1" and "a=1&b=2" sign
+// alike. It is empty without a query.
+func canonicalQuery(raw string) (string, error) {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return "", err
+	}
+	return values.Encode(), nil
+}
+
+func (a *hmacAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	keyID := r.Header.Get(signatureKeyHeader)
+	secret, ok := a.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown signing key", ErrUnauthenticated)
+	}
+
+	timestamp := r.Header.Get(signatureTimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature timestamp", ErrUnauthenticated)
+	}
+	if skew := clock.Now().Sub(time.Unix(seconds, 0)); skew > maxSignatureSkew || skew < -maxSignatureSkew {
+		return nil, fmt.Errorf("%w: signature timestamp out of range", ErrUnauthenticated)
+	}
+
+	// The body is bounded by withBodyLimit further up the chain; hand the
+	// handler a fresh reader over the bytes that were signed.
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, ErrUploadTooLarge
+		}
+		return nil, fmt.Errorf("%w: %w", ErrReadBody, err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	signature, err := hex.DecodeString(r.Header.Get(signatureHeader))
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrUnauthenticated)
+	}
+	query, err := canonicalQuery(r.URL.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed query", ErrUnauthenticated)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(hmacStringToSign(timestamp, r.Method, r.URL.Path, query, body)))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, fmt.Errorf("%w: bad signature", ErrUnauthenticated)
+	}
+
+	return &Principal{Subject: keyID}, nil
+}
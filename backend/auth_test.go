@@ -0,0 +1,227 @@
+package followercount
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signJWT(t *testing.T, secret string, claims map[string]any) string {
+	t.Helper()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Failed to marshal claims: %v", err)
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// authProbe records the principal the wrapped handler saw.
+func authProbe(seen **Principal) http.Handler {
+	return withAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*seen = principalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestWithAuth_Disabled(t *testing.T) {
+	withEnv(t, "AUTH_MODE", "")
+
+	var seen *Principal
+	w := httptest.NewRecorder()
+	authProbe(&seen).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if w.Code != http.StatusOK || seen != nil {
+		t.Fatalf("Expected anonymous pass-through, got %d (principal %v)", w.Code, seen)
+	}
+}
+
+func TestWithAuth_Misconfigured(t *testing.T) {
+	withEnv(t, "AUTH_MODE", "jwt")
+	withEnv(t, "AUTH_JWT_SECRET", "")
+
+	var seen *Principal
+	w := httptest.NewRecorder()
+	authProbe(&seen).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", w.Code)
+	}
+}
+
+func TestWithAuth_JWT(t *testing.T) {
+	withEnv(t, "AUTH_MODE", "jwt")
+	withEnv(t, "AUTH_JWT_SECRET", "s3cret")
+	withEnv(t, "AUTH_JWT_ISSUER", "https://id.example")
+	withEnv(t, "AUTH_JWT_AUDIENCE", "follower-watch")
+
+	future := float64(time.Now().Add(time.Hour).Unix())
+	valid := map[string]any{"sub": "user-1", "iss": "https://id.example", "aud": []string{"follower-watch"}, "exp": future}
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"valid", signJWT(t, "s3cret", valid), http.StatusOK},
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong secret", signJWT(t, "other", valid), http.StatusUnauthorized},
+		{"no expiry", signJWT(t, "s3cret", map[string]any{"sub": "user-1", "iss": "https://id.example", "aud": "follower-watch"}), http.StatusUnauthorized},
+		{"expired", signJWT(t, "s3cret", map[string]any{"sub": "user-1", "iss": "https://id.example", "aud": "follower-watch", "exp": 1}), http.StatusUnauthorized},
+		{"wrong audience", signJWT(t, "s3cret", map[string]any{"sub": "user-1", "iss": "https://id.example", "aud": "other", "exp": future}), http.StatusUnauthorized},
+		{"alg none", strings.Replace(signJWT(t, "s3cret", valid), "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", "eyJhbGciOiJub25lIn0", 1), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			var seen *Principal
+			w := httptest.NewRecorder()
+			authProbe(&seen).ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d", tt.want, w.Code)
+			}
+			if tt.want == http.StatusOK && (seen == nil || seen.Subject != "user-1") {
+				t.Fatalf("Expected principal user-1, got %v", seen)
+			}
+		})
+	}
+}
+
+func TestWithAuth_RejectsGuestTokens(t *testing.T) {
+	silenceLogs(t)
+	withEnv(t, "AUTH_MODE", "jwt")
+	withEnv(t, "AUTH_JWT_SECRET", "s3cret")
+	withEnv(t, "AUTH_JWT_ISSUER", "")
+	withEnv(t, "AUTH_JWT_AUDIENCE", "")
+	withEnv(t, "GUEST_TOKEN_SECRET", "guest-s3cret")
+
+	// A guest token signed with the JWT secret, as if the two were shared.
+	token := signJWT(t, "s3cret", map[string]any{"sub": "acme", "aud": guestTokenAudience, "exp": float64(time.Now().Add(time.Hour).Unix())})
+	request := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		var seen *Principal
+		w := httptest.NewRecorder()
+		authProbe(&seen).ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := request(); code != http.StatusUnauthorized {
+		t.Fatalf("Expected a guest token to be rejected as a bearer token, got %d", code)
+	}
+
+	withEnv(t, "GUEST_TOKEN_SECRET", "s3cret")
+	if code := request(); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected a shared JWT and guest secret to fail closed, got %d", code)
+	}
+}
+
+func TestWithAuth_OIDCIntrospection(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "client" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		active := r.PostForm.Get("token") == "good-token"
+		json.NewEncoder(w).Encode(map[string]any{"active": active, "sub": "user-2"})
+	}))
+	defer provider.Close()
+
+	withEnv(t, "AUTH_MODE", "oidc")
+	withEnv(t, "AUTH_OIDC_INTROSPECTION_URL", provider.URL)
+	withEnv(t, "AUTH_OIDC_CLIENT_ID", "client")
+	withEnv(t, "AUTH_OIDC_CLIENT_SECRET", "secret")
+
+	for token, want := range map[string]int{"good-token": http.StatusOK, "revoked": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		var seen *Principal
+		w := httptest.NewRecorder()
+		authProbe(&seen).ServeHTTP(w, req)
+
+		if w.Code != want {
+			t.Fatalf("%s: expected status %d, got %d", token, want, w.Code)
+		}
+	}
+
+	withEnv(t, "AUTH_OIDC_CLIENT_SECRET", "wrong")
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer good-token")
+	var seen *Principal
+	w := httptest.NewRecorder()
+	authProbe(&seen).ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 when introspection fails, got %d", w.Code)
+	}
+}
+
+func TestWithAuth_HMAC(t *testing.T) {
+	withEnv(t, "AUTH_MODE", "hmac")
+	withEnv(t, "AUTH_HMAC_KEYS", "partner=k3y, broken")
+
+	sign := func(secret, timestamp, body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(hmacStringToSign(timestamp, http.MethodPost, "/plan", "format=ics&per_day=20", []byte(body))))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		query     string
+		key       string
+		timestamp string
+		signature string
+		want      int
+	}{
+		{"valid", "per_day=20&format=ics", "partner", now, sign("k3y", now, "payload"), http.StatusOK},
+		{"unknown key", "per_day=20&format=ics", "other", now, sign("k3y", now, "payload"), http.StatusUnauthorized},
+		{"tampered body", "per_day=20&format=ics", "partner", now, sign("k3y", now, "something else"), http.StatusUnauthorized},
+		{"tampered query", "per_day=50&format=ics", "partner", now, sign("k3y", now, "payload"), http.StatusUnauthorized},
+		{"dropped query", "", "partner", now, sign("k3y", now, "payload"), http.StatusUnauthorized},
+		{"stale timestamp", "per_day=20&format=ics", "partner", stale, sign("k3y", stale, "payload"), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/plan?"+tt.query, strings.NewReader("payload"))
+			req.Header.Set(signatureKeyHeader, tt.key)
+			req.Header.Set(signatureTimestampHeader, tt.timestamp)
+			req.Header.Set(signatureHeader, tt.signature)
+
+			var body string
+			h := withAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				body = string(data)
+			}))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d", tt.want, w.Code)
+			}
+			if tt.want == http.StatusOK && body != "payload" {
+				t.Fatalf("Expected the handler to still see the body, got %q", body)
+			}
+		})
+	}
+}
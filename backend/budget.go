@@ -0,0 +1,143 @@
+package followercount
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// budgetSampleInterval is how often the heap is sampled while a request runs
+// to approximate its peak.
+const budgetSampleInterval = 100 * time.Millisecond
+
+// memoryWarnRatio is the share of the memory limit above which a request is
+// logged as close to being killed.
+const memoryWarnRatio = 0.8
+
+// RequestBudget reports the resources a request used. The figures come from
+// process-wide runtime.MemStats, so they are exact only when the function
+// serves one request at a time, as Cloud Functions does by default.
+type RequestBudget struct {
+	DurationMS int64 `json:"duration_ms"`
+	// AllocBytes is the total allocated while the request ran.
+	AllocBytes uint64 `json:"alloc_bytes"`
+	// PeakHeapBytes is the largest live heap observed while the request ran.
+	PeakHeapBytes uint64 `json:"peak_heap_bytes"`
+	// LimitBytes is the memory limit the peak is measured against, or 0 when
+	// none is known.
+	LimitBytes uint64 `json:"limit_bytes,omitempty"`
+}
+
+// budget tracks one request's resource use from the moment it was created.
+type budget struct {
+	start      time.Time
+	startAlloc uint64
+
+	mu       sync.Mutex
+	peakHeap uint64
+}
+
+func newBudget() *budget {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return &budget{start: time.Now(), startAlloc: m.TotalAlloc, peakHeap: m.HeapAlloc}
+}
+
+// sample records the current heap and returns the allocation total.
+func (b *budget) sample() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if m.HeapAlloc > b.peakHeap {
+		b.peakHeap = m.HeapAlloc
+	}
+	return m.TotalAlloc
+}
+
+// snapshot reports the resources used so far.
+func (b *budget) snapshot() RequestBudget {
+	totalAlloc := b.sample()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return RequestBudget{
+		DurationMS:    time.Since(b.start).Milliseconds(),
+		AllocBytes:    totalAlloc - b.startAlloc,
+		PeakHeapBytes: b.peakHeap,
+		LimitBytes:    memoryLimit(),
+	}
+}
+
+type budgetKey struct{}
+
+// budgetFromContext returns the budget started by withBudget, or nil when the
+// request did not pass through it.
+func budgetFromContext(ctx context.Context) *budget {
+	b, _ := ctx.Value(budgetKey{}).(*budget)
+	return b
+}
+
+// memoryLimit is the memory available to the function: MEMORY_LIMIT_MB when
+// the operator set it (to the function's configured memory), otherwise the
+// Go runtime's soft limit from GOMEMLIMIT, otherwise 0.
+func memoryLimit() uint64 {
+	if mb, err := strconv.ParseUint(getEnv("MEMORY_LIMIT_MB"), 10, 64); err == nil && mb > 0 {
+		return mb * 1024 * 1024
+	}
+	if limit := debug.SetMemoryLimit(-1); limit > 0 && limit < math.MaxInt64 {
+		return uint64(limit)
+	}
+	return 0
+}
+
+// withBudget measures the duration and memory of each request, samples the
+// heap while it runs, and logs a warning when its peak comes within
+// memoryWarnRatio of the memory limit, before the platform kills an
+// instance for running out of memory.
+func withBudget(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b := newBudget()
+		done := make(chan struct{})
+		go func() {
+			ticker := time.NewTicker(budgetSampleInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					b.sample()
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), budgetKey{}, b)))
+		close(done)
+
+		usage := b.snapshot()
+		if usage.LimitBytes > 0 && float64(usage.PeakHeapBytes) >= memoryWarnRatio*float64(usage.LimitBytes) {
+			log.Printf("Warning: request %s peaked at %d MB of %d MB memory limit after %d ms",
+				requestIDFromContext(r.Context()), usage.PeakHeapBytes>>20, usage.LimitBytes>>20, usage.DurationMS)
+		}
+	})
+}
+
+// debugBudget returns the resources used so far when the operator enabled
+// DEBUG_RESPONSES=true and the request asked for them with debug=true.
+func debugBudget(r *http.Request) *RequestBudget {
+	if getEnv("DEBUG_RESPONSES") != "true" || r.URL.Query().Get("debug") != "true" {
+		return nil
+	}
+	b := budgetFromContext(r.Context())
+	if b == nil {
+		return nil
+	}
+	usage := b.snapshot()
+	return &usage
+}
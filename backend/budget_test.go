@@ -0,0 +1,74 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var budgetSink []byte
+
+func TestWithBudget(t *testing.T) {
+	var usage RequestBudget
+	h := withBudget(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b := budgetFromContext(r.Context())
+		if b == nil {
+			t.Fatal("Expected a budget in the request context")
+		}
+		budgetSink = bytes.Repeat([]byte("x"), 1<<20)
+		usage = b.snapshot()
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if usage.AllocBytes < 1<<20 || usage.PeakHeapBytes == 0 {
+		t.Fatalf("Expected the allocation to be measured, got %+v", usage)
+	}
+}
+
+func TestWithBudget_WarnsNearLimit(t *testing.T) {
+	withEnv(t, "MEMORY_LIMIT_MB", "1")
+	silenceLogs(t)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+
+	withBudget(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if !strings.Contains(logs.String(), "memory limit") {
+		t.Fatalf("Expected a memory warning, got %q", logs.String())
+	}
+}
+
+func TestDebugBudget(t *testing.T) {
+	silenceLogs(t)
+	resetRateLimiter()
+	zipData := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "a"}]}]`,
+		"connections/followers_and_following/following.json":   `{"relationships_following": [{"title": "a"}, {"title": "b"}]}`,
+	})
+
+	analyze := func(query string) APIResponse {
+		rec := httptest.NewRecorder()
+		AnalyzeFollowers(rec, httptest.NewRequest(http.MethodPost, "/"+query, bytes.NewReader(zipData)))
+		var response APIResponse
+		json.NewDecoder(rec.Body).Decode(&response)
+		return response
+	}
+
+	if response := analyze("?debug=true"); response.Budget != nil {
+		t.Fatal("Expected no budget unless DEBUG_RESPONSES is enabled")
+	}
+
+	withEnv(t, "DEBUG_RESPONSES", "true")
+	if response := analyze(""); response.Budget != nil {
+		t.Fatal("Expected no budget without debug=true")
+	}
+	response := analyze("?debug=true")
+	if response.Budget == nil || response.Budget.AllocBytes == 0 {
+		t.Fatalf("Expected a budget in the debug response, got %+v", response.Budget)
+	}
+}
@@ -0,0 +1,155 @@
+package followercount
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CaptchaVerifier checks the CAPTCHA token a browser client solved before
+// uploading. Implementations return an error wrapping ErrCaptchaFailed when
+// the token is invalid or scored too low, and ErrCaptchaUnavailable when it
+// could not be checked.
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// captchaTokenHeader carries the CAPTCHA token of an upload.
+const captchaTokenHeader = "X-Captcha-Token"
+
+// Verification endpoints of the supported providers, which share the
+// siteverify protocol.
+const (
+	turnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+	recaptchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+)
+
+// defaultCaptchaMinScore is the lowest reCAPTCHA v3 score accepted unless
+// CAPTCHA_MIN_SCORE says otherwise; Google recommends 0.5 to start with.
+const defaultCaptchaMinScore = 0.5
+
+// siteVerifier verifies tokens against a siteverify endpoint, as used by
+// Cloudflare Turnstile and reCAPTCHA.
+type siteVerifier struct {
+	endpoint string
+	secret   string
+	// minScore is the lowest score accepted from providers that score
+	// requests (reCAPTCHA v3). Turnstile sends no score.
+	minScore float64
+	// action, when set, must match the action the token was issued for.
+	action string
+	client *http.Client
+}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"`
+	Action     string   `json:"action"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func (v *siteVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCaptchaUnavailable, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := v.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCaptchaUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: siteverify returned %d", ErrCaptchaUnavailable, resp.StatusCode)
+	}
+
+	var result siteVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("%w: %w", ErrCaptchaUnavailable, err)
+	}
+	switch {
+	case !result.Success:
+		return fmt.Errorf("%w: %v", ErrCaptchaFailed, result.ErrorCodes)
+	case result.Score != nil && *result.Score < v.minScore:
+		return fmt.Errorf("%w: score %.2f below %.2f", ErrCaptchaFailed, *result.Score, v.minScore)
+	case v.action != "" && result.Action != v.action:
+		return fmt.Errorf("%w: action %q, expected %q", ErrCaptchaFailed, result.Action, v.action)
+	}
+	return nil
+}
+
+// captchaVerifierFromEnv builds the verifier selected by CAPTCHA_PROVIDER:
+// turnstile, recaptcha or empty (or "none") to disable the check.
+func captchaVerifierFromEnv() (CaptchaVerifier, error) {
+	var endpoint string
+	switch provider := strings.ToLower(strings.TrimSpace(getEnv("CAPTCHA_PROVIDER"))); provider {
+	case "", "none":
+		return nil, nil
+	case "turnstile":
+		endpoint = turnstileVerifyURL
+	case "recaptcha":
+		endpoint = recaptchaVerifyURL
+	default:
+		return nil, fmt.Errorf("unknown CAPTCHA_PROVIDER %q", provider)
+	}
+
+	secret := getSecret("CAPTCHA_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("CAPTCHA_SECRET is not set")
+	}
+	minScore := defaultCaptchaMinScore
+	if v, err := strconv.ParseFloat(getEnv("CAPTCHA_MIN_SCORE"), 64); err == nil && v >= 0 && v <= 1 {
+		minScore = v
+	}
+	return &siteVerifier{endpoint: endpoint, secret: secret, minScore: minScore, action: getEnv("CAPTCHA_ACTION")}, nil
+}
+
+// withCaptcha rejects uploads without a valid CAPTCHA token when a provider
+// is configured, to stop scripted abuse that rotates IP addresses.
+// Authenticated callers and clients exempt from rate limiting are trusted
+// and skip the check, so it must run after withAuth. Guests aren't: anyone
+// can get a guest token by claiming a partner's origin. A misconfigured
+// deployment fails closed.
+func withCaptcha(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifier, err := captchaVerifierFromEnv()
+		if err != nil {
+			log.Printf("CAPTCHA misconfigured: %v", err)
+			sendDomainError(w, ErrCaptchaUnavailable)
+			return
+		}
+		if principal := principalFromContext(r.Context()); verifier == nil || principal != nil && !principal.guest || isRateLimitExempt(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := strings.TrimSpace(r.Header.Get(captchaTokenHeader))
+		if token == "" {
+			sendDomainError(w, fmt.Errorf("%w: missing %s header", ErrCaptchaFailed, captchaTokenHeader))
+			return
+		}
+		if err := verifier.Verify(r.Context(), token, getClientIP(r)); err != nil {
+			log.Printf("CAPTCHA verification failed: %v", err)
+			sendDomainError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
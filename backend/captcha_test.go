@@ -0,0 +1,135 @@
+package followercount
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSiteVerifier(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("secret") != "s3cret" {
+			json.NewEncoder(w).Encode(map[string]any{"success": false, "error-codes": []string{"invalid-input-secret"}})
+			return
+		}
+		switch r.PostForm.Get("response") {
+		case "human":
+			json.NewEncoder(w).Encode(map[string]any{"success": true, "score": 0.9, "action": "upload"})
+		case "bot":
+			json.NewEncoder(w).Encode(map[string]any{"success": true, "score": 0.1, "action": "upload"})
+		case "unscored":
+			json.NewEncoder(w).Encode(map[string]any{"success": true})
+		case "down":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"success": false, "error-codes": []string{"invalid-input-response"}})
+		}
+	}))
+	defer provider.Close()
+
+	tests := []struct {
+		token  string
+		action string
+		want   error
+	}{
+		{"human", "", nil},
+		{"human", "upload", nil},
+		{"human", "login", ErrCaptchaFailed},
+		{"unscored", "", nil},
+		{"bot", "", ErrCaptchaFailed},
+		{"forged", "", ErrCaptchaFailed},
+		{"down", "", ErrCaptchaUnavailable},
+	}
+	for _, tt := range tests {
+		v := &siteVerifier{endpoint: provider.URL, secret: "s3cret", minScore: 0.5, action: tt.action}
+		err := v.Verify(context.Background(), tt.token, "203.0.113.7")
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Verify(%q, action %q) = %v, want %v", tt.token, tt.action, err, tt.want)
+		}
+	}
+}
+
+func TestCaptchaVerifierFromEnv(t *testing.T) {
+	withEnv(t, "CAPTCHA_PROVIDER", "")
+	if v, err := captchaVerifierFromEnv(); v != nil || err != nil {
+		t.Fatalf("Expected no verifier by default, got %v, %v", v, err)
+	}
+
+	withEnv(t, "CAPTCHA_PROVIDER", "recaptcha")
+	withEnv(t, "CAPTCHA_SECRET", "")
+	if _, err := captchaVerifierFromEnv(); err == nil {
+		t.Fatal("Expected an error without CAPTCHA_SECRET")
+	}
+
+	withEnv(t, "CAPTCHA_SECRET", "s3cret")
+	withEnv(t, "CAPTCHA_MIN_SCORE", "0.7")
+	v, err := captchaVerifierFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sv := v.(*siteVerifier); sv.endpoint != recaptchaVerifyURL || sv.minScore != 0.7 {
+		t.Fatalf("Unexpected verifier %+v", sv)
+	}
+
+	withEnv(t, "CAPTCHA_PROVIDER", "hcaptcha")
+	if _, err := captchaVerifierFromEnv(); err == nil {
+		t.Fatal("Expected an error for an unknown provider")
+	}
+}
+
+func TestWithCaptcha(t *testing.T) {
+	silenceLogs(t)
+	withEnv(t, "AUTH_MODE", "")
+	withEnv(t, "RATE_LIMIT_EXEMPT_KEYS", "")
+	h := withAuth(withCaptcha(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		return w
+	}
+
+	withEnv(t, "CAPTCHA_PROVIDER", "")
+	if w := request(); w.Code != http.StatusOK {
+		t.Fatalf("Expected uploads to pass without a provider, got %d", w.Code)
+	}
+
+	withEnv(t, "CAPTCHA_PROVIDER", "turnstile")
+	withEnv(t, "CAPTCHA_SECRET", "")
+	if w := request(); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 when misconfigured, got %d", w.Code)
+	}
+
+	withEnv(t, "CAPTCHA_SECRET", "s3cret")
+	w := request()
+	var response APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusForbidden || response.ErrorCode != "captcha_failed" {
+		t.Fatalf("Expected 403 captcha_failed without a token, got %d %q", w.Code, response.ErrorCode)
+	}
+
+	withEnv(t, "RATE_LIMIT_EXEMPT_CIDRS", "10.0.0.0/8")
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected a spoofed X-Forwarded-For not to skip the CAPTCHA, got %d", w.Code)
+	}
+
+	withEnv(t, "AUTH_MODE", "jwt")
+	withEnv(t, "AUTH_JWT_SECRET", "secret")
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signJWT(t, "secret", map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected authenticated callers to skip the CAPTCHA, got %d", w.Code)
+	}
+}
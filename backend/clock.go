@@ -0,0 +1,16 @@
+package followercount
+
+import "time"
+
+// Clock tells the time. Code that makes decisions based on the current time,
+// such as the rate limiter and quotas, reads it through clock so tests can
+// move time forward deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+var clock Clock = systemClock{}
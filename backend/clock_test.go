@@ -0,0 +1,44 @@
+package followercount
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// withClock installs a fake clock starting at start for the duration of the
+// test.
+func withClock(t *testing.T, start time.Time) *fakeClock {
+	t.Helper()
+	previous := clock
+	fake := &fakeClock{now: start}
+	clock = fake
+	t.Cleanup(func() { clock = previous })
+	return fake
+}
+
+func TestSystemClock(t *testing.T) {
+	before := time.Now()
+	now := systemClock{}.Now()
+	if now.Before(before) || now.After(time.Now()) {
+		t.Fatalf("Expected the system clock to report the current time, got %v", now)
+	}
+}
@@ -0,0 +1,104 @@
+// Command analyze runs the follower analysis on a local Instagram export,
+// given as a ZIP, a tar archive (optionally gzipped) or an extracted
+// directory, and prints the result as JSON.
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// maxTarSize bounds how much of a tar archive is loaded into memory.
+const maxTarSize = 512 * 1024 * 1024
+
+func main() {
+	password := flag.String("password", "", "password of an encrypted export ZIP")
+	flag.BoolVar(&analysis.DebugLogs, "debug", false, "log how each export file is parsed")
+	flag.BoolVar(&analysis.Default.Mutuals, "mutuals", false, "also list the followed accounts that follow back")
+	flag.BoolVar(&analysis.Default.Fans, "fans", false, "also list the followers that aren't followed back")
+	rulesFile := flag.String("rules", "", "JSON file of file matching rules overriding the defaults")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: analyze [-password pw] [-debug] [-mutuals] [-fans] [-rules rules.json] <export.zip|export.tar.gz|export-dir>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *rulesFile != "" {
+		if err := loadRules(*rulesFile); err != nil {
+			log.Fatalf("loading %s: %v", *rulesFile, err)
+		}
+	}
+
+	result, err := analyzePath(flag.Arg(0), *password)
+	if err != nil {
+		log.Fatalf("analyzing %s: %v", flag.Arg(0), err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		log.Fatalf("writing result: %v", err)
+	}
+}
+
+func loadRules(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	rules, err := analysis.ParseRules(data)
+	if err != nil {
+		return err
+	}
+	return analysis.SetRules(rules)
+}
+
+func analyzePath(name, password string) (*analysis.Result, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var fsys fs.FS
+	switch lower := strings.ToLower(name); {
+	case info.IsDir():
+		fsys = os.DirFS(name)
+	case strings.HasSuffix(lower, ".tar"), strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		if fsys, err = analysis.FromTar(f, maxTarSize); err != nil {
+			return nil, err
+		}
+	default:
+		zipReader, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", analysis.ErrCorruptZip, err)
+		}
+		fsys = zipReader
+		if analysis.IsEncrypted(zipReader) {
+			if password == "" {
+				return nil, analysis.ErrPasswordRequired
+			}
+			if fsys, err = analysis.DecryptZip(zipReader, password); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return analysis.AnalyzeFiles(fsys)
+}
@@ -0,0 +1,26 @@
+// Command genexport writes a synthetic Instagram export ZIP for load tests.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	followercount "github.com/afaafhariri/follower-watch/backend"
+)
+
+func main() {
+	followers := flag.Int("followers", 1000, "number of followers")
+	following := flag.Int("following", 1000, "number of followed accounts")
+	out := flag.String("out", "export.zip", "output file")
+	flag.Parse()
+
+	data, err := followercount.GenerateExport(*followers, *following)
+	if err != nil {
+		log.Fatalf("generating export: %v", err)
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("writing %s: %v", *out, err)
+	}
+	log.Printf("Wrote %s (%d bytes)", *out, len(data))
+}
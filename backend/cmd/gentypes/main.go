@@ -0,0 +1,30 @@
+// Command gentypes writes the TypeScript types and JSON Schema of the API
+// response for the frontend. Run it with go generate after changing any
+// response type.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	followercount "github.com/afaafhariri/follower-watch/backend"
+)
+
+func main() {
+	tsOut := flag.String("ts", "api.ts", "TypeScript output file")
+	schemaOut := flag.String("schema", "api.schema.json", "JSON Schema output file")
+	flag.Parse()
+
+	schema, err := followercount.GenerateJSONSchema()
+	if err != nil {
+		log.Fatalf("generating JSON Schema: %v", err)
+	}
+	if err := os.WriteFile(*tsOut, followercount.GenerateTypeScript(), 0o644); err != nil {
+		log.Fatalf("writing %s: %v", *tsOut, err)
+	}
+	if err := os.WriteFile(*schemaOut, schema, 0o644); err != nil {
+		log.Fatalf("writing %s: %v", *schemaOut, err)
+	}
+	log.Printf("Wrote %s and %s", *tsOut, *schemaOut)
+}
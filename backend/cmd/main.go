@@ -3,7 +3,7 @@ package main
 import (
 	"log"
 
-	_ "github.com/followercount/backend"
+	_ "github.com/afaafhariri/follower-watch/backend"
 
 	"github.com/GoogleCloudPlatform/functions-framework-go/funcframework"
 	"github.com/joho/godotenv"
@@ -0,0 +1,272 @@
+package followercount
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+const crashReportTimeout = 5 * time.Second
+
+// crashEvent is a Sentry event, in the subset of the format this function
+// sends. Events are built from fixed strings, types, code locations and
+// counts only: panic values and error messages may quote usernames or file
+// contents, so they are never included.
+type crashEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Release     string            `json:"release"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Exception   *crashExceptions  `json:"exception,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Request     *crashRequest     `json:"request,omitempty"`
+}
+
+type crashExceptions struct {
+	Values []crashException `json:"values"`
+}
+
+type crashException struct {
+	Type       string           `json:"type"`
+	Value      string           `json:"value,omitempty"`
+	Stacktrace *crashStacktrace `json:"stacktrace,omitempty"`
+}
+
+type crashStacktrace struct {
+	Frames []crashFrame `json:"frames"`
+}
+
+type crashFrame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type crashRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// sentryDSN is a parsed Sentry DSN, https://<key>@<host>/<project>.
+type sentryDSN struct {
+	storeURL  string
+	publicKey string
+}
+
+func parseSentryDSN(dsn string) (*sentryDSN, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	project := path.Base(u.Path)
+	if u.User == nil || u.User.Username() == "" || u.Host == "" || project == "." || project == "/" {
+		return nil, errors.New("expected https://<key>@<host>/<project>")
+	}
+	store := url.URL{Scheme: u.Scheme, Host: u.Host, Path: path.Join(path.Dir(u.Path), "api", project, "store") + "/"}
+	return &sentryDSN{storeURL: store.String(), publicKey: u.User.Username()}, nil
+}
+
+// crashReportingDSN returns the DSN set in SENTRY_DSN, or nil when crash
+// reporting is off, which is the default.
+func crashReportingDSN() *sentryDSN {
+	raw := strings.TrimSpace(getSecret("SENTRY_DSN"))
+	if raw == "" {
+		return nil
+	}
+	dsn, err := parseSentryDSN(raw)
+	if err != nil {
+		log.Printf("Warning: ignoring invalid SENTRY_DSN: %v", err)
+		return nil
+	}
+	return dsn
+}
+
+// beforeSend scrubs an event right before it is sent, as a last line of
+// defence should a caller put export data into it: the query string, which
+// may hold a search or password, and exception values are dropped, and
+// only known tags are kept.
+func beforeSend(event *crashEvent) {
+	if event.Request != nil {
+		if u, err := url.Parse(event.Request.URL); err == nil {
+			u.RawQuery, u.Fragment, u.User = "", "", nil
+			event.Request.URL = u.String()
+		} else {
+			event.Request.URL = ""
+		}
+	}
+	if event.Exception != nil {
+		for i := range event.Exception.Values {
+			event.Exception.Values[i].Value = ""
+		}
+	}
+	for key := range event.Tags {
+		if !crashTags[key] {
+			delete(event.Tags, key)
+		}
+	}
+}
+
+// crashTags are the tags events may carry.
+var crashTags = map[string]bool{
+	"request_id": true, "endpoint": true, "analyzer": true, "error_code": true,
+	"platform": true, "rules_version": true, "locale": true,
+}
+
+// crashStack returns the calling goroutine's stack in Sentry's order, oldest
+// call first, skipping skip frames above the caller. Called while panicking,
+// the stack ends where the panic happened rather than in the recovery. File
+// paths are cut down to the package directory and file name so build paths
+// aren't reported.
+func crashStack(skip int) *crashStacktrace {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []crashFrame
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			// Everything collected so far is the recovery.
+			stack = stack[:0]
+		} else {
+			stack = append(stack, crashFrame{
+				Function: frame.Function,
+				Filename: path.Join(path.Base(path.Dir(frame.File)), path.Base(frame.File)),
+				Lineno:   frame.Line,
+				InApp:    strings.HasPrefix(frame.Function, "github.com/afaafhariri/follower-watch/"),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+		stack[i], stack[j] = stack[j], stack[i]
+	}
+	return &crashStacktrace{Frames: stack}
+}
+
+// newCrashEvent fills in the fields every event has.
+func newCrashEvent(level, message string) *crashEvent {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &crashEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   clock.Now().UTC().Format(time.RFC3339),
+		Level:       level,
+		Platform:    "go",
+		Release:     Version,
+		Environment: getEnv("SENTRY_ENVIRONMENT"),
+		Message:     message,
+		Tags:        map[string]string{"platform": platform(), "rules_version": analysis.RulesVersion()},
+	}
+}
+
+// reportCrash scrubs the event and posts it in the background when crash
+// reporting is enabled. Like telemetry, failures are only logged.
+func reportCrash(event *crashEvent) {
+	dsn := crashReportingDSN()
+	if dsn == nil {
+		return
+	}
+	beforeSend(event)
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), crashReportTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, dsn.storeURL, bytes.NewReader(body))
+		if err != nil {
+			log.Printf("crash report: %v", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=follower-watch/%s, sentry_key=%s", Version, dsn.publicKey))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Printf("crash report: %v", err)
+			return
+		}
+		resp.Body.Close()
+	}()
+}
+
+// reportPanic reports a panic recovered while serving r. It must be called
+// from the deferred recovery so the stack still shows where it happened.
+func reportPanic(r *http.Request, recovered any) {
+	event := newCrashEvent("fatal", "")
+	event.Exception = &crashExceptions{Values: []crashException{{
+		Type:       fmt.Sprintf("panic: %T", recovered),
+		Stacktrace: crashStack(1),
+	}}}
+	event.Tags["request_id"] = requestIDFromContext(r.Context())
+	event.Tags["endpoint"] = path.Base(r.URL.Path)
+	event.Request = &crashRequest{Method: r.Method, URL: r.URL.String()}
+	reportCrash(event)
+}
+
+// reportAnalyzerPanic reports an optional analyzer that panicked; it is
+// installed as analysis.OnPanic.
+func reportAnalyzerPanic(analyzer string, recovered any) {
+	event := newCrashEvent("error", "")
+	event.Exception = &crashExceptions{Values: []crashException{{
+		Type:       fmt.Sprintf("panic: %T", recovered),
+		Stacktrace: crashStack(1),
+	}}}
+	event.Tags["analyzer"] = analyzer
+	reportCrash(event)
+}
+
+// reportUnrecognizedExport reports an export whose follower or following
+// list couldn't be found, which usually means Instagram changed the export
+// format. The parser context is limited to how many files of each kind the
+// current rules recognize and the archive's total file count.
+func reportUnrecognizedExport(ctx context.Context, err error, fsys fs.FS) {
+	if crashReportingDSN() == nil {
+		return
+	}
+	event := newCrashEvent("warning", "Export format not recognized")
+	event.Tags["request_id"] = requestIDFromContext(ctx)
+	event.Tags["error_code"] = lookupError(err).code
+	var diagnosis *analysis.Diagnosis
+	if errors.As(err, &diagnosis) && diagnosis.Locale != "" {
+		event.Tags["locale"] = diagnosis.Locale
+	}
+
+	kinds := make(map[string]int)
+	for _, file := range analysis.BuildManifest(fsys).Files {
+		kinds[file.Kind]++
+	}
+	total := 0
+	fs.WalkDir(fsys, ".", func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			total++
+		}
+		return nil
+	})
+	event.Extra = map[string]any{"recognized_files": kinds, "total_files": total}
+	reportCrash(event)
+}
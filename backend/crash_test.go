@@ -0,0 +1,131 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// withSentry points SENTRY_DSN at a fake Sentry server and returns the
+// events it receives.
+func withSentry(t *testing.T) <-chan crashEvent {
+	t.Helper()
+	events := make(chan crashEvent, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/42/store/" || !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=public") {
+			t.Errorf("Unexpected request %s with auth %q", r.URL.Path, r.Header.Get("X-Sentry-Auth"))
+		}
+		var event crashEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+	}))
+	t.Cleanup(server.Close)
+	withEnv(t, "SENTRY_DSN", strings.Replace(server.URL, "://", "://public@", 1)+"/42")
+	return events
+}
+
+func receiveEvent(t *testing.T, events <-chan crashEvent) crashEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("No crash report received")
+		return crashEvent{}
+	}
+}
+
+func TestParseSentryDSN(t *testing.T) {
+	dsn, err := parseSentryDSN("https://abc123@o1.ingest.sentry.io/4505")
+	if err != nil {
+		t.Fatalf("parseSentryDSN failed: %v", err)
+	}
+	if dsn.storeURL != "https://o1.ingest.sentry.io/api/4505/store/" || dsn.publicKey != "abc123" {
+		t.Fatalf("Unexpected DSN %+v", dsn)
+	}
+	for _, invalid := range []string{"https://o1.ingest.sentry.io/4505", "https://abc@/4505", "https://abc@host"} {
+		if _, err := parseSentryDSN(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestBeforeSend(t *testing.T) {
+	event := &crashEvent{
+		Exception: &crashExceptions{Values: []crashException{{Type: "panic: string", Value: "bad entry for celeb"}}},
+		Tags:      map[string]string{"endpoint": "plan", "username": "celeb"},
+		Request:   &crashRequest{Method: http.MethodPost, URL: "https://fn.example/plan?q=celeb"},
+	}
+	beforeSend(event)
+
+	if event.Exception.Values[0].Value != "" {
+		t.Errorf("Expected the exception value to be dropped, got %q", event.Exception.Values[0].Value)
+	}
+	if _, ok := event.Tags["username"]; ok || event.Tags["endpoint"] != "plan" {
+		t.Errorf("Expected only known tags to be kept, got %v", event.Tags)
+	}
+	if event.Request.URL != "https://fn.example/plan" {
+		t.Errorf("Expected the query string to be dropped, got %q", event.Request.URL)
+	}
+}
+
+func TestWithRecovery_ReportsCrash(t *testing.T) {
+	silenceLogs(t)
+	events := withSentry(t)
+
+	h := withRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("entry for celeb is malformed")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/plan?q=celeb", nil))
+
+	event := receiveEvent(t, events)
+	body, _ := json.Marshal(event)
+	if strings.Contains(string(body), "celeb") {
+		t.Fatalf("Expected the report to be scrubbed, got %s", body)
+	}
+	if event.Level != "fatal" || event.Tags["endpoint"] != "plan" || event.Exception == nil {
+		t.Fatalf("Unexpected event %+v", event)
+	}
+	frames := event.Exception.Values[0].Stacktrace.Frames
+	if len(frames) == 0 || !strings.Contains(frames[len(frames)-1].Function, "TestWithRecovery_ReportsCrash") {
+		t.Fatalf("Expected the stack to end where the panic happened, got %+v", frames)
+	}
+}
+
+func TestReportUnrecognizedExport(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+	events := withSentry(t)
+
+	data := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.json":         `[{"string_list_data": [{"value": "celeb"}]}]`,
+		"connections/followers_and_following/accounts_you_follow.json": `{"relationships_following": [{"title": "celeb"}]}`,
+	})
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 no_following, got %d", w.Code)
+	}
+
+	event := receiveEvent(t, events)
+	if event.Level != "warning" || event.Tags["error_code"] != "no_following" || event.Tags["rules_version"] != analysis.RulesVersion() {
+		t.Fatalf("Unexpected event %+v", event)
+	}
+	if body, _ := json.Marshal(event); strings.Contains(string(body), "celeb") || strings.Contains(string(body), "accounts_you_follow") {
+		t.Fatalf("Expected no usernames or file names in the report, got %s", body)
+	}
+}
+
+func TestReportCrash_Disabled(t *testing.T) {
+	withEnv(t, "SENTRY_DSN", "")
+	if crashReportingDSN() != nil {
+		t.Fatal("Expected crash reporting to be off without SENTRY_DSN")
+	}
+}
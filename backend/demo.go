@@ -0,0 +1,69 @@
+package followercount
+
+import (
+	"log"
+	"net/http"
+	"sync"
+)
+
+// Size of the synthetic account behind the demo endpoint.
+const (
+	demoFollowers = 320
+	demoFollowing = 480
+)
+
+var (
+	demoOnce     sync.Once
+	demoResponse APIResponse
+	demoErr      error
+)
+
+var demoHandler = chain(
+	http.HandlerFunc(serveDemo),
+	withRequestID,
+	withRecovery,
+	withLogging,
+	withSignature,
+	withKeyCase,
+	withCORS,
+	withMethod(http.MethodGet),
+)
+
+// buildDemoResponse runs the real analysis on a generated export, so the
+// demo always has the same shape as a live result.
+func buildDemoResponse() (APIResponse, error) {
+	data, err := GenerateExport(demoFollowers, demoFollowing)
+	if err != nil {
+		return APIResponse{}, err
+	}
+	zipReader, err := openZip(data)
+	if err != nil {
+		return APIResponse{}, err
+	}
+	// Analyzed without the plugin hooks, which are for real uploads, and
+	// with the opt-in lists enabled so every section is filled.
+	analyzer := newAnalyzer()
+	analyzer.Mutuals = true
+	analyzer.Fans = true
+	result, err := analyzer.AnalyzeFiles(zipReader)
+	if err != nil {
+		return APIResponse{}, err
+	}
+	response := newAPIResponse(result)
+	response.Message = "Demo analysis of a synthetic account"
+	return response, nil
+}
+
+// serveDemo returns a complete synthetic analysis so frontends and docs can
+// render every section without a real export.
+func serveDemo(w http.ResponseWriter, r *http.Request) {
+	demoOnce.Do(func() {
+		demoResponse, demoErr = buildDemoResponse()
+	})
+	if demoErr != nil {
+		log.Printf("Error building demo response: %v", demoErr)
+		sendDomainError(w, ErrDemoUnavailable)
+		return
+	}
+	sendJSON(w, http.StatusOK, demoResponse)
+}
@@ -0,0 +1,36 @@
+package followercount
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnalyzeFollowers_Demo(t *testing.T) {
+	silenceLogs(t)
+
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodGet, "/api/demo", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var apiResponse APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&apiResponse); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if !apiResponse.Success || apiResponse.TotalFollowers != demoFollowers || apiResponse.TotalFollowing != demoFollowing {
+		t.Fatalf("Unexpected demo response: %+v", apiResponse)
+	}
+	if apiResponse.Count == 0 || apiResponse.Stats == nil || apiResponse.Quality == nil {
+		t.Fatal("Expected the demo to include non-followers, stats and quality sections")
+	}
+	if len(apiResponse.Mutuals) == 0 || len(apiResponse.Fans) == 0 {
+		t.Fatal("Expected the demo to include mutuals and fans")
+	}
+	if len(apiResponse.StrongestConnections) == 0 || len(apiResponse.WeakestConnections) == 0 {
+		t.Fatal("Expected the demo to include the strongest and weakest connections")
+	}
+}
@@ -0,0 +1,80 @@
+package followercount
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// bareSections are the response sections that can be requested as a bare
+// JSON array with envelope=false, keyed by their section name. Each returns
+// the section and its length.
+var bareSections = map[string]func(APIResponse) (any, int){
+	"non_followers":         func(r APIResponse) (any, int) { return r.NonFollowers, len(r.NonFollowers) },
+	"insights":              func(r APIResponse) (any, int) { return r.Insights, len(r.Insights) },
+	"milestones":            func(r APIResponse) (any, int) { return r.Milestones, len(r.Milestones) },
+	"warnings":              func(r APIResponse) (any, int) { return r.Warnings, len(r.Warnings) },
+	"other_relationships":   func(r APIResponse) (any, int) { return r.OtherRelationships, len(r.OtherRelationships) },
+	"pending_requests":      func(r APIResponse) (any, int) { return r.PendingRequests, len(r.PendingRequests) },
+	"possible_duplicates":   func(r APIResponse) (any, int) { return r.PossibleDuplicates, len(r.PossibleDuplicates) },
+	"mutuals":               func(r APIResponse) (any, int) { return r.Mutuals, len(r.Mutuals) },
+	"fans":                  func(r APIResponse) (any, int) { return r.Fans, len(r.Fans) },
+	"strongest_connections": func(r APIResponse) (any, int) { return r.StrongestConnections, len(r.StrongestConnections) },
+	"weakest_connections":   func(r APIResponse) (any, int) { return r.WeakestConnections, len(r.WeakestConnections) },
+}
+
+// Headers carrying the response metadata when the envelope is dropped.
+const (
+	totalCountHeader     = "X-Total-Count"
+	totalFollowingHeader = "X-Total-Following"
+	totalFollowersHeader = "X-Total-Followers"
+	partialHeader        = "X-Partial"
+	dataAsOfHeader       = "X-Data-As-Of"
+)
+
+// bareSection validates the envelope and section query parameters. It
+// returns the section to send as a bare array, or an empty string to keep
+// the usual envelope.
+func bareSection(r *http.Request) (string, error) {
+	query := r.URL.Query()
+	switch query.Get("envelope") {
+	case "", "true":
+		if query.Get("section") != "" {
+			return "", withDetail(ErrInvalidOption, "section requires envelope=false")
+		}
+		return "", nil
+	case "false":
+	default:
+		return "", withDetail(ErrInvalidOption, "envelope must be true or false")
+	}
+
+	section := query.Get("section")
+	if section == "" {
+		section = "non_followers"
+	}
+	if _, ok := bareSections[section]; !ok {
+		return "", withDetail(ErrInvalidOption, "unknown section %q", section)
+	}
+	return section, nil
+}
+
+// sendBare writes one section of response as a bare JSON array, with the
+// counts and flags that would have been in the envelope moved to headers.
+// X-Total-Count is total, the length of the section before preview=true
+// trimmed it.
+func sendBare(w http.ResponseWriter, response APIResponse, section string, total int) {
+	items, count := bareSections[section](response)
+
+	h := w.Header()
+	h.Set(totalCountHeader, strconv.Itoa(total))
+	h.Set(totalFollowingHeader, strconv.Itoa(response.TotalFollowing))
+	h.Set(totalFollowersHeader, strconv.Itoa(response.TotalFollowers))
+	h.Set(partialHeader, strconv.FormatBool(response.Partial))
+	if response.DataAsOf != "" {
+		h.Set(dataAsOfHeader, response.DataAsOf)
+	}
+	if count == 0 {
+		// Send [] rather than null for an empty section.
+		items = []any{}
+	}
+	sendJSON(w, http.StatusOK, items)
+}
@@ -0,0 +1,76 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+func TestBareSection(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"?envelope=true", "", false},
+		{"?envelope=false", "non_followers", false},
+		{"?envelope=false&section=insights", "insights", false},
+		{"?envelope=false&section=stats", "", true},
+		{"?envelope=maybe", "", true},
+		{"?section=insights", "", true},
+	}
+	for _, tt := range tests {
+		got, err := bareSection(httptest.NewRequest(http.MethodPost, "/"+tt.query, nil))
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("bareSection(%q) = %q, %v; want %q (error %v)", tt.query, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAnalyzeFollowers_NoEnvelope(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	zipBytes := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "a"}]}]`,
+		"connections/followers_and_following/following.json":   `{"relationships_following": [{"title": "a"}, {"title": "b"}, {"title": "c"}]}`,
+	})
+	analyze := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/"+query, bytes.NewReader(zipBytes)))
+		return w
+	}
+
+	w := analyze("?envelope=false")
+	var nonFollowers []analysis.NonFollower
+	if err := json.NewDecoder(w.Body).Decode(&nonFollowers); err != nil {
+		t.Fatalf("Expected a bare array: %v", err)
+	}
+	if len(nonFollowers) != 2 {
+		t.Fatalf("Expected 2 non-followers, got %+v", nonFollowers)
+	}
+	if got := w.Header().Get(totalCountHeader); got != "2" {
+		t.Errorf("Expected X-Total-Count 2, got %q", got)
+	}
+	if got := w.Header().Get(totalFollowingHeader); got != "3" {
+		t.Errorf("Expected X-Total-Following 3, got %q", got)
+	}
+	if got := w.Header().Get(totalFollowersHeader); got != "1" {
+		t.Errorf("Expected X-Total-Followers 1, got %q", got)
+	}
+
+	w = analyze("?envelope=false&section=pending_requests")
+	if body := bytes.TrimSpace(w.Body.Bytes()); string(body) != "[]" || w.Header().Get(totalCountHeader) != "0" {
+		t.Fatalf("Expected an empty array for an empty section, got %s", body)
+	}
+
+	if w := analyze("?envelope=false&section=stats"); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an unknown section, got %d", w.Code)
+	}
+}
@@ -0,0 +1,178 @@
+package followercount
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// Errors returned while reading and analyzing an upload. Handlers map them to
+// HTTP responses with sendDomainError; callers can match them with errors.Is.
+var (
+	ErrUploadTooLarge     = errors.New("upload too large")
+	ErrReadBody           = errors.New("failed to read upload")
+	ErrChecksumMismatch   = errors.New("upload checksum mismatch")
+	ErrUploadRejected     = errors.New("upload rejected by scanner")
+	ErrScanFailed         = errors.New("upload could not be scanned")
+	ErrNotZip             = errors.New("upload is not a ZIP file")
+	ErrCorruptZip         = analysis.ErrCorruptZip
+	ErrInvalidBundle      = errors.New("invalid slim bundle")
+	ErrNoFollowing        = analysis.ErrNoFollowing
+	ErrNoFollowers        = analysis.ErrNoFollowers
+	ErrUnauthenticated    = errors.New("request not authenticated")
+	ErrAuthUnavailable    = errors.New("authentication unavailable")
+	ErrForbidden          = errors.New("request not permitted")
+	ErrPasswordRequired   = analysis.ErrPasswordRequired
+	ErrWrongPassword      = analysis.ErrWrongPassword
+	ErrCaptchaFailed      = errors.New("CAPTCHA verification failed")
+	ErrCaptchaUnavailable = errors.New("CAPTCHA verification unavailable")
+	ErrOriginNotAllowed   = errors.New("origin is not an embedding partner")
+	ErrGuestTokenUsedUp   = errors.New("guest token used up")
+	ErrInvalidOption      = errors.New("invalid request option")
+	ErrInvalidForm        = errors.New("invalid multipart form")
+	ErrMethodNotAllowed   = errors.New("method not allowed")
+	ErrRateLimited        = errors.New("rate limit exceeded")
+	ErrQuotaExceeded      = errors.New("origin quota used up")
+	ErrNotEnabled         = errors.New("feature not enabled")
+	ErrDemoUnavailable    = errors.New("demo data unavailable")
+)
+
+// detailedError carries a catalogued error with a message for the client
+// that is more specific than the catalogue's, such as which option was
+// invalid and what it accepts.
+type detailedError struct {
+	err     error
+	message string
+}
+
+func (e *detailedError) Error() string { return e.err.Error() + ": " + e.message }
+
+func (e *detailedError) Unwrap() error { return e.err }
+
+// withDetail wraps err, a catalogued error, so that its response carries the
+// formatted message in place of the catalogue's.
+func withDetail(err error, format string, args ...any) error {
+	return &detailedError{err: err, message: fmt.Sprintf(format, args...)}
+}
+
+// errorInfo describes how a domain error is reported to the client. Code is
+// a stable, machine-readable identifier sent as error_code.
+type errorInfo struct {
+	err     error
+	code    string
+	status  int
+	message string
+}
+
+// internalError is reported for errors that aren't part of the catalogue.
+var internalError = errorInfo{code: "internal_error", status: http.StatusInternalServerError, message: "Failed to process upload"}
+
+// errorResponses maps each domain error to the code, status code and message
+// sent to the client. It is also served as the /errors catalogue.
+var errorResponses = []errorInfo{
+	{ErrUploadTooLarge, "upload_too_large", http.StatusRequestEntityTooLarge, "File too large. Maximum size is 50MB."},
+	{ErrReadBody, "read_body_failed", http.StatusBadRequest, "Failed to read request body"},
+	{ErrChecksumMismatch, "checksum_mismatch", http.StatusBadRequest, "Upload checksum mismatch. The file may have been corrupted in transit; please upload it again."},
+	{ErrUploadRejected, "upload_rejected", http.StatusUnprocessableEntity, "The uploaded file was rejected by a security check."},
+	{ErrScanFailed, "scan_failed", http.StatusServiceUnavailable, "The upload could not be checked right now. Please try again later."},
+	{ErrNotZip, "not_zip", http.StatusBadRequest, "Invalid file format. Please upload a valid ZIP file."},
+	{ErrCorruptZip, "corrupt_zip", http.StatusBadRequest, "Failed to read ZIP file. Please ensure it's a valid ZIP archive."},
+	{ErrPasswordRequired, "password_required", http.StatusBadRequest, "This ZIP file is password-protected. Please provide its password."},
+	{ErrWrongPassword, "wrong_password", http.StatusBadRequest, "The ZIP password is incorrect."},
+	{ErrInvalidBundle, "invalid_bundle", http.StatusBadRequest, "Invalid slim bundle. Expected a JSON object with a 'files' map."},
+	{ErrNoFollowing, "no_following", http.StatusBadRequest, "No following data found. Please upload a valid Instagram data export."},
+	{ErrNoFollowers, "no_followers", http.StatusBadRequest, "No followers data found. Please upload a valid Instagram data export."},
+	{ErrUnauthenticated, "unauthenticated", http.StatusUnauthorized, "Authentication required."},
+	{ErrForbidden, "forbidden", http.StatusForbidden, "Your role does not allow this action."},
+	{ErrAuthUnavailable, "auth_unavailable", http.StatusServiceUnavailable, "Authentication is temporarily unavailable. Please try again later."},
+	{ErrCaptchaFailed, "captcha_failed", http.StatusForbidden, "CAPTCHA verification failed. Please complete the challenge and try again."},
+	{ErrCaptchaUnavailable, "captcha_unavailable", http.StatusServiceUnavailable, "CAPTCHA verification is temporarily unavailable. Please try again later."},
+	{ErrOriginNotAllowed, "origin_not_allowed", http.StatusForbidden, "This site is not allowed to embed the analyzer."},
+	{ErrGuestTokenUsedUp, "guest_token_used_up", http.StatusTooManyRequests, "This session has reached its upload limit. Please reload the page to start a new one."},
+	{ErrInvalidOption, "invalid_option", http.StatusBadRequest, "Invalid request option."},
+	{ErrInvalidForm, "invalid_form", http.StatusBadRequest, "Expected a multipart form with 'primary' and 'secondary' ZIP files."},
+	{ErrMethodNotAllowed, "method_not_allowed", http.StatusMethodNotAllowed, "Method not allowed"},
+	{ErrRateLimited, "rate_limited", http.StatusTooManyRequests, "Rate limit exceeded. Please try again later."},
+	{ErrQuotaExceeded, "origin_quota_exceeded", http.StatusTooManyRequests, "Daily processing quota for this site has been used up. Please try again tomorrow."},
+	{ErrNotEnabled, "not_enabled", http.StatusNotFound, "This feature is not enabled."},
+	{ErrDemoUnavailable, "demo_unavailable", http.StatusInternalServerError, "Demo data is unavailable"},
+}
+
+// lookupError returns the catalogue entry for err, falling back to a generic
+// 500 for errors that aren't part of the catalogue.
+func lookupError(err error) errorInfo {
+	for _, e := range errorResponses {
+		if errors.Is(err, e.err) {
+			return e
+		}
+	}
+
+	log.Printf("Error processing upload: %v", err)
+	return internalError
+}
+
+// errorResponse builds the unsuccessful response for err, including any
+// message attached with withDetail and localized hint attached by
+// diagnoseExport.
+func errorResponse(err error) (int, APIResponse) {
+	e := lookupError(err)
+	response := APIResponse{Success: false, Error: e.message, ErrorCode: e.code}
+
+	var detailed *detailedError
+	if errors.As(err, &detailed) {
+		response.Error = detailed.message
+	}
+	var diagnosis *analysis.Diagnosis
+	if errors.As(err, &diagnosis) {
+		response.Hint = diagnosis.Hint
+		response.Locale = diagnosis.Locale
+	}
+	return e.status, response
+}
+
+// sendDomainError writes the response registered for err.
+func sendDomainError(w http.ResponseWriter, err error) {
+	status, response := errorResponse(err)
+	sendJSON(w, status, response)
+}
+
+// CatalogueEntry is one error in the /errors catalogue.
+type CatalogueEntry struct {
+	Code    string `json:"error_code"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// ErrorCatalogue lists every error the API can return, plus the localized
+// hints that may accompany no_following and no_followers.
+type ErrorCatalogue struct {
+	Errors []CatalogueEntry             `json:"errors"`
+	Hints  map[string]map[string]string `json:"hints"`
+}
+
+var errorsHandler = chain(
+	http.HandlerFunc(serveErrorCatalogue),
+	withRequestID,
+	withRecovery,
+	withLogging,
+	withKeyCase,
+	withCORS,
+	withMethod(http.MethodGet),
+)
+
+// buildErrorCatalogue renders errorResponses, the table the handlers report
+// errors from, so the published catalogue can't drift from them.
+func buildErrorCatalogue() ErrorCatalogue {
+	catalogue := ErrorCatalogue{Hints: analysis.LocalizedHints}
+	for _, e := range append(errorResponses, internalError) {
+		catalogue.Errors = append(catalogue.Errors, CatalogueEntry{Code: e.code, Status: e.status, Message: e.message})
+	}
+	return catalogue
+}
+
+func serveErrorCatalogue(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, http.StatusOK, buildErrorCatalogue())
+}
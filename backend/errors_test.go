@@ -0,0 +1,266 @@
+package followercount
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+func TestReadUpload_TooLarge(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(append([]byte("PK\x03\x04"), make([]byte, 16)...)))
+	req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, 8)
+
+	_, err := readUpload(req)
+	if !errors.Is(err, ErrUploadTooLarge) {
+		t.Fatalf("Expected ErrUploadTooLarge, got %v", err)
+	}
+}
+
+func TestReadUpload_RejectsNonZipEarly(t *testing.T) {
+	// A reader that fails after the first bytes shows the body was not
+	// buffered in full before rejecting it.
+	body := io.MultiReader(strings.NewReader("GIF89a"), iotest.ErrReader(errors.New("read too far")))
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+
+	if _, err := readUpload(req); !errors.Is(err, ErrNotZip) {
+		t.Fatalf("Expected ErrNotZip, got %v", err)
+	}
+}
+
+func TestReadUpload_Checksum(t *testing.T) {
+	body := []byte("PK\x03\x04 not really a zip")
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(checksumHeader, "0000000000000000000000000000000000000000000000000000000000000000")
+	if _, err := readUpload(req); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Expected ErrChecksumMismatch, got %v", err)
+	}
+
+	sum := sha256.Sum256(body)
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(checksumHeader, strings.ToUpper(hex.EncodeToString(sum[:])))
+	if _, err := readUpload(req); errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Expected a matching checksum to pass, got %v", err)
+	}
+}
+
+func TestSendDomainError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{fmt.Errorf("%w: http: request body too large", ErrUploadTooLarge), http.StatusRequestEntityTooLarge},
+		{ErrNotZip, http.StatusBadRequest},
+		{ErrNoFollowers, http.StatusBadRequest},
+		{errors.New("unexpected"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		sendDomainError(w, tt.err)
+		if w.Code != tt.status {
+			t.Errorf("%v: expected status %d, got %d", tt.err, tt.status, w.Code)
+		}
+	}
+}
+
+func TestErrorCatalogue_CodesAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, e := range buildErrorCatalogue().Errors {
+		if e.Code == "" || e.Status == 0 || e.Message == "" {
+			t.Errorf("Incomplete catalogue entry %+v", e)
+		}
+		if seen[e.Code] {
+			t.Errorf("Duplicate error code %q", e.Code)
+		}
+		seen[e.Code] = true
+	}
+}
+
+func TestServeErrorCatalogue(t *testing.T) {
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodGet, "/errors", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var catalogue ErrorCatalogue
+	if err := json.NewDecoder(w.Body).Decode(&catalogue); err != nil {
+		t.Fatalf("Failed to parse catalogue: %v", err)
+	}
+	if len(catalogue.Errors) != len(errorResponses)+1 {
+		t.Fatalf("Expected %d entries, got %d", len(errorResponses)+1, len(catalogue.Errors))
+	}
+	if catalogue.Hints["en"][analysis.HintHTMLFormat] == "" {
+		t.Fatal("Expected the localized hints in the catalogue")
+	}
+}
+
+func TestSendDomainError_IncludesCode(t *testing.T) {
+	silenceLogs(t)
+
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{fmt.Errorf("%w: truncated", ErrCorruptZip), "corrupt_zip"},
+		{errors.New("something unexpected"), "internal_error"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		sendDomainError(w, tt.err)
+
+		var response APIResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.ErrorCode != tt.code {
+			t.Errorf("%v: expected error_code %q, got %q", tt.err, tt.code, response.ErrorCode)
+		}
+	}
+}
+
+func TestAnalyzeFollowers_LocalizedHint(t *testing.T) {
+	silenceLogs(t)
+	resetRateLimiter()
+	defer resetRateLimiter()
+
+	data := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.html": `<html lang="fr"><body>user1</body></html>`,
+		"connections/followers_and_following/following.html":   `<html lang="fr"><body>user2</body></html>`,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	var response APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Locale != "fr" || response.Hint != analysis.LocalizedHints["fr"][analysis.HintHTMLFormat] {
+		t.Fatalf("Expected the French HTML-format hint, got locale %q hint %q", response.Locale, response.Hint)
+	}
+}
+
+// TestErrorPaths_IncludeCode sends a request down each error path outside
+// the analysis itself and checks that its response carries the expected
+// catalogued error_code.
+func TestErrorPaths_IncludeCode(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+	withEnv(t, "GUEST_TOKEN_SECRET", "")
+	withEnv(t, "RESPONSE_SIGNING_KEY", "")
+	withEnv(t, "ORIGIN_QUOTAS", "https://quota.example=1:0")
+	withLimiter(t, newMemoryLimiter())
+
+	catalogued := make(map[string]int)
+	for _, e := range buildErrorCatalogue().Errors {
+		catalogued[e.Code] = e.Status
+	}
+	check := func(name string, w *httptest.ResponseRecorder, code string) {
+		t.Helper()
+		var response APIResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("%s: failed to parse response: %v", name, err)
+		}
+		if response.ErrorCode != code || catalogued[code] != w.Code || response.Error == "" {
+			t.Errorf("%s: expected %d %q, got %d %q %q", name, catalogued[code], code, w.Code, response.ErrorCode, response.Error)
+		}
+	}
+
+	post := func(target string) *http.Request {
+		return httptest.NewRequest(http.MethodPost, target, nil)
+	}
+	export, err := GenerateExport(2, 4)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+	onlyPrimary := new(bytes.Buffer)
+	mw := multipart.NewWriter(onlyPrimary)
+	part, _ := mw.CreateFormFile("primary", "primary.zip")
+	part.Write(export)
+	mw.Close()
+	missingSecondary := post("/overlap")
+	missingSecondary.Body = io.NopCloser(onlyPrimary)
+	missingSecondary.Header.Set("Content-Type", mw.FormDataContentType())
+	noFollowers := createTestZip(t, map[string]string{"README.txt": "nothing here"})
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		code string
+	}{
+		{"method", httptest.NewRequest(http.MethodGet, "/", nil), "method_not_allowed"},
+		{"format", post("/?format=xml"), "invalid_option"},
+		{"section", post("/?section=nope&envelope=false"), "invalid_option"},
+		{"preview", post("/?preview=maybe"), "invalid_option"},
+		{"platforms", post("/?platforms=myspace"), "invalid_option"},
+		{"mutuals", post("/?mutuals=maybe"), "invalid_option"},
+		{"fans", post("/?fans=maybe"), "invalid_option"},
+		{"pending_days", post("/?pending_days=0"), "invalid_option"},
+		{"connections", post("/?connections=0"), "invalid_option"},
+		{"analyzers", post("/?analyzers=nope"), "invalid_option"},
+		{"q", post("/?q=" + strings.Repeat("a", maxQueryLength+1)), "invalid_option"},
+		{"group_by", post("/?group_by=length"), "invalid_option"},
+		{"per_day", post("/plan?per_day=0"), "invalid_option"},
+		{"start", post("/plan?start=tomorrow"), "invalid_option"},
+		{"plan format", post("/plan?format=pdf"), "invalid_option"},
+		{"overlap form", post("/overlap"), "invalid_form"},
+		{"overlap missing export", missingSecondary, "invalid_form"},
+		{"overlap not zip", overlapRequest(t, []byte("not a zip"), []byte("not a zip")), "not_zip"},
+		{"overlap no followers", overlapRequest(t, noFollowers, noFollowers), "no_followers"},
+		{"guest token", post("/guest-token"), "not_enabled"},
+		{"signing key", httptest.NewRequest(http.MethodGet, "/signing-key", nil), "not_enabled"},
+	}
+	for _, tt := range tests {
+		resetRateLimiter()
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, tt.req)
+		check(tt.name, w, tt.code)
+	}
+
+	resetRateLimiter()
+	var w *httptest.ResponseRecorder
+	for i := 0; i <= maxRequests; i++ {
+		w = httptest.NewRecorder()
+		AnalyzeFollowers(w, post("/?format=xml"))
+	}
+	check("rate limit", w, "rate_limited")
+
+	resetRateLimiter()
+	for i := 0; i < 2; i++ {
+		req := post("/?format=xml")
+		req.Header.Set("Origin", "https://quota.example")
+		w = httptest.NewRecorder()
+		AnalyzeFollowers(w, req)
+	}
+	check("origin quota", w, "origin_quota_exceeded")
+
+	demoOnce = sync.Once{}
+	demoOnce.Do(func() { demoErr = errors.New("broken") })
+	t.Cleanup(func() {
+		demoOnce = sync.Once{}
+		demoResponse, demoErr = APIResponse{}, nil
+	})
+	w = httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodGet, "/demo", nil))
+	check("demo", w, "demo_unavailable")
+}
@@ -0,0 +1,52 @@
+package followercount
+
+import (
+	"encoding/csv"
+	"net/http"
+	"time"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// csvFlushEvery is how many rows are written between flushes, so large
+// exports reach the client in chunks instead of being buffered whole.
+const csvFlushEvery = 1000
+
+var csvHeader = []string{"username", "display_name", "profile_url", "followed_at", "source"}
+
+// csvSafe neutralizes values a spreadsheet would otherwise evaluate as a
+// formula. Every text column comes from the export, directly or through
+// the names of its files, so none of them is trusted.
+func csvSafe(s string) string {
+	if s != "" && (s[0] == '=' || s[0] == '+' || s[0] == '-' || s[0] == '@' || s[0] == '\t' || s[0] == '\r') {
+		return "'" + s
+	}
+	return s
+}
+
+// writeNonFollowersCSV streams the non-followers as CSV using chunked
+// transfer encoding, flushing every csvFlushEvery rows.
+func writeNonFollowersCSV(w http.ResponseWriter, nonFollowers []analysis.NonFollower) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="non-followers.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for i, nf := range nonFollowers {
+		followedAt := ""
+		if nf.FollowedAt != 0 {
+			followedAt = time.Unix(nf.FollowedAt, 0).UTC().Format(time.RFC3339)
+		}
+		cw.Write([]string{csvSafe(nf.Username), csvSafe(nf.DisplayName), csvSafe(nf.ProfileURL), followedAt, csvSafe(nf.Source)})
+
+		if (i+1)%csvFlushEvery == 0 {
+			cw.Flush()
+			// Writers that can't flush (e.g. a buffering middleware) still
+			// get the whole body once the handler returns.
+			rc.Flush()
+		}
+	}
+	cw.Flush()
+}
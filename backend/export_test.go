@@ -0,0 +1,94 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+func TestAnalyzeFollowers_CSV(t *testing.T) {
+	silenceLogs(t)
+	resetRateLimiter()
+	defer resetRateLimiter()
+
+	data, err := GenerateExport(10, 2500)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/?format=csv", bytes.NewReader(data))
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Fatalf("Expected a CSV content type, got %q", ct)
+	}
+	if !w.Flushed {
+		t.Error("Expected a large export to be flushed while streaming")
+	}
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(rows) < csvFlushEvery+1 || rows[0][0] != "username" {
+		t.Fatalf("Expected a header and over %d rows, got %d rows", csvFlushEvery, len(rows))
+	}
+	if len(rows[1]) != len(csvHeader) {
+		t.Fatalf("Expected %d columns, got %d", len(csvHeader), len(rows[1]))
+	}
+}
+
+func TestAnalyzeFollowers_UnknownFormat(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+
+	req := httptest.NewRequest(http.MethodPost, "/?format=xlsx", bytes.NewReader([]byte("PK")))
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestWriteNonFollowersCSV_Escapes(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeNonFollowersCSV(w, []analysis.NonFollower{{
+		Username:    "=cmd|' /C calc'!A0",
+		DisplayName: "+1 555",
+		ProfileURL:  "@SUM(1+1)",
+		Source:      "-2+3/following.json",
+	}})
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	for i, value := range rows[1] {
+		if value != "" && value[0] != '\'' {
+			t.Errorf("Expected column %s to be escaped, got %q", csvHeader[i], value)
+		}
+	}
+}
+
+func TestCSVSafe(t *testing.T) {
+	tests := map[string]string{
+		"Jane Doe":          "Jane Doe",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1 555":            "'+1 555",
+		"@brand":            "'@brand",
+		"":                  "",
+	}
+	for in, want := range tests {
+		if got := csvSafe(in); got != want {
+			t.Errorf("csvSafe(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package followercount
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type syntheticStringData struct {
+	Href      string `json:"href"`
+	Value     string `json:"value,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type syntheticEntry struct {
+	Title          string                `json:"title"`
+	StringListData []syntheticStringData `json:"string_list_data"`
+}
+
+// GenerateExport builds a synthetic Instagram export ZIP with the given
+// number of followers and followed accounts, laid out like a real JSON
+// export. Every other followed account follows back, so half of following
+// (capped by followers) are mutuals. The output is deterministic, which makes
+// it usable for benchmarks, load tests and demos.
+func GenerateExport(followers, following int) ([]byte, error) {
+	base := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	const step = 3 * 60 * 60
+
+	followerEntries := make([]syntheticEntry, 0, followers)
+	mutuals := 0
+	for i := 0; i < following && mutuals < followers; i += 2 {
+		name := fmt.Sprintf("account_%d", i)
+		followerEntries = append(followerEntries, syntheticEntry{
+			StringListData: []syntheticStringData{{
+				Href:      "https://www.instagram.com/" + name,
+				Value:     name,
+				Timestamp: base + int64(i)*step + 60,
+			}},
+		})
+		mutuals++
+	}
+	for i := 0; len(followerEntries) < followers; i++ {
+		name := fmt.Sprintf("fan_%d", i)
+		followerEntries = append(followerEntries, syntheticEntry{
+			StringListData: []syntheticStringData{{
+				Href:      "https://www.instagram.com/" + name,
+				Value:     name,
+				Timestamp: base + int64(i)*step + 90,
+			}},
+		})
+	}
+
+	followingEntries := make([]syntheticEntry, 0, following)
+	for i := 0; i < following; i++ {
+		name := fmt.Sprintf("account_%d", i)
+		followingEntries = append(followingEntries, syntheticEntry{
+			Title: name,
+			StringListData: []syntheticStringData{{
+				Href:      "https://www.instagram.com/_u/" + name,
+				Timestamp: base + int64(i)*step,
+			}},
+		})
+	}
+
+	followersJSON, err := json.Marshal(followerEntries)
+	if err != nil {
+		return nil, err
+	}
+	followingJSON, err := json.Marshal(map[string][]syntheticEntry{"relationships_following": followingEntries})
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"connections/followers_and_following/followers_1.json", followersJSON},
+		{"connections/followers_and_following/following.json", followingJSON},
+	}
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(f.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
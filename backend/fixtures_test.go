@@ -0,0 +1,131 @@
+package followercount
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+// benchmarkSizes are the relationship counts benchmarked by default. Set
+// FW_BENCH_LARGE=1 to include the 1M case, which needs a few GB of memory.
+func benchmarkSizes() []int {
+	sizes := []int{1_000, 100_000}
+	if os.Getenv("FW_BENCH_LARGE") != "" {
+		sizes = append(sizes, 1_000_000)
+	}
+	return sizes
+}
+
+func sizeName(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%dM", n/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%dk", n/1_000)
+	}
+	return fmt.Sprint(n)
+}
+
+func silenceLogs(tb testing.TB) {
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+func benchmarkAnalyzeArchive(b *testing.B, size int) {
+	data, err := GenerateExport(size, size)
+	if err != nil {
+		b.Fatalf("Failed to generate export: %v", err)
+	}
+	silenceLogs(b)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		zipReader, err := openZip(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := analyzeArchive(context.Background(), zipReader); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAnalyzeArchive(b *testing.B) {
+	for _, size := range benchmarkSizes() {
+		b.Run(sizeName(size), func(b *testing.B) {
+			benchmarkAnalyzeArchive(b, size)
+		})
+	}
+}
+
+func TestGenerateExport(t *testing.T) {
+	silenceLogs(t)
+
+	data, err := GenerateExport(10, 20)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+	zipReader, err := openZip(data)
+	if err != nil {
+		t.Fatalf("Generated export is not a valid ZIP: %v", err)
+	}
+
+	response, err := analyzeArchive(context.Background(), zipReader)
+	if err != nil {
+		t.Fatalf("Failed to analyze generated export: %v", err)
+	}
+	if response.TotalFollowers != 10 || response.TotalFollowing != 20 || response.Count != 10 {
+		t.Fatalf("Expected 10 followers, 20 following and 10 non-followers, got %d, %d and %d",
+			response.TotalFollowers, response.TotalFollowing, response.Count)
+	}
+}
+
+const perfBaselinePath = "testdata/perf_baseline.json"
+
+// perfTolerance is how much allocs/op may grow over the baseline before the
+// regression check fails. Timings vary too much between machines to gate on,
+// so they are only reported.
+const perfTolerance = 1.2
+
+// TestPerformanceBaseline compares allocation counts against the checked-in
+// baseline. It only runs with FW_PERF_CHECK=1; FW_PERF_UPDATE=1 rewrites the
+// baseline from the current results instead.
+func TestPerformanceBaseline(t *testing.T) {
+	update := os.Getenv("FW_PERF_UPDATE") != ""
+	if os.Getenv("FW_PERF_CHECK") == "" && !update {
+		t.Skip("set FW_PERF_CHECK=1 to run the performance regression check")
+	}
+
+	baseline := map[string]int64{}
+	if data, err := os.ReadFile(perfBaselinePath); err == nil {
+		if err := json.Unmarshal(data, &baseline); err != nil {
+			t.Fatalf("Failed to parse %s: %v", perfBaselinePath, err)
+		}
+	}
+
+	current := map[string]int64{}
+	for _, size := range benchmarkSizes() {
+		name := "AnalyzeArchive/" + sizeName(size)
+		result := testing.Benchmark(func(b *testing.B) { benchmarkAnalyzeArchive(b, size) })
+		current[name] = result.AllocsPerOp()
+		t.Logf("%s: %s %s", name, result.String(), result.MemString())
+
+		if want, ok := baseline[name]; ok && !update && float64(result.AllocsPerOp()) > float64(want)*perfTolerance {
+			t.Errorf("%s: %d allocs/op exceeds baseline %d by more than %.0f%%",
+				name, result.AllocsPerOp(), want, (perfTolerance-1)*100)
+		}
+	}
+
+	if update {
+		data, _ := json.MarshalIndent(current, "", "  ")
+		if err := os.WriteFile(perfBaselinePath, append(data, '\n'), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", perfBaselinePath, err)
+		}
+	}
+}
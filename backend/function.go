@@ -3,18 +3,28 @@ package followercount
 
 import (
 	"archive/zip"
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
-	"regexp"
+	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/GoogleCloudPlatform/functions-framework-go/functions"
+	"github.com/afaafhariri/follower-watch/backend/analysis"
 	"github.com/joho/godotenv"
 )
 
@@ -27,39 +37,93 @@ func init() {
 		log.Printf("Warning: Could not read .env file: %v", err)
 		envConfig = make(map[string]string)
 	}
+	// Debug logs include export contents; only enable them locally.
+	analysis.DebugLogs = getEnv("DEBUG_LOGS") == "true"
+	analysis.OnPanic = reportAnalyzerPanic
+	// Broken overrides keep the embedded rules rather than failing every
+	// request.
+	if err := loadMatchingRules(getEnv("FILE_MATCHING_RULES")); err != nil {
+		log.Printf("Warning: ignoring FILE_MATCHING_RULES: %v", err)
+	}
 	functions.HTTP("AnalyzeFollowers", AnalyzeFollowers)
-}
-
-type InstagramRelationship struct {
-	Title     string `json:"title"`
-	MediaList []struct {
-		Title string `json:"title"`
-	} `json:"media_list_data"`
-	StringListData []struct {
-		Href      string `json:"href"`
-		Value     string `json:"value"`
-		Timestamp int64  `json:"timestamp"`
-	} `json:"string_list_data"`
-}
-
-type FollowingData struct {
-	RelationshipsFollowing []InstagramRelationship `json:"relationships_following"`
-}
-
-type NonFollower struct {
-	Username   string `json:"username"`
-	ProfileURL string `json:"profile_url"`
-	FollowedAt int64  `json:"followed_at,omitempty"`
+	functions.CloudEvent("ProcessStorageExport", ProcessStorageExport)
 }
 
 type APIResponse struct {
-	Success        bool          `json:"success"`
-	NonFollowers   []NonFollower `json:"non_followers,omitempty"`
-	TotalFollowing int           `json:"total_following,omitempty"`
-	TotalFollowers int           `json:"total_followers,omitempty"`
-	Count          int           `json:"count,omitempty"`
-	Error          string        `json:"error,omitempty"`
-	Message        string        `json:"message,omitempty"`
+	Success        bool                   `json:"success"`
+	NonFollowers   []analysis.NonFollower `json:"non_followers,omitempty"`
+	TotalFollowing int                    `json:"total_following,omitempty"`
+	TotalFollowers int                    `json:"total_followers,omitempty"`
+	Count          int                    `json:"count,omitempty"`
+	// MatchedCount is how many non-followers matched the q option, which
+	// leaves Count and the unfollow advice describing all of them.
+	MatchedCount *int `json:"matched_count,omitempty"`
+	// RecommendedDailyUnfollows is how many non-followers to unfollow a day
+	// to stay clear of Instagram's action limits, with ActionLimitWarning
+	// explaining why when the cleanup takes more than a day.
+	RecommendedDailyUnfollows int    `json:"recommended_daily_unfollows,omitempty"`
+	ActionLimitWarning        string `json:"action_limit_warning,omitempty"`
+	Error                     string `json:"error,omitempty"`
+	// ErrorCode identifies the error for clients; see the /errors catalogue.
+	ErrorCode string `json:"error_code,omitempty"`
+	// Hint is localized guidance for fixing an export that couldn't be
+	// analyzed, in the language given by Locale when it was detected.
+	Hint     string                  `json:"hint,omitempty"`
+	Locale   string                  `json:"locale,omitempty"`
+	Message  string                  `json:"message,omitempty"`
+	Overlap  *OverlapReport          `json:"overlap,omitempty"`
+	Plan     *CleanupPlan            `json:"plan,omitempty"`
+	Quality  *analysis.QualityReport `json:"quality,omitempty"`
+	Stats    *analysis.Stats         `json:"stats,omitempty"`
+	Insights []analysis.Insight      `json:"insights,omitempty"`
+	Warnings []analysis.Warning      `json:"warnings,omitempty"`
+	// PossibleDuplicates groups followed accounts that probably belong to
+	// the same person.
+	PossibleDuplicates []analysis.DuplicateGroup `json:"possible_duplicates,omitempty"`
+	// ProcessingReceipt lists the export files that were read, with a hash
+	// of their contents, for users' records and support.
+	ProcessingReceipt *analysis.ProcessingReceipt `json:"processing_receipt,omitempty"`
+	// DataAsOf is the date of the newest activity in the export.
+	DataAsOf string `json:"data_as_of,omitempty"`
+	// Milestones are notable moments in the account's history.
+	Milestones []analysis.Milestone `json:"milestones,omitempty"`
+	// OtherRelationships summarizes lists of accounts in export files the
+	// analysis doesn't recognize.
+	OtherRelationships []analysis.OtherRelationship `json:"other_relationships,omitempty"`
+	// PendingRequests ages the follow requests awaiting approval; see the
+	// pending_days option.
+	PendingRequests []analysis.PendingRequest `json:"pending_requests,omitempty"`
+	// Partial is set when some relationship files couldn't be read, so the
+	// counts may be too low.
+	Partial bool `json:"partial,omitempty"`
+	// SkippedAnalyzers lists the optional analyzers that failed or lacked
+	// their input files, whose sections are therefore missing.
+	SkippedAnalyzers []analysis.SkippedAnalyzer `json:"skipped_analyzers,omitempty"`
+	// Preview is set when the lists were trimmed with preview=true.
+	Preview   bool   `json:"preview,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Platforms holds the Facebook and Threads results of a combined Meta
+	// export analyzed with platforms=all.
+	Platforms map[string]*analysis.Result `json:"platforms,omitempty"`
+	// Mutuals lists the followed accounts that follow back when
+	// mutuals=true is set.
+	Mutuals []analysis.Mutual `json:"mutuals,omitempty"`
+	// Fans lists the followers that aren't followed back when fans=true is
+	// set.
+	Fans []analysis.Fan `json:"fans,omitempty"`
+	// StrongestConnections and WeakestConnections rank the mutuals by the
+	// strength of the relationship; see the connections option.
+	StrongestConnections []analysis.Connection `json:"strongest_connections,omitempty"`
+	WeakestConnections   []analysis.Connection `json:"weakest_connections,omitempty"`
+	// Groups holds the non-followers bucketed by initial instead of
+	// NonFollowers when group_by=alpha is set.
+	Groups []AlphaGroup `json:"groups,omitempty"`
+	// Manifest describes an export's files and the optional analyzers
+	// they allow; see the manifest endpoint.
+	Manifest *analysis.Manifest `json:"manifest,omitempty"`
+	// Budget reports the request's duration and memory use in debug
+	// responses.
+	Budget *RequestBudget `json:"budget,omitempty"`
 }
 
 var (
@@ -92,16 +156,18 @@ func setCORSHeaders(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Requested-With")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Requested-With, X-Content-SHA256, X-API-Key, Authorization, X-Signature-Key, X-Signature-Timestamp, X-Signature, X-Zip-Password, X-Captcha-Token, X-Guest-Token")
+	w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, X-Total-Count, X-Total-Following, X-Total-Followers, X-Partial, X-Data-As-Of, X-Response-Signature, X-Response-Key-Id")
 	w.Header().Set("Access-Control-Max-Age", "86400")
 }
 
 func checkRateLimit(ip string) bool {
+	limit := effectiveMaxRequests()
 	rateLimitMu.Lock()
 	defer rateLimitMu.Unlock()
 
-	now := time.Now()
+	now := clock.Now()
 	cutoff := now.Add(-windowDuration)
 
 	var validRequests []time.Time
@@ -112,7 +178,7 @@ func checkRateLimit(ip string) bool {
 	}
 	requestTracker[ip] = validRequests
 
-	if len(validRequests) >= maxRequests {
+	if len(validRequests) >= limit {
 		return false
 	}
 
@@ -120,6 +186,89 @@ func checkRateLimit(ip string) bool {
 	return true
 }
 
+// apiKeyHeader carries the key checked against RATE_LIMIT_EXEMPT_KEYS.
+const apiKeyHeader = "X-API-Key"
+
+// isRateLimitExempt reports whether the request comes from a network listed
+// in RATE_LIMIT_EXEMPT_CIDRS, judged by trustedClientIP, or carries a key
+// listed in RATE_LIMIT_EXEMPT_KEYS. Both are comma-separated; they let
+// monitoring probes, the operator's own servers and load tests bypass the
+// public limit.
+func isRateLimitExempt(r *http.Request) bool {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		for _, exempt := range strings.Split(getSecret("RATE_LIMIT_EXEMPT_KEYS"), ",") {
+			exempt = strings.TrimSpace(exempt)
+			if exempt != "" && subtle.ConstantTimeCompare([]byte(key), []byte(exempt)) == 1 {
+				return true
+			}
+		}
+	}
+
+	ip := trustedClientIP(r)
+	return ip != nil && containsIP(cidrList("RATE_LIMIT_EXEMPT_CIDRS"), ip)
+}
+
+// trustedClientIP returns the address a request came from, as far as it can
+// be trusted: the connection's remote address or, when that is a proxy listed
+// in TRUSTED_PROXY_CIDRS, the rightmost X-Forwarded-For hop not added by a
+// trusted proxy. Unlike getClientIP, clients can't choose it by sending their
+// own X-Forwarded-For header, so exemptions are matched against it.
+func trustedClientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	proxies := cidrList("TRUSTED_PROXY_CIDRS")
+	if ip == nil || !containsIP(proxies, ip) {
+		return ip
+	}
+
+	forwarded := r.Header.Get("X-Forwarded-For")
+	if forwarded == "" {
+		return ip
+	}
+	hops := strings.Split(forwarded, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			return nil
+		}
+		if !containsIP(proxies, hop) {
+			return hop
+		}
+		ip = hop
+	}
+	return ip
+}
+
+// cidrList parses the comma-separated CIDRs in the named setting, skipping
+// invalid entries.
+func cidrList(name string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range strings.Split(getEnv(name), ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Printf("Warning: ignoring invalid %s entry %q", name, cidr)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 func getClientIP(r *http.Request) string {
 	forwarded := r.Header.Get("X-Forwarded-For")
 	if forwarded != "" {
@@ -145,308 +294,371 @@ func sendJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-func sendError(w http.ResponseWriter, statusCode int, message string) {
-	sendJSON(w, statusCode, APIResponse{
-		Success: false,
-		Error:   message,
-	})
+const maxUploadSize = 50 * 1024 * 1024
+
+var analyzeHandler = uploadHandler("analyze", analyzeFollowers)
+
+// routes maps the last path segment of the request URL to a handler. Every
+// other path falls through to the follower analysis, so deployments that
+// mount the function under "/" or behind a rewrite such as "/api/analyze"
+// keep working.
+var routes = map[string]http.Handler{
+	"demo":        demoHandler,
+	"overlap":     overlapHandler,
+	"plan":        planHandler,
+	"manifest":    manifestHandler,
+	"errors":      errorsHandler,
+	"signing-key": signingKeyHandler,
+	"guest-token": guestTokenHandler,
 }
 
-func extractFollowers(zipReader *zip.Reader) (map[string]struct{}, int, error) {
-	followers := make(map[string]struct{})
-	// Match followers_1.json, followers_2.json, etc. in connections/followers_and_following/ folder
-	followerPattern := regexp.MustCompile(`(?i)followers(_\d+)?\.json$`)
-	// Path pattern to match the expected folder structure
-	pathPattern := regexp.MustCompile(`(?i)connections/followers_and_following/`)
-
-	log.Printf("[DEBUG] extractFollowers: scanning %d files in zip", len(zipReader.File))
-
-	for _, file := range zipReader.File {
-		fileName := file.Name
-		log.Printf("[DEBUG] extractFollowers: checking file: %s", fileName)
-		baseName := fileName
-		if idx := strings.LastIndex(fileName, "/"); idx != -1 {
-			baseName = fileName[idx+1:]
-		}
-
-		inExpectedPath := pathPattern.MatchString(fileName)
-		matchesFollowerPattern := followerPattern.MatchString(baseName)
-
-		log.Printf("[DEBUG] extractFollowers: file=%s, baseName=%s, inExpectedPath=%v, matchesFollowerPattern=%v", fileName, baseName, inExpectedPath, matchesFollowerPattern)
+// AnalyzeFollowers is the HTTP entry point of the Cloud Function.
+func AnalyzeFollowers(w http.ResponseWriter, r *http.Request) {
+	if h, ok := routes[path.Base(r.URL.Path)]; ok {
+		h.ServeHTTP(w, r)
+		return
+	}
+	analyzeHandler.ServeHTTP(w, r)
+}
 
-		if !matchesFollowerPattern && !strings.Contains(strings.ToLower(baseName), "followers") {
-			log.Printf("[DEBUG] extractFollowers: skipping %s (not a followers file)", fileName)
-			continue
-		}
+// hasZipMagic reports whether data starts with the "PK" signature of a ZIP
+// archive.
+func hasZipMagic(data []byte) bool {
+	return len(data) >= 4 && data[0] == 0x50 && data[1] == 0x4B
+}
 
-		if strings.Contains(strings.ToLower(baseName), "following") {
-			log.Printf("[DEBUG] extractFollowers: skipping %s (is a following file)", fileName)
-			continue
-		}
+func analyzeFollowers(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		sendDomainError(w, withDetail(ErrInvalidOption, "format must be json or csv"))
+		return
+	}
 
-		if !inExpectedPath && !matchesFollowerPattern {
-			log.Printf("[DEBUG] extractFollowers: skipping %s (not in expected path and doesn't match pattern)", fileName)
-			continue
-		}
+	section, err := bareSection(r)
+	if err != nil {
+		sendDomainError(w, err)
+		return
+	}
 
-		log.Printf("[DEBUG] extractFollowers: PROCESSING file: %s", fileName)
+	var preview bool
+	switch r.URL.Query().Get("preview") {
+	case "", "false":
+	case "true":
+		preview = true
+	default:
+		sendDomainError(w, withDetail(ErrInvalidOption, "preview must be true or false"))
+		return
+	}
 
-		rc, err := file.Open()
-		if err != nil {
-			continue
+	analyzer := newAnalyzer()
+	switch r.URL.Query().Get("platforms") {
+	case "", analysis.PlatformInstagram:
+	case "all":
+		analyzer.AllPlatforms = true
+	default:
+		sendDomainError(w, withDetail(ErrInvalidOption, "platforms must be instagram or all"))
+		return
+	}
+	switch r.URL.Query().Get("mutuals") {
+	case "", "false":
+	case "true":
+		analyzer.Mutuals = true
+	default:
+		sendDomainError(w, withDetail(ErrInvalidOption, "mutuals must be true or false"))
+		return
+	}
+	switch r.URL.Query().Get("fans") {
+	case "", "false":
+	case "true":
+		analyzer.Fans = true
+	default:
+		sendDomainError(w, withDetail(ErrInvalidOption, "fans must be true or false"))
+		return
+	}
+	if v := r.URL.Query().Get("pending_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 || days > maxPendingDays {
+			sendDomainError(w, withDetail(ErrInvalidOption, "pending_days must be between 1 and %d", maxPendingDays))
+			return
 		}
-
-		content, err := io.ReadAll(rc)
-		rc.Close()
-		if err != nil {
-			continue
+		analyzer.PendingThreshold = time.Duration(days) * 24 * time.Hour
+	}
+	if v := r.URL.Query().Get("connections"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxTopConnections {
+			sendDomainError(w, withDetail(ErrInvalidOption, "connections must be between 1 and %d", maxTopConnections))
+			return
 		}
+		analyzer.TopConnections = n
+	}
+	if analyzer.Only, err = selectedAnalyzers(r); err != nil {
+		sendDomainError(w, err)
+		return
+	}
+	query, err := searchQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		sendDomainError(w, err)
+		return
+	}
+	grouped, err := groupBy(r, format, section)
+	if err != nil {
+		sendDomainError(w, err)
+		return
+	}
 
-		var relationships []InstagramRelationship
-		if err := json.Unmarshal(content, &relationships); err == nil {
-			log.Printf("[DEBUG] extractFollowers: parsed %s as []InstagramRelationship with %d items", fileName, len(relationships))
-			for _, rel := range relationships {
-				// For followers: username is in string_list_data[].value (title is empty)
-				// For following: username is in title (string_list_data has href/timestamp only)
-				var username string
-				if len(rel.StringListData) > 0 && rel.StringListData[0].Value != "" {
-					username = rel.StringListData[0].Value
-				} else if rel.Title != "" {
-					username = rel.Title
-				}
-				if username != "" {
-					followers[strings.ToLower(username)] = struct{}{}
-					log.Printf("[DEBUG] extractFollowers: added follower: %s", username)
-				}
-			}
-			continue
-		} else {
-			log.Printf("[DEBUG] extractFollowers: failed to parse %s as []InstagramRelationship: %v", fileName, err)
-		}
+	fsys, err := readUpload(r)
+	if err != nil {
+		sendDomainError(w, err)
+		return
+	}
 
-		var singleRel InstagramRelationship
-		if err := json.Unmarshal(content, &singleRel); err == nil {
-			log.Printf("[DEBUG] extractFollowers: parsed %s as single InstagramRelationship", fileName)
-			var username string
-			if len(singleRel.StringListData) > 0 && singleRel.StringListData[0].Value != "" {
-				username = singleRel.StringListData[0].Value
-			} else if singleRel.Title != "" {
-				username = singleRel.Title
-			}
-			if username != "" {
-				followers[strings.ToLower(username)] = struct{}{}
-			}
-		} else {
-			log.Printf("[DEBUG] extractFollowers: failed to parse %s as single InstagramRelationship: %v", fileName, err)
-			log.Printf("[DEBUG] extractFollowers: content preview: %.500s", string(content))
-		}
+	response, err := analyzeArchiveWith(r.Context(), analyzer, fsys)
+	if err != nil {
+		sendDomainError(w, err)
+		return
 	}
 
-	log.Printf("[DEBUG] extractFollowers: found %d total followers", len(followers))
-	return followers, len(followers), nil
+	if query != "" {
+		response.NonFollowers = searchNonFollowers(response.NonFollowers, query)
+		matched := len(response.NonFollowers)
+		response.MatchedCount = &matched
+	}
+	if format == "csv" {
+		writeNonFollowersCSV(w, response.NonFollowers)
+		return
+	}
+	var total int
+	if section != "" {
+		_, total = bareSections[section](response)
+	}
+	if preview {
+		applyPreview(&response)
+	}
+	if section != "" {
+		sendBare(w, response, section, total)
+		return
+	}
+	if grouped {
+		response.Groups = groupAlphabetically(response.NonFollowers)
+		response.NonFollowers = nil
+	}
+	response.Budget = debugBudget(r)
+	sendJSON(w, http.StatusOK, response)
 }
 
-func extractFollowing(zipReader *zip.Reader) ([]NonFollower, int, error) {
-	var following []NonFollower
-	pathPattern := regexp.MustCompile(`(?i)connections/followers_and_following/`)
-	followingPattern := regexp.MustCompile(`(?i)^following\.json$`)
-
-	log.Printf("[DEBUG] extractFollowing: scanning %d files in zip", len(zipReader.File))
-
-	for _, file := range zipReader.File {
-		fileName := file.Name
-		log.Printf("[DEBUG] extractFollowing: checking file: %s", fileName)
-		lowerFileName := strings.ToLower(fileName)
-		baseName := fileName
-		if idx := strings.LastIndex(fileName, "/"); idx != -1 {
-			baseName = fileName[idx+1:]
-		}
-		lowerBaseName := strings.ToLower(baseName)
+// maxPendingDays bounds the pending_days option to ten years.
+const maxPendingDays = 3650
 
-		inExpectedPath := pathPattern.MatchString(fileName)
-		matchesFollowingPattern := followingPattern.MatchString(baseName)
+// maxTopConnections bounds the connections option.
+const maxTopConnections = 100
 
-		log.Printf("[DEBUG] extractFollowing: file=%s, baseName=%s, inExpectedPath=%v, matchesFollowingPattern=%v", fileName, baseName, inExpectedPath, matchesFollowingPattern)
+// readUpload reads the request body as either a ZIP export or a slim bundle.
+func readUpload(r *http.Request) (fs.FS, error) {
+	body := bufio.NewReader(r.Body)
 
-		if !matchesFollowingPattern && !strings.Contains(lowerBaseName, "following") {
-			log.Printf("[DEBUG] extractFollowing: skipping %s (not a following file)", fileName)
-			continue
+	// Reject anything that isn't a ZIP from its first bytes, before
+	// buffering up to 50MB of it.
+	if !isSlimBundle(r) {
+		head, err := body.Peek(4)
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%w: %w", ErrReadBody, err)
 		}
-
-		if strings.Contains(lowerBaseName, "followers") {
-			log.Printf("[DEBUG] extractFollowing: skipping %s (is a followers file)", fileName)
-			continue
-		}
-
-		if !inExpectedPath && !matchesFollowingPattern && !strings.Contains(lowerFileName, "following") {
-			log.Printf("[DEBUG] extractFollowing: skipping %s (not in expected path)", fileName)
-			continue
+		if !hasZipMagic(head) {
+			return nil, ErrNotZip
 		}
+	}
 
-		log.Printf("[DEBUG] extractFollowing: PROCESSING file: %s", fileName)
-
-		rc, err := file.Open()
-		if err != nil {
-			continue
+	bodyBytes, sum, err := readBody(body, r.ContentLength)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, fmt.Errorf("%w: %w", ErrUploadTooLarge, err)
 		}
+		return nil, fmt.Errorf("%w: %w", ErrReadBody, err)
+	}
 
-		content, err := io.ReadAll(rc)
-		rc.Close()
-		if err != nil {
-			continue
-		}
+	if err := verifyChecksum(r, sum); err != nil {
+		return nil, err
+	}
 
-		var followingData FollowingData
-		if err := json.Unmarshal(content, &followingData); err == nil {
-			log.Printf("[DEBUG] extractFollowing: parsed %s as FollowingData with %d relationships", fileName, len(followingData.RelationshipsFollowing))
-			for _, rel := range followingData.RelationshipsFollowing {
-				var username string
-				var timestamp int64
-				if len(rel.StringListData) > 0 {
-					if rel.StringListData[0].Value != "" {
-						username = rel.StringListData[0].Value
-					}
-					timestamp = rel.StringListData[0].Timestamp
-				}
-				if username == "" && rel.Title != "" {
-					username = rel.Title
-				}
-				if username != "" {
-					following = append(following, NonFollower{
-						Username:   username,
-						ProfileURL: fmt.Sprintf("https://instagram.com/%s", username),
-						FollowedAt: timestamp,
-					})
-				}
-			}
-			if len(following) > 0 {
-				log.Printf("[DEBUG] extractFollowing: found %d following from FollowingData", len(following))
-				break
-			}
-		} else {
-			log.Printf("[DEBUG] extractFollowing: failed to parse %s as FollowingData: %v", fileName, err)
-		}
+	return openUpload(r.Context(), bodyBytes, r.Header.Get(zipPasswordHeader), isSlimBundle(r))
+}
 
-		var relationships []InstagramRelationship
-		if err := json.Unmarshal(content, &relationships); err == nil {
-			log.Printf("[DEBUG] extractFollowing: parsed %s as []InstagramRelationship with %d items", fileName, len(relationships))
-			for _, rel := range relationships {
-				var username string
-				var timestamp int64
-				if len(rel.StringListData) > 0 {
-					if rel.StringListData[0].Value != "" {
-						username = rel.StringListData[0].Value
-					}
-					timestamp = rel.StringListData[0].Timestamp
-				}
-				if username == "" && rel.Title != "" {
-					username = rel.Title
-				}
-				if username != "" {
-					following = append(following, NonFollower{
-						Username:   username,
-						ProfileURL: fmt.Sprintf("https://instagram.com/%s", username),
-						FollowedAt: timestamp,
-					})
-				}
-			}
-		} else {
-			log.Printf("[DEBUG] extractFollowing: failed to parse %s as []InstagramRelationship: %v", fileName, err)
-			log.Printf("[DEBUG] extractFollowing: content preview: %.500s", string(content))
+// openUpload runs the upload scanners over an upload read into memory, opens
+// it as a slim bundle or an export ZIP and runs the OnUploadValidated hooks.
+// Every path that accepts an export, including /overlap and Cloud Storage,
+// opens it through here so none of them skips the scanners or the hooks.
+func openUpload(ctx context.Context, data []byte, password string, slim bool) (fs.FS, error) {
+	if err := scanUpload(ctx, data); err != nil {
+		return nil, err
+	}
+	var fsys fs.FS
+	var err error
+	if slim {
+		if fsys, err = slimBundleFS(data); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
 		}
+	} else if fsys, err = openExport(data, password); err != nil {
+		return nil, err
 	}
 
-	log.Printf("[DEBUG] extractFollowing: found %d total following", len(following))
-	return following, len(following), nil
+	if err := runUploadValidated(ctx, fsys); err != nil {
+		return nil, err
+	}
+	return fsys, nil
 }
 
-func findNonFollowers(following []NonFollower, followers map[string]struct{}) []NonFollower {
-	var nonFollowers []NonFollower
-
-	for _, user := range following {
-		username := strings.ToLower(user.Username)
-		if _, exists := followers[username]; !exists {
-			nonFollowers = append(nonFollowers, user)
-		}
+// readBody reads an upload into a buffer allocated once from its declared
+// length, capped at maxUploadSize, rather than one regrown and copied as it
+// fills, which could briefly hold nearly twice the upload. The body is hashed
+// in the same pass for verifyChecksum. It stays in memory: uploads are never
+// written to disk, and the function's /tmp is memory-backed anyway.
+func readBody(body io.Reader, length int64) ([]byte, [sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	buf := new(bytes.Buffer)
+	if length > 0 {
+		// ReadFrom grows the buffer whenever less than MinRead bytes are
+		// free, including after the last byte.
+		buf.Grow(int(min(length, maxUploadSize)) + bytes.MinRead)
 	}
-
-	return nonFollowers
+	h := sha256.New()
+	if _, err := buf.ReadFrom(io.TeeReader(body, h)); err != nil {
+		return nil, sum, err
+	}
+	h.Sum(sum[:0])
+	return buf.Bytes(), sum, nil
 }
 
-func AnalyzeFollowers(w http.ResponseWriter, r *http.Request) {
-	setCORSHeaders(w, r)
+// zipPasswordHeader carries the password of an encrypted export ZIP.
+const zipPasswordHeader = "X-Zip-Password"
 
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
+// openExport opens an export ZIP, decrypting it with password when it is
+// password-protected. The password is only held for the request and never
+// logged.
+func openExport(data []byte, password string) (fs.FS, error) {
+	zipReader, err := openZip(data)
+	if err != nil {
+		return nil, err
 	}
-
-	if r.Method != http.MethodPost {
-		sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
+	if !analysis.IsEncrypted(zipReader) {
+		return zipReader, nil
 	}
-
-	clientIP := getClientIP(r)
-	if !checkRateLimit(clientIP) {
-		sendError(w, http.StatusTooManyRequests, "Rate limit exceeded. Please try again later.")
-		return
+	if password == "" {
+		return nil, ErrPasswordRequired
 	}
+	return analysis.DecryptZip(zipReader, password)
+}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 50*1024*1024)
-
-	bodyBytes, err := io.ReadAll(r.Body)
-	if err != nil {
-		if strings.Contains(err.Error(), "request body too large") {
-			sendError(w, http.StatusRequestEntityTooLarge, "File too large. Maximum size is 50MB.")
-			return
-		}
-		sendError(w, http.StatusBadRequest, "Failed to read request body")
-		return
+// checksumHeader optionally carries the hex SHA-256 of the upload as computed
+// by the client.
+const checksumHeader = "X-Content-SHA256"
+
+// verifyChecksum rejects uploads whose body doesn't match the client-supplied
+// checksum, so a truncated or corrupted upload fails with a clear error
+// instead of a misleading "no data found". sum is the SHA-256 of the body.
+func verifyChecksum(r *http.Request, sum [sha256.Size]byte) error {
+	expected := strings.TrimSpace(r.Header.Get(checksumHeader))
+	if expected == "" {
+		return nil
 	}
 
-	if len(bodyBytes) < 4 || bodyBytes[0] != 0x50 || bodyBytes[1] != 0x4B {
-		sendError(w, http.StatusBadRequest, "Invalid file format. Please upload a valid ZIP file.")
-		return
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(expected, actual) {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expected, actual)
 	}
+	return nil
+}
 
-	zipReader, err := zip.NewReader(bytes.NewReader(bodyBytes), int64(len(bodyBytes)))
-	if err != nil {
-		sendError(w, http.StatusBadRequest, "Failed to read ZIP file. Please ensure it's a valid ZIP archive.")
-		return
+// openZip opens data as a ZIP archive.
+func openZip(data []byte) (*zip.Reader, error) {
+	if !hasZipMagic(data) {
+		return nil, ErrNotZip
 	}
 
-	followers, totalFollowers, err := extractFollowers(zipReader)
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
-		log.Printf("Error extracting followers: %v", err)
-		sendError(w, http.StatusInternalServerError, "Failed to process followers data")
-		return
+		return nil, fmt.Errorf("%w: %w", ErrCorruptZip, err)
 	}
+	return zipReader, nil
+}
 
-	following, totalFollowing, err := extractFollowing(zipReader)
-	if err != nil {
-		log.Printf("Error extracting following: %v", err)
-		sendError(w, http.StatusInternalServerError, "Failed to process following data")
-		return
+// qualityBaseline reads a quality report baseline from the environment.
+func qualityBaseline(key string, fallback float64) float64 {
+	if v, err := strconv.ParseFloat(getEnv(key), 64); err == nil && v >= 0 {
+		return v
 	}
+	return fallback
+}
 
-	if totalFollowing == 0 {
-		sendError(w, http.StatusBadRequest, "No following data found. Please upload a valid Instagram data export.")
-		return
+// newAnalyzer configures the analysis from the environment. Insights are
+// relative to clock, so tests can pin them.
+func newAnalyzer() analysis.Analyzer {
+	return analysis.Analyzer{
+		SpamBaseline:  qualityBaseline("QUALITY_BASELINE_SPAM", analysis.DefaultSpamBaseline),
+		BrandBaseline: qualityBaseline("QUALITY_BASELINE_BRAND", analysis.DefaultBrandBaseline),
+		StaleAfter:    staleAfter(),
+		Now:           clock.Now,
 	}
+}
 
-	if totalFollowers == 0 {
-		sendError(w, http.StatusBadRequest, "No followers data found. Please upload a valid Instagram data export.")
-		return
+// staleAfter reads STALE_EXPORT_DAYS, the age in days of an export's newest
+// activity above which it is reported as stale.
+func staleAfter() time.Duration {
+	if days, err := strconv.Atoi(getEnv("STALE_EXPORT_DAYS")); err == nil && days > 0 {
+		return time.Duration(days) * 24 * time.Hour
 	}
+	return analysis.DefaultStaleAfter
+}
+
+// analyzeArchive runs the follower analysis on an export, passing the result
+// through the registered hooks. It returns ErrNoFollowing or ErrNoFollowers
+// when the archive lacks either list.
+func analyzeArchive(ctx context.Context, fsys fs.FS) (APIResponse, error) {
+	return analyzeArchiveWith(ctx, newAnalyzer(), fsys)
+}
 
-	nonFollowers := findNonFollowers(following, followers)
+// analyzeArchiveWith is analyzeArchive with a customized analyzer.
+func analyzeArchiveWith(ctx context.Context, analyzer analysis.Analyzer, fsys fs.FS) (APIResponse, error) {
+	result, err := analyzer.AnalyzeFiles(fsys)
+	if err != nil {
+		if errors.Is(err, ErrNoFollowing) || errors.Is(err, ErrNoFollowers) {
+			reportUnrecognizedExport(ctx, err, fsys)
+		}
+		return APIResponse{}, err
+	}
+	runParsed(ctx, result)
+	response := newAPIResponse(result)
+	runResult(ctx, &response)
+	return response, nil
+}
 
-	sendJSON(w, http.StatusOK, APIResponse{
-		Success:        true,
-		NonFollowers:   nonFollowers,
-		TotalFollowing: totalFollowing,
-		TotalFollowers: totalFollowers,
-		Count:          len(nonFollowers),
-		Message:        "Analysis complete",
-	})
+// newAPIResponse builds the response to a successful analysis.
+func newAPIResponse(result *analysis.Result) APIResponse {
+	perDay, limitWarning := unfollowAdvice(len(result.NonFollowers))
+	return APIResponse{
+		Success:                   true,
+		NonFollowers:              result.NonFollowers,
+		TotalFollowing:            result.TotalFollowing,
+		TotalFollowers:            result.TotalFollowers,
+		Count:                     len(result.NonFollowers),
+		RecommendedDailyUnfollows: perDay,
+		ActionLimitWarning:        limitWarning,
+		Quality:                   result.Quality,
+		Stats:                     result.Stats,
+		Insights:                  result.Insights,
+		Warnings:                  result.Warnings,
+		PossibleDuplicates:        result.PossibleDuplicates,
+		ProcessingReceipt:         result.Receipt,
+		DataAsOf:                  result.DataAsOf,
+		Milestones:                result.Milestones,
+		OtherRelationships:        result.OtherRelationships,
+		PendingRequests:           result.PendingRequests,
+		Partial:                   result.Partial,
+		SkippedAnalyzers:          result.SkippedAnalyzers,
+		Platforms:                 result.Platforms,
+		Mutuals:                   result.Mutuals,
+		Fans:                      result.Fans,
+		StrongestConnections:      result.StrongestConnections,
+		WeakestConnections:        result.WeakestConnections,
+		Message:                   "Analysis complete",
+	}
 }
@@ -3,11 +3,16 @@ package followercount
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
 )
 
 func createTestZip(t *testing.T, files map[string]string) []byte {
@@ -79,6 +84,145 @@ func TestAnalyzeFollowers_ValidZip(t *testing.T) {
 	if apiResponse.Count != 2 {
 		t.Fatalf("Expected 2 non-followers, got %d", apiResponse.Count)
 	}
+
+	for _, nf := range apiResponse.NonFollowers {
+		if nf.Source != "connections/followers_and_following/following.json" {
+			t.Errorf("Expected source following.json for %s, got %q", nf.Username, nf.Source)
+		}
+	}
+}
+
+func TestAnalyzeFollowers_HrefMatching(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+
+	// The following entry carries a display name as title; only the href
+	// identifies it as the same account as the follower.
+	zipBytes := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.json": `[
+			{"string_list_data": [{"href": "https://www.instagram.com/jane.doe", "value": "jane.doe", "timestamp": 1}]}
+		]`,
+		"connections/followers_and_following/following.json": `{"relationships_following": [
+			{"title": "Jane Doe", "string_list_data": [{"href": "https://www.instagram.com/jane.doe", "timestamp": 2}]},
+			{"title": "user3", "string_list_data": [{"href": "https://www.instagram.com/user3", "timestamp": 3}]}
+		]}`,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(zipBytes))
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, req)
+
+	var apiResponse APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&apiResponse); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if apiResponse.Count != 1 || apiResponse.NonFollowers[0].Username != "user3" {
+		t.Fatalf("Expected user3 as the only non-follower, got %+v", apiResponse.NonFollowers)
+	}
+}
+
+func TestAnalyzeFollowers_AllPlatforms(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	zipBytes := createTestZip(t, map[string]string{
+		"instagram/connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "a"}]}]`,
+		"instagram/connections/followers_and_following/following.json":   `{"relationships_following": [{"title": "a"}, {"title": "b"}]}`,
+		"threads/followers.json": `[{"string_list_data": [{"value": "a"}]}]`,
+		"threads/following.json": `{"relationships_following": [{"title": "c"}]}`,
+	})
+
+	analyze := func(query string) (int, APIResponse) {
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/"+query, bytes.NewReader(zipBytes)))
+		var apiResponse APIResponse
+		json.NewDecoder(w.Body).Decode(&apiResponse)
+		return w.Code, apiResponse
+	}
+
+	if _, response := analyze(""); response.Count != 1 || response.Platforms != nil {
+		t.Fatalf("Expected only the Instagram result by default, got %+v", response)
+	}
+	_, response := analyze("?platforms=all")
+	if threads := response.Platforms["threads"]; response.Count != 1 || threads == nil || len(threads.NonFollowers) != 1 {
+		t.Fatalf("Expected Instagram and Threads results, got %+v", response)
+	}
+	if code, _ := analyze("?platforms=facebook"); code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an unknown platforms value, got %d", code)
+	}
+}
+
+func TestAnalyzeFollowers_PendingDays(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+	withClock(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	zipBytes := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "a"}]}]`,
+		"connections/followers_and_following/following.json":   `{"relationships_following": [{"title": "a"}]}`,
+		"connections/followers_and_following/pending_follow_requests.json": `{"relationships_follow_requests_sent": [
+			{"string_list_data": [{"value": "private.one", "timestamp": 1733011200}]}
+		]}`,
+	})
+
+	analyze := func(query string) (int, APIResponse) {
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/"+query, bytes.NewReader(zipBytes)))
+		var apiResponse APIResponse
+		json.NewDecoder(w.Body).Decode(&apiResponse)
+		return w.Code, apiResponse
+	}
+
+	// The request was sent on 2024-12-01, 31 days before the clock.
+	if _, response := analyze(""); len(response.PendingRequests) != 1 || response.PendingRequests[0].ConsiderCancelling {
+		t.Fatalf("Expected the request not to be flagged with the default threshold, got %+v", response.PendingRequests)
+	}
+	if _, response := analyze("?pending_days=30"); len(response.PendingRequests) != 1 || !response.PendingRequests[0].ConsiderCancelling {
+		t.Fatalf("Expected the request to be flagged after 30 days, got %+v", response.PendingRequests)
+	}
+	for _, query := range []string{"?pending_days=0", "?pending_days=soon"} {
+		if code, _ := analyze(query); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, code)
+		}
+	}
+}
+
+func TestAnalyzeFollowers_EncryptedZip(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	// Created with Info-ZIP: zip -r -P s3cret encrypted_export.zip connections
+	zipBytes, err := os.ReadFile("testdata/encrypted_export.zip")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	analyze := func(password string) APIResponse {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(zipBytes))
+		if password != "" {
+			req.Header.Set(zipPasswordHeader, password)
+		}
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, req)
+		var apiResponse APIResponse
+		json.NewDecoder(w.Body).Decode(&apiResponse)
+		return apiResponse
+	}
+
+	if response := analyze(""); response.ErrorCode != "password_required" {
+		t.Fatalf("Expected password_required, got %+v", response)
+	}
+	if response := analyze("wrong"); response.ErrorCode != "wrong_password" {
+		t.Fatalf("Expected wrong_password, got %+v", response)
+	}
+	response := analyze("s3cret")
+	if !response.Success || response.Count != 1 || response.NonFollowers[0].Username != "celeb" {
+		t.Fatalf("Expected celeb as the only non-follower, got %+v", response)
+	}
 }
 
 func TestAnalyzeFollowers_InvalidZip(t *testing.T) {
@@ -96,6 +240,119 @@ func TestAnalyzeFollowers_InvalidZip(t *testing.T) {
 	}
 }
 
+func TestAnalyzeFollowers_Mutuals(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	// 10 of the 60 followed accounts follow back.
+	zipBytes, err := GenerateExport(10, 60)
+	if err != nil {
+		t.Fatalf("GenerateExport failed: %v", err)
+	}
+	analyze := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/"+query, bytes.NewReader(zipBytes)))
+		return w
+	}
+
+	var response APIResponse
+	json.NewDecoder(analyze("").Body).Decode(&response)
+	if response.Mutuals != nil {
+		t.Fatalf("Expected no mutuals by default, got %d", len(response.Mutuals))
+	}
+
+	json.NewDecoder(analyze("?mutuals=true").Body).Decode(&response)
+	if len(response.Mutuals) != 10 || len(response.NonFollowers) != 50 {
+		t.Fatalf("Expected 10 mutuals and 50 non-followers, got %d and %d", len(response.Mutuals), len(response.NonFollowers))
+	}
+
+	w := analyze("?mutuals=true&envelope=false&section=mutuals")
+	var bare []analysis.Mutual
+	json.NewDecoder(w.Body).Decode(&bare)
+	if len(bare) != 10 || w.Header().Get(totalCountHeader) != "10" {
+		t.Fatalf("Expected the 10 mutuals as a bare array, got %d", len(bare))
+	}
+
+	if w := analyze("?mutuals=yes"); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an invalid mutuals value, got %d", w.Code)
+	}
+}
+
+func TestAnalyzeFollowers_Fans(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	zipBytes := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.json": `[
+			{"string_list_data": [{"value": "friend"}]},
+			{"string_list_data": [{"value": "fan1"}]},
+			{"string_list_data": [{"value": "fan2"}]}
+		]`,
+		"connections/followers_and_following/following.json": `{"relationships_following": [{"title": "friend"}, {"title": "celeb"}]}`,
+	})
+	analyze := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/"+query, bytes.NewReader(zipBytes)))
+		return w
+	}
+
+	var response APIResponse
+	json.NewDecoder(analyze("").Body).Decode(&response)
+	if response.Fans != nil {
+		t.Fatalf("Expected no fans by default, got %d", len(response.Fans))
+	}
+
+	json.NewDecoder(analyze("?fans=true").Body).Decode(&response)
+	if len(response.Fans) != 2 || response.Fans[0].Username != "fan1" || len(response.NonFollowers) != 1 {
+		t.Fatalf("Expected fan1 and fan2 as fans and one non-follower, got %+v", response.Fans)
+	}
+
+	w := analyze("?fans=true&envelope=false&section=fans")
+	var bare []analysis.Fan
+	json.NewDecoder(w.Body).Decode(&bare)
+	if len(bare) != 2 || w.Header().Get(totalCountHeader) != "2" {
+		t.Fatalf("Expected the 2 fans as a bare array, got %d", len(bare))
+	}
+
+	if w := analyze("?fans=1"); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an invalid fans value, got %d", w.Code)
+	}
+}
+
+func TestAnalyzeFollowers_Connections(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	// 10 of the 60 followed accounts follow back.
+	zipBytes, err := GenerateExport(10, 60)
+	if err != nil {
+		t.Fatalf("GenerateExport failed: %v", err)
+	}
+	analyze := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/"+query, bytes.NewReader(zipBytes)))
+		return w
+	}
+
+	var response APIResponse
+	json.NewDecoder(analyze("?connections=3").Body).Decode(&response)
+	if len(response.StrongestConnections) != 3 || len(response.WeakestConnections) != 3 {
+		t.Fatalf("Expected 3 strongest and 3 weakest connections, got %d and %d", len(response.StrongestConnections), len(response.WeakestConnections))
+	}
+	if response.StrongestConnections[0].Score < response.WeakestConnections[0].Score {
+		t.Fatal("Expected the strongest connections to outscore the weakest")
+	}
+
+	for _, v := range []string{"0", "101", "many"} {
+		if w := analyze("?connections=" + v); w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400 for connections=%s, got %d", v, w.Code)
+		}
+	}
+}
+
 func TestAnalyzeFollowers_MethodNotAllowed(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 
@@ -129,29 +386,6 @@ func TestAnalyzeFollowers_OptionsRequest(t *testing.T) {
 	}
 }
 
-func TestFindNonFollowers(t *testing.T) {
-	followers := map[string]struct{}{
-		"user1": {},
-		"user2": {},
-	}
-
-	following := []NonFollower{
-		{Username: "user1", ProfileURL: "https://instagram.com/user1"},
-		{Username: "user3", ProfileURL: "https://instagram.com/user3"},
-		{Username: "USER2", ProfileURL: "https://instagram.com/USER2"}, // Test case insensitivity
-	}
-
-	nonFollowers := findNonFollowers(following, followers)
-
-	if len(nonFollowers) != 1 {
-		t.Fatalf("Expected 1 non-follower, got %d", len(nonFollowers))
-	}
-
-	if nonFollowers[0].Username != "user3" {
-		t.Fatalf("Expected user3 to be non-follower, got %s", nonFollowers[0].Username)
-	}
-}
-
 func TestGetClientIP(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -200,3 +434,33 @@ func TestGetClientIP(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAnalyzer_Baselines(t *testing.T) {
+	withEnv(t, "QUALITY_BASELINE_SPAM", "10")
+	withEnv(t, "QUALITY_BASELINE_BRAND", "not a number")
+
+	analyzer := newAnalyzer()
+	if analyzer.SpamBaseline != 10 || analyzer.BrandBaseline != analysis.DefaultBrandBaseline {
+		t.Fatalf("Expected baselines 10 and %v, got %v and %v", analysis.DefaultBrandBaseline, analyzer.SpamBaseline, analyzer.BrandBaseline)
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	data := bytes.Repeat([]byte("follower-watch"), 10000)
+	want := sha256.Sum256(data)
+
+	for _, length := range []int64{int64(len(data)), -1} {
+		body, sum, err := readBody(bytes.NewReader(data), length)
+		if err != nil {
+			t.Fatalf("readBody failed: %v", err)
+		}
+		if !bytes.Equal(body, data) || sum != want {
+			t.Fatalf("Expected the body and its hash back for length %d", length)
+		}
+		// Regrowing would at least double the capacity; allocation size
+		// classes only round it up a little.
+		if length > 0 && cap(body) > len(data)*5/4 {
+			t.Fatalf("Expected a buffer sized from the length, got capacity %d", cap(body))
+		}
+	}
+}
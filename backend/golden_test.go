@@ -0,0 +1,65 @@
+package followercount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/golden/*.json from the current results")
+
+// TestGolden analyzes every export in testdata/golden and compares the
+// response with the matching .json file. To cover a new export layout, drop
+// the ZIP next to the others and run `go test -run TestGolden -update`, then
+// review the generated JSON before committing it.
+func TestGolden(t *testing.T) {
+	silenceLogs(t)
+	// Insights depend on how long ago accounts were followed.
+	withClock(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "golden", "*.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("No golden fixtures found")
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".zip")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("Failed to read fixture: %v", err)
+			}
+
+			got, err := json.MarshalIndent(analyzeExportBytes(context.Background(), data), "", "  ")
+			if err != nil {
+				t.Fatalf("Failed to encode response: %v", err)
+			}
+			got = append(got, '\n')
+
+			goldenPath := strings.TrimSuffix(fixture, ".zip") + ".json"
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+					t.Fatalf("Failed to write %s: %v", goldenPath, err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("Missing expected result %s (run with -update to create it): %v", goldenPath, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Result differs from %s.\ngot:\n%s\nwant:\n%s", goldenPath, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package followercount
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// otherGroup collects usernames that don't start with a letter A–Z.
+const otherGroup = "#"
+
+// AlphaGroup is the non-followers whose usernames start with one letter.
+type AlphaGroup struct {
+	// Letter is the uppercase initial, or "#" for usernames starting with a
+	// digit, dot or underscore.
+	Letter       string                 `json:"letter"`
+	Count        int                    `json:"count"`
+	NonFollowers []analysis.NonFollower `json:"non_followers"`
+}
+
+// groupBy reads the group_by option. Grouping replaces the flat list, so it
+// can't be combined with the layouts that only carry that list.
+func groupBy(r *http.Request, format, section string) (bool, error) {
+	switch r.URL.Query().Get("group_by") {
+	case "":
+		return false, nil
+	case "alpha":
+		if format == "csv" || section != "" {
+			return false, withDetail(ErrInvalidOption, "group_by can't be combined with format=csv or envelope=false")
+		}
+		return true, nil
+	default:
+		return false, withDetail(ErrInvalidOption, "group_by must be alpha")
+	}
+}
+
+// initial returns the group of a username.
+func initial(username string) string {
+	if username != "" {
+		if c := strings.ToUpper(username[:1]); c >= "A" && c <= "Z" {
+			return c
+		}
+	}
+	return otherGroup
+}
+
+// groupAlphabetically buckets non-followers by the initial of their username,
+// for mobile frontends that scroll long lists with an A–Z index. Groups are
+// ordered A to Z followed by "#", each sorted by username; empty groups are
+// left out.
+func groupAlphabetically(list []analysis.NonFollower) []AlphaGroup {
+	byLetter := make(map[string][]analysis.NonFollower)
+	for _, nf := range list {
+		letter := initial(nf.Username)
+		byLetter[letter] = append(byLetter[letter], nf)
+	}
+
+	groups := []AlphaGroup{}
+	for _, letter := range strings.Split("ABCDEFGHIJKLMNOPQRSTUVWXYZ"+otherGroup, "") {
+		members := byLetter[letter]
+		if len(members) == 0 {
+			continue
+		}
+		sort.SliceStable(members, func(i, j int) bool {
+			return strings.ToLower(members[i].Username) < strings.ToLower(members[j].Username)
+		})
+		groups = append(groups, AlphaGroup{Letter: letter, Count: len(members), NonFollowers: members})
+	}
+	return groups
+}
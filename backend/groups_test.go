@@ -0,0 +1,76 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+func TestGroupAlphabetically(t *testing.T) {
+	list := []analysis.NonFollower{
+		{Username: "zed"}, {Username: "_hidden"}, {Username: "bob"}, {Username: "Alice"}, {Username: "9lives"}, {Username: "anna"},
+	}
+	groups := groupAlphabetically(list)
+
+	want := []struct {
+		letter string
+		users  []string
+	}{
+		{"A", []string{"Alice", "anna"}},
+		{"B", []string{"bob"}},
+		{"Z", []string{"zed"}},
+		{"#", []string{"9lives", "_hidden"}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("Expected %d groups, got %+v", len(want), groups)
+	}
+	for i, w := range want {
+		g := groups[i]
+		if g.Letter != w.letter || g.Count != len(w.users) {
+			t.Fatalf("Group %d: expected %s with %d, got %s with %d", i, w.letter, len(w.users), g.Letter, g.Count)
+		}
+		for j, u := range w.users {
+			if g.NonFollowers[j].Username != u {
+				t.Errorf("Group %s: expected %v, got %+v", g.Letter, w.users, g.NonFollowers)
+			}
+		}
+	}
+}
+
+func TestAnalyzeFollowers_GroupByAlpha(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	data, err := GenerateExport(2, 6)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/?group_by=alpha", bytes.NewReader(data)))
+	var response APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || response.NonFollowers != nil || len(response.Groups) == 0 {
+		t.Fatalf("Expected grouped non-followers, got %d %+v", w.Code, response)
+	}
+	total := 0
+	for _, g := range response.Groups {
+		total += g.Count
+	}
+	if total != response.Count {
+		t.Fatalf("Expected the groups to hold all %d non-followers, got %d", response.Count, total)
+	}
+
+	for _, query := range []string{"/?group_by=size", "/?group_by=alpha&format=csv", "/?group_by=alpha&envelope=false"} {
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, query, bytes.NewReader(data)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package followercount
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// guestTokenHeader carries the guest token of an embedded widget's upload.
+const guestTokenHeader = "X-Guest-Token"
+
+// Defaults for GUEST_TOKEN_TTL_MINUTES and GUEST_TOKEN_MAX_UPLOADS.
+const (
+	defaultGuestTokenTTL        = 15 * time.Minute
+	defaultGuestTokenMaxUploads = 3
+)
+
+// guestTokenAudience sets guest tokens apart from other tokens signed with
+// the same secret.
+const guestTokenAudience = "follower-watch-guest"
+
+// guestConfig is the guest token setup read from the environment.
+type guestConfig struct {
+	secret []byte
+	// partners maps each allowed origin to the embedding partner it
+	// belongs to.
+	partners   map[string]string
+	ttl        time.Duration
+	maxUploads int
+}
+
+// guestConfigFromEnv reads GUEST_TOKEN_SECRET, GUEST_PARTNERS,
+// GUEST_TOKEN_TTL_MINUTES and GUEST_TOKEN_MAX_UPLOADS. It returns nil when
+// guest tokens are disabled, which is the default.
+func guestConfigFromEnv() *guestConfig {
+	secret := getSecret("GUEST_TOKEN_SECRET")
+	if secret == "" {
+		return nil
+	}
+	config := &guestConfig{
+		secret:     []byte(secret),
+		partners:   parseGuestPartners(getEnv("GUEST_PARTNERS")),
+		ttl:        defaultGuestTokenTTL,
+		maxUploads: defaultGuestTokenMaxUploads,
+	}
+	if v := getEnv("GUEST_TOKEN_TTL_MINUTES"); v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil && minutes > 0 {
+			config.ttl = time.Duration(minutes) * time.Minute
+		} else {
+			log.Printf("Warning: ignoring invalid GUEST_TOKEN_TTL_MINUTES %q", v)
+		}
+	}
+	if v := getEnv("GUEST_TOKEN_MAX_UPLOADS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			config.maxUploads = n
+		} else {
+			log.Printf("Warning: ignoring invalid GUEST_TOKEN_MAX_UPLOADS %q", v)
+		}
+	}
+	return config
+}
+
+// parseGuestPartners parses GUEST_PARTNERS, a comma-separated list of
+// partner=origin entries; a partner embedding on several origins is listed
+// once per origin, e.g.
+//
+//	GUEST_PARTNERS=acme=https://acme.example,acme=https://www.acme.example
+//
+// Malformed entries are logged and ignored.
+func parseGuestPartners(value string) map[string]string {
+	partners := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		partner, origin, ok := strings.Cut(entry, "=")
+		if !ok || partner == "" || origin == "" {
+			log.Printf("Warning: ignoring malformed GUEST_PARTNERS entry %q", entry)
+			continue
+		}
+		partners[strings.TrimSuffix(origin, "/")] = partner
+	}
+	return partners
+}
+
+// GuestToken is issued by the guest-token endpoint for an embedded widget.
+type GuestToken struct {
+	Token   string `json:"token"`
+	Partner string `json:"partner"`
+	// ExpiresAt is when the token stops being accepted, in Unix seconds.
+	ExpiresAt int64 `json:"expires_at"`
+	// MaxUploads is how many uploads the token allows.
+	MaxUploads int `json:"max_uploads"`
+}
+
+var guestTokenHandler = chain(
+	http.HandlerFunc(issueGuestToken),
+	withRequestID,
+	withRecovery,
+	withLogging,
+	withCORS,
+	withMethod(http.MethodPost),
+	withRateLimit,
+)
+
+// issueGuestToken hands a short-lived guest token to a page on a partner's
+// site, identified by the Origin header its browser sends, or 404 when guest
+// tokens are disabled.
+func issueGuestToken(w http.ResponseWriter, r *http.Request) {
+	config := guestConfigFromEnv()
+	if config == nil {
+		sendDomainError(w, withDetail(ErrNotEnabled, "Guest tokens are not enabled"))
+		return
+	}
+	origin := r.Header.Get("Origin")
+	partner, ok := config.partners[origin]
+	if origin == "" || !ok {
+		sendDomainError(w, ErrOriginNotAllowed)
+		return
+	}
+
+	now := clock.Now()
+	expires := now.Add(config.ttl)
+	token, err := signHS256(map[string]any{
+		"sub":     "guest:" + partner,
+		"aud":     guestTokenAudience,
+		"jti":     newRequestID(),
+		"iat":     now.Unix(),
+		"exp":     expires.Unix(),
+		"partner": partner,
+		"origin":  origin,
+		"uploads": config.maxUploads,
+	}, config.secret)
+	if err != nil {
+		sendDomainError(w, err)
+		return
+	}
+	log.Printf("Guest token issued for partner %s", partner)
+	sendJSON(w, http.StatusOK, GuestToken{
+		Token:      token,
+		Partner:    partner,
+		ExpiresAt:  expires.Unix(),
+		MaxUploads: config.maxUploads,
+	})
+}
+
+// signHS256 builds an HS256 JWT carrying claims.
+func signHS256(claims map[string]any, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) +
+		"." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// guestUse counts the uploads made with one guest token.
+type guestUse struct {
+	uploads int
+	expires time.Time
+}
+
+var (
+	guestMu      sync.Mutex
+	guestTracker = make(map[string]*guestUse)
+)
+
+// useGuestToken reports whether the token with the given ID may make another
+// upload and, if so, counts it. Like the rate limit, the count is kept per
+// instance.
+func useGuestToken(id string, limit int, expires time.Time) bool {
+	guestMu.Lock()
+	defer guestMu.Unlock()
+
+	now := clock.Now()
+	for key, use := range guestTracker {
+		if !now.Before(use.expires) {
+			delete(guestTracker, key)
+		}
+	}
+	use, ok := guestTracker[id]
+	if !ok {
+		use = &guestUse{expires: expires}
+		guestTracker[id] = use
+	}
+	if use.uploads >= limit {
+		return false
+	}
+	use.uploads++
+	return true
+}
+
+// withGuestToken admits uploads from embedded widgets that carry a guest
+// token in the X-Guest-Token header. The token must be unexpired, used from
+// the origin it was issued to and within its upload limit; the caller then
+// acts as a guest principal of the partner, and each upload is logged with
+// the partner and its size for usage attribution. Requests without the
+// header pass through. It must run before withAuth.
+func withGuestToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimSpace(r.Header.Get(guestTokenHeader))
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		config := guestConfigFromEnv()
+		if config == nil {
+			sendDomainError(w, fmt.Errorf("%w: guest tokens are not enabled", ErrUnauthenticated))
+			return
+		}
+
+		principal, err := verifyGuestToken(token, config.secret, r.Header.Get("Origin"))
+		if err != nil {
+			log.Printf("Guest token rejected: %v", err)
+			sendDomainError(w, err)
+			return
+		}
+		partner, _ := principal.Claims["partner"].(string)
+		id, _ := principal.Claims["jti"].(string)
+		limit, _ := principal.Claims["uploads"].(float64)
+		exp, _ := principal.Claims["exp"].(float64)
+		if !useGuestToken(id, int(limit), time.Unix(int64(exp), 0)) {
+			sendDomainError(w, ErrGuestTokenUsedUp)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		counter := &countingReader{ReadCloser: r.Body}
+		r.Body = counter
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+		log.Printf("Guest upload for partner %s: status %d, %d bytes", partner, rec.status, counter.n)
+	})
+}
+
+// verifyGuestToken checks a guest token and that it is used from the origin
+// it was issued to, and returns the guest principal it identifies.
+func verifyGuestToken(token string, secret []byte, origin string) (*Principal, error) {
+	claims, err := verifyHS256(token, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClaims(claims, "", guestTokenAudience, clock.Now()); err != nil {
+		return nil, err
+	}
+	if issued, _ := claims["origin"].(string); origin == "" || issued != origin {
+		return nil, fmt.Errorf("%w: guest token used from another origin", ErrUnauthenticated)
+	}
+
+	subject, _ := claims["sub"].(string)
+	return &Principal{Subject: subject, Claims: claims, guest: true}, nil
+}
@@ -0,0 +1,174 @@
+package followercount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const partnerOrigin = "https://partner.example"
+
+// withGuestTokens enables guest tokens for partnerOrigin with a fresh usage
+// tracker.
+func withGuestTokens(t *testing.T) {
+	t.Helper()
+	withEnv(t, "GUEST_TOKEN_SECRET", "guest-secret")
+	withEnv(t, "GUEST_PARTNERS", "partner="+partnerOrigin+"/, broken")
+	withEnv(t, "GUEST_TOKEN_MAX_UPLOADS", "2")
+	guestMu.Lock()
+	guestTracker = make(map[string]*guestUse)
+	guestMu.Unlock()
+}
+
+// requestGuestToken asks the guest-token endpoint for a token from origin.
+func requestGuestToken(t *testing.T, origin string) (*httptest.ResponseRecorder, GuestToken) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/guest-token", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, req)
+	var token GuestToken
+	json.NewDecoder(w.Body).Decode(&token)
+	return w, token
+}
+
+func TestParseGuestPartners(t *testing.T) {
+	silenceLogs(t)
+	partners := parseGuestPartners("acme=https://acme.example, acme=https://www.acme.example/,=https://x.example,nobody")
+	want := map[string]string{"https://acme.example": "acme", "https://www.acme.example": "acme"}
+	if len(partners) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, partners)
+	}
+	for origin, partner := range want {
+		if partners[origin] != partner {
+			t.Errorf("Expected %s to belong to %s, got %q", origin, partner, partners[origin])
+		}
+	}
+}
+
+func TestIssueGuestToken(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	withEnv(t, "GUEST_TOKEN_SECRET", "")
+	if w, _ := requestGuestToken(t, partnerOrigin); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 while guest tokens are disabled, got %d", w.Code)
+	}
+
+	withGuestTokens(t)
+	fake := withClock(t, time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	w, token := requestGuestToken(t, partnerOrigin)
+	if w.Code != http.StatusOK || token.Token == "" || token.Partner != "partner" || token.MaxUploads != 2 {
+		t.Fatalf("Expected a token for partner, got %d %+v", w.Code, token)
+	}
+	if want := fake.Now().Add(defaultGuestTokenTTL).Unix(); token.ExpiresAt != want {
+		t.Fatalf("Expected the token to expire at %d, got %d", want, token.ExpiresAt)
+	}
+
+	for _, origin := range []string{"", "https://evil.example"} {
+		req := httptest.NewRequest(http.MethodPost, "/guest-token", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, req)
+		var response APIResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		if w.Code != http.StatusForbidden || response.ErrorCode != "origin_not_allowed" {
+			t.Fatalf("Expected 403 origin_not_allowed for origin %q, got %d %q", origin, w.Code, response.ErrorCode)
+		}
+	}
+}
+
+func TestWithGuestToken(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+	withGuestTokens(t)
+	withEnv(t, "AUTH_MODE", "jwt")
+	withEnv(t, "AUTH_JWT_SECRET", "secret")
+	withEnv(t, "CAPTCHA_PROVIDER", "")
+	fake := withClock(t, time.Now())
+
+	_, token := requestGuestToken(t, partnerOrigin)
+	var seen *Principal
+	h := withGuestToken(withAuth(withPermission(PermUpload)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = principalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))))
+	upload := func(token, origin string) (int, string) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("zip")))
+		req.Header.Set(guestTokenHeader, token)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var response APIResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response.ErrorCode
+	}
+
+	if code, _ := upload(token.Token, partnerOrigin); code != http.StatusOK {
+		t.Fatalf("Expected the guest upload to pass authentication, got %d", code)
+	}
+	if seen == nil || seen.Subject != "guest:partner" || !seen.guest {
+		t.Fatalf("Expected a guest principal of partner, got %+v", seen)
+	}
+	if code, _ := upload(token.Token, "https://evil.example"); code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 for a token used from another origin, got %d", code)
+	}
+	if code, _ := upload(token.Token+"x", partnerOrigin); code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 for a forged token, got %d", code)
+	}
+	forged := signJWT(t, "secret", map[string]any{"sub": "guest:partner", "aud": guestTokenAudience, "exp": fake.Now().Add(time.Hour).Unix(), "origin": partnerOrigin})
+	if code, _ := upload(forged, partnerOrigin); code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 for a token signed with another secret, got %d", code)
+	}
+
+	if code, _ := upload(token.Token, partnerOrigin); code != http.StatusOK {
+		t.Fatalf("Expected the second upload to pass, got %d", code)
+	}
+	if code, errorCode := upload(token.Token, partnerOrigin); code != http.StatusTooManyRequests || errorCode != "guest_token_used_up" {
+		t.Fatalf("Expected 429 guest_token_used_up past the upload limit, got %d %q", code, errorCode)
+	}
+
+	_, fresh := requestGuestToken(t, partnerOrigin)
+	fake.Advance(defaultGuestTokenTTL)
+	if code, _ := upload(fresh.Token, partnerOrigin); code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 for an expired token, got %d", code)
+	}
+}
+
+func TestWithCaptcha_Guest(t *testing.T) {
+	silenceLogs(t)
+	withEnv(t, "CAPTCHA_PROVIDER", "turnstile")
+	withEnv(t, "CAPTCHA_SECRET", "s3cret")
+	withEnv(t, "RATE_LIMIT_EXEMPT_KEYS", "")
+	h := withCaptcha(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	guest := &Principal{Subject: "guest:partner", guest: true}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), principalKey{}, guest)))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected guests to still need a CAPTCHA, got %d", w.Code)
+	}
+}
+
+func TestPrincipalRoles_Guest(t *testing.T) {
+	withEnv(t, "AUTH_DEFAULT_ROLE", "")
+	guest := &Principal{Subject: "guest:partner", Claims: map[string]any{"roles": "owner"}, guest: true}
+	roles := principalRoles(guest)
+	if len(roles) != 1 || roles[0] != RoleGuest {
+		t.Fatalf("Expected guests to only be guests, got %v", roles)
+	}
+	if !hasPermission(roles, PermUpload) {
+		t.Fatal("Expected guests to upload")
+	}
+}
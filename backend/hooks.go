@@ -0,0 +1,82 @@
+package followercount
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// Hooks are callbacks that plugins compiled into a deployment, such as
+// company-specific storage or notifications, run while an upload is
+// processed. Any of them may be nil. Hooks run synchronously on the
+// request's goroutine in registration order, so slow work belongs in a
+// goroutine of the plugin's own.
+type Hooks struct {
+	// OnUploadValidated runs once an upload, including each export sent to
+	// /overlap and each one dropped into Cloud Storage, has passed the size,
+	// checksum and scanner checks and been opened, before it is analyzed.
+	// An error rejects the upload; return one of the Err values, wrapped as
+	// needed, to choose the response, since other errors are reported as
+	// internal errors.
+	OnUploadValidated func(ctx context.Context, export fs.FS) error
+	// OnParsed runs with the result of every successful analysis, including
+	// those of /plan and Cloud Storage exports.
+	OnParsed func(ctx context.Context, result *analysis.Result)
+	// OnResult runs with the response built from each result and may add
+	// to it. Options such as preview and section are applied afterwards.
+	OnResult func(ctx context.Context, response *APIResponse)
+}
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []Hooks
+)
+
+// RegisterHooks adds h to the hooks run on every upload. Deployments call it
+// from an init function in their own file.
+func RegisterHooks(h Hooks) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, h)
+}
+
+// registeredHooks returns the hooks registered so far.
+func registeredHooks() []Hooks {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return hooks
+}
+
+// runUploadValidated runs the OnUploadValidated hooks, stopping at the first
+// that rejects the upload.
+func runUploadValidated(ctx context.Context, export fs.FS) error {
+	for _, h := range registeredHooks() {
+		if h.OnUploadValidated == nil {
+			continue
+		}
+		if err := h.OnUploadValidated(ctx, export); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runParsed runs the OnParsed hooks.
+func runParsed(ctx context.Context, result *analysis.Result) {
+	for _, h := range registeredHooks() {
+		if h.OnParsed != nil {
+			h.OnParsed(ctx, result)
+		}
+	}
+}
+
+// runResult runs the OnResult hooks.
+func runResult(ctx context.Context, response *APIResponse) {
+	for _, h := range registeredHooks() {
+		if h.OnResult != nil {
+			h.OnResult(ctx, response)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package followercount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// withHooks registers h for the duration of the test.
+func withHooks(t *testing.T, h Hooks) {
+	t.Helper()
+	hooksMu.Lock()
+	previous := hooks
+	hooksMu.Unlock()
+	RegisterHooks(h)
+	t.Cleanup(func() {
+		hooksMu.Lock()
+		hooks = previous
+		hooksMu.Unlock()
+	})
+}
+
+func TestHooks(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	var calls []string
+	withHooks(t, Hooks{
+		OnUploadValidated: func(_ context.Context, export fs.FS) error {
+			calls = append(calls, "validated")
+			return nil
+		},
+		OnParsed: func(_ context.Context, result *analysis.Result) {
+			calls = append(calls, "parsed")
+		},
+		OnResult: func(_ context.Context, response *APIResponse) {
+			calls = append(calls, "result")
+			response.Message = "Stored by plugin"
+		},
+	})
+	// Hooks left nil are skipped.
+	withHooks(t, Hooks{})
+
+	data, err := GenerateExport(2, 4)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data)))
+
+	var response APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || response.Message != "Stored by plugin" {
+		t.Fatalf("Expected the OnResult hook to amend the response, got %d %q", w.Code, response.Message)
+	}
+	if want := []string{"validated", "parsed", "result"}; !slices.Equal(calls, want) {
+		t.Fatalf("Expected hooks %v, got %v", want, calls)
+	}
+}
+
+func TestHooks_RejectUpload(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	parsed := false
+	withHooks(t, Hooks{
+		OnUploadValidated: func(context.Context, fs.FS) error { return ErrForbidden },
+		OnParsed:          func(context.Context, *analysis.Result) { parsed = true },
+	})
+
+	data, err := GenerateExport(2, 4)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data)))
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected the hook's error to reject the upload with 403, got %d", w.Code)
+	}
+	if parsed {
+		t.Fatal("Expected a rejected upload not to be analyzed")
+	}
+}
+
+func TestHooks_OverlapAndStorage(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	validated := 0
+	withHooks(t, Hooks{
+		OnUploadValidated: func(context.Context, fs.FS) error {
+			validated++
+			return nil
+		},
+	})
+
+	data, err := GenerateExport(2, 4)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, overlapRequest(t, data, data))
+	if w.Code != http.StatusOK || validated != 2 {
+		t.Fatalf("Expected both overlap exports to be validated, got %d after %d", w.Code, validated)
+	}
+
+	if response := analyzeExportBytes(context.Background(), data); !response.Success || validated != 3 {
+		t.Fatalf("Expected the storage export to be validated, got %d", validated)
+	}
+}
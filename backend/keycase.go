@@ -0,0 +1,140 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// wantsCamelCase reports whether the client asked for camelCase response
+// keys, either with ?case=camel or with an Accept header such as
+// "application/json; case=camel".
+func wantsCamelCase(r *http.Request) bool {
+	if r.URL.Query().Get("case") == "camel" {
+		return true
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == "application/json" && params["case"] == "camel" {
+			return true
+		}
+	}
+	return false
+}
+
+// snakeToCamel converts a snake_case key to camelCase.
+func snakeToCamel(key string) string {
+	parts := strings.Split(key, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// dataKeyFields maps the JSON names of the map-valued response fields, such
+// as platforms or the receipt's parsers, to how many levels of object keys
+// under them are data rather than field names: 2 for the catalogue's hints,
+// keyed by locale and then by hint. It is derived from the response types so
+// new map fields can't be missed.
+var dataKeyFields = collectDataKeyFields(reflect.TypeOf(APIResponse{}), reflect.TypeOf(ErrorCatalogue{}))
+
+func collectDataKeyFields(roots ...reflect.Type) map[string]int {
+	s := &schemaTypes{fields: make(map[reflect.Type][]typeField)}
+	for _, root := range roots {
+		s.visit(root)
+	}
+	fields := make(map[string]int)
+	for _, t := range s.order {
+		for _, f := range s.fields[t] {
+			if depth := mapDepth(f.typ); depth > 0 {
+				fields[f.name] = depth
+			}
+		}
+	}
+	return fields
+}
+
+// mapDepth returns how many maps are nested directly in t.
+func mapDepth(t reflect.Type) int {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Map {
+		return 0
+	}
+	return 1 + mapDepth(t.Elem())
+}
+
+// camelizeKeys rewrites the field names in v, recursively. The keys of the
+// top dataLevels levels of objects are data, such as file names or platform
+// IDs, and are kept as they are.
+func camelizeKeys(v interface{}, dataLevels int) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if dataLevels > 0 {
+				out[key] = camelizeKeys(value, dataLevels-1)
+				continue
+			}
+			out[snakeToCamel(key)] = camelizeKeys(value, dataKeyFields[key])
+		}
+		return out
+	case []interface{}:
+		for i := range v {
+			v[i] = camelizeKeys(v[i], 0)
+		}
+		return v
+	}
+	return v
+}
+
+// bufferedResponse holds a response until the handler has finished so it can
+// be rewritten.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) WriteHeader(code int) { b.status = code }
+
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+// withKeyCase rewrites JSON response keys to camelCase for clients that ask
+// for it, so they don't need their own translation layer. The response
+// structs keep a single set of snake_case tags.
+func withKeyCase(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !wantsCamelCase(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+
+		body := buf.body.Bytes()
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			dec := json.NewDecoder(bytes.NewReader(body))
+			dec.UseNumber()
+			var v interface{}
+			if err := dec.Decode(&v); err == nil {
+				out := new(bytes.Buffer)
+				if err := json.NewEncoder(out).Encode(camelizeKeys(v, 0)); err == nil {
+					body = out.Bytes()
+				}
+			}
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(buf.status)
+		w.Write(body)
+	})
+}
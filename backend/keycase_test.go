@@ -0,0 +1,108 @@
+package followercount
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+func TestSnakeToCamel(t *testing.T) {
+	tests := map[string]string{
+		"success":             "success",
+		"non_followers":       "nonFollowers",
+		"primary_followed_at": "primaryFollowedAt",
+	}
+	for in, want := range tests {
+		if got := snakeToCamel(in); got != want {
+			t.Errorf("snakeToCamel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWithKeyCase(t *testing.T) {
+	h := withKeyCase(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, http.StatusCreated, APIResponse{
+			Success:      true,
+			NonFollowers: []analysis.NonFollower{{Username: "user1", ProfileURL: "https://instagram.com/user1", FollowedAt: 1234567890}},
+		})
+	}))
+
+	for _, tt := range []struct {
+		name   string
+		url    string
+		accept string
+	}{
+		{"query", "/?case=camel", ""},
+		{"accept", "/", "application/json; case=camel"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status to be preserved, got %d", w.Code)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			entries, ok := body["nonFollowers"].([]interface{})
+			if !ok || len(entries) != 1 {
+				t.Fatalf("Expected nonFollowers key, got %s", w.Body.String())
+			}
+			entry := entries[0].(map[string]interface{})
+			if entry["profileUrl"] != "https://instagram.com/user1" || entry["followedAt"] != float64(1234567890) {
+				t.Fatalf("Expected camelCase entry keys, got %v", entry)
+			}
+		})
+	}
+}
+
+func TestWithKeyCase_KeepsMapKeys(t *testing.T) {
+	h := withKeyCase(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, http.StatusOK, APIResponse{
+			Success:           true,
+			ProcessingReceipt: &analysis.ProcessingReceipt{Parsers: map[string]string{"pending_requests": "1"}},
+			Platforms: map[string]*analysis.Result{
+				"threads_app": {TotalFollowing: 2},
+			},
+		})
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/?case=camel", nil))
+
+	var body struct {
+		ProcessingReceipt struct {
+			Parsers map[string]string `json:"parsers"`
+		} `json:"processingReceipt"`
+		Platforms map[string]map[string]any `json:"platforms"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if body.ProcessingReceipt.Parsers["pending_requests"] != "1" {
+		t.Fatalf("Expected the parsers keys kept, got %s", w.Body.String())
+	}
+	if body.Platforms["threads_app"]["totalFollowing"] != float64(2) {
+		t.Fatalf("Expected the platform ID kept and its result camelized, got %s", w.Body.String())
+	}
+}
+
+func TestCollectDataKeyFields(t *testing.T) {
+	for name, want := range map[string]int{"platforms": 1, "parsers": 1, "hints": 2} {
+		if got := dataKeyFields[name]; got != want {
+			t.Errorf("Expected %s to have %d levels of data keys, got %d", name, want, got)
+		}
+	}
+	if dataKeyFields["non_followers"] != 0 {
+		t.Error("Expected list fields to have field names")
+	}
+}
@@ -0,0 +1,27 @@
+// Load test for the analyze endpoint.
+//
+//   go run ./cmd/genexport -followers 10000 -following 10000 -out /tmp/export.zip
+//   k6 run -e EXPORT_ZIP=/tmp/export.zip -e TARGET=http://localhost:8080/ loadtest/k6.js
+//
+// The local rate limiter allows 10 uploads per IP every 5 minutes, so point
+// this at a deployment with the limit raised or exempted.
+import http from "k6/http";
+import { check } from "k6";
+
+const body = open(__ENV.EXPORT_ZIP, "b");
+
+export const options = {
+  vus: Number(__ENV.VUS || 5),
+  duration: __ENV.DURATION || "30s",
+  thresholds: {
+    http_req_failed: ["rate<0.01"],
+    http_req_duration: ["p(95)<2000"],
+  },
+};
+
+export default function () {
+  const res = http.post(__ENV.TARGET || "http://localhost:8080/", body, {
+    headers: { "Content-Type": "application/zip" },
+  });
+  check(res, { "status is 200": (r) => r.status === 200 });
+}
@@ -0,0 +1,96 @@
+package followercount
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Identifiers and fields that hold export contents or usernames, which must
+// never be passed to the standard logger.
+var (
+	unloggableIdents = map[string]bool{"content": true, "body": true, "bodyBytes": true, "upload": true, "raw": true}
+	unloggableFields = map[string]bool{"Username": true, "DisplayName": true, "Title": true, "Href": true, "StringListData": true, "NonFollowers": true}
+)
+
+// TestNoUserDataInLogs parses every non-test source file of the backend and
+// fails on log calls whose arguments include export contents, usernames or
+// raw bytes converted to a string. Verbose parsing logs belong behind
+// analysis.DebugLogs (debugf), which this check doesn't cover.
+func TestNoUserDataInLogs(t *testing.T) {
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "testdata" || d.Name() == "loadtest") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || !isLogCall(call) {
+				return true
+			}
+			for _, arg := range call.Args {
+				if what := unloggable(arg); what != "" {
+					t.Errorf("%s: log call passes %s", fset.Position(arg.Pos()), what)
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// isLogCall reports whether call is log.Print*, log.Fatal* or log.Panic*.
+func isLogCall(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || pkg.Name != "log" {
+		return false
+	}
+	name := sel.Sel.Name
+	return strings.HasPrefix(name, "Print") || strings.HasPrefix(name, "Fatal") || strings.HasPrefix(name, "Panic")
+}
+
+// unloggable describes the user data expr refers to, or returns an empty
+// string when it has none.
+func unloggable(expr ast.Expr) string {
+	var found string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if unloggableIdents[n.Name] {
+				found = n.Name
+			}
+		case *ast.SelectorExpr:
+			if unloggableFields[n.Sel.Name] {
+				found = "the " + n.Sel.Name + " field"
+			}
+		case *ast.CallExpr:
+			if fn, ok := n.Fun.(*ast.Ident); ok && fn.Name == "string" {
+				found = "a string conversion"
+			}
+		}
+		return found == ""
+	})
+	return found
+}
@@ -0,0 +1,47 @@
+package followercount
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+var manifestHandler = uploadHandler("manifest", exportManifest)
+
+// exportManifest lists the files of an export and the optional analyzers
+// they allow, without analyzing it. Clients then upload the export again
+// with analyzers= set to the ones the user wants; uploads are never kept
+// between requests, so there is nothing to analyze it from otherwise.
+func exportManifest(w http.ResponseWriter, r *http.Request) {
+	fsys, err := readUpload(r)
+	if err != nil {
+		sendDomainError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, APIResponse{
+		Success:  true,
+		Manifest: analysis.BuildManifest(fsys),
+		Message:  "Manifest ready",
+	})
+}
+
+// selectedAnalyzers parses the analyzers option, a comma-separated list of
+// optional analyzers to run. It returns nil, meaning all of them, when the
+// option is absent.
+func selectedAnalyzers(r *http.Request) ([]string, error) {
+	if !r.URL.Query().Has("analyzers") {
+		return nil, nil
+	}
+	names := []string{}
+	for _, name := range strings.Split(r.URL.Query().Get("analyzers"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if err := analysis.ValidateAnalyzers(names); err != nil {
+		return nil, withDetail(ErrInvalidOption, "%v", err)
+	}
+	return names, nil
+}
@@ -0,0 +1,66 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnalyzeFollowers_Manifest(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	data, err := GenerateExport(2, 4)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/manifest", bytes.NewReader(data))
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Manifest == nil || !response.Manifest.Analyzable || len(response.Manifest.Files) == 0 {
+		t.Fatalf("Expected a manifest of an analyzable export, got %+v", response.Manifest)
+	}
+	if response.NonFollowers != nil || response.Stats != nil {
+		t.Fatal("Expected the manifest not to analyze the export")
+	}
+}
+
+func TestAnalyzeFollowers_SelectedAnalyzers(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	data, err := GenerateExport(2, 4)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/?analyzers=stats", bytes.NewReader(data))
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, req)
+
+	var response APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || response.Stats == nil || response.Quality != nil {
+		t.Fatalf("Expected only stats, got %d %+v", w.Code, response)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/?analyzers=stats,horoscope", bytes.NewReader(data))
+	w = httptest.NewRecorder()
+	AnalyzeFollowers(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an unknown analyzer, got %d", w.Code)
+	}
+}
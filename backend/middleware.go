@@ -0,0 +1,176 @@
+package followercount
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps an http.Handler with a cross-cutting concern such as CORS
+// or rate limiting.
+type Middleware func(http.Handler) http.Handler
+
+// chain wraps h with the given middlewares. The first middleware is the
+// outermost one, so it sees the request first and the response last.
+func chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// uploadHandler wraps an endpoint that accepts an export upload with the
+// middleware stack shared by all such endpoints. name identifies the endpoint
+// in telemetry.
+func uploadHandler(name string, h http.HandlerFunc) http.Handler {
+	return chain(h,
+		withRequestID,
+		withRecovery,
+		withBudget,
+		withLogging,
+		withTelemetry(name),
+		withSignature,
+		withKeyCase,
+		withCORS,
+		withMethod(http.MethodPost),
+		withRateLimit,
+		withBodyLimit(maxUploadSize),
+		withOriginQuota,
+		withGuestToken,
+		withAuth,
+		withPermission(PermUpload),
+		withCaptcha,
+	)
+}
+
+// statusRecorder captures the status code written by the wrapped handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// streaming handlers can still flush.
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+func withLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start))
+	})
+}
+
+type requestIDKey struct{}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestIDFromContext returns the ID assigned by withRequestID, or an empty
+// string when the request did not pass through it.
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// withRequestID tags every request with a random ID that is echoed in the
+// X-Request-ID header so users can quote it when reporting a failure.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := newRequestID()
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// withRecovery turns a panic anywhere further down the chain into a 500
+// response. Chains place it right after withRequestID so that it also
+// covers the other middleware, including those that buffer the response.
+// Only the panic type and stack are logged, and reported when crash
+// reporting is on: the panic value may carry usernames or file contents
+// from the upload.
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				id := requestIDFromContext(r.Context())
+				log.Printf("panic recovered (request %s): %T\n%s", id, rec, debug.Stack())
+				reportPanic(r, rec)
+				sendJSON(w, http.StatusInternalServerError, APIResponse{
+					Success:   false,
+					Error:     "Internal error while processing the upload",
+					ErrorCode: internalError.code,
+					RequestID: id,
+				})
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withCORS sets the CORS headers on every response and answers preflight
+// requests without invoking the rest of the chain.
+func withCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w, r)
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func withMethod(method string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				sendDomainError(w, ErrMethodNotAllowed)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// withRateLimit rejects clients over their request limit and records the
+// load of the requests it lets through for the adaptive limit.
+func withRateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isRateLimitExempt(r) && !checkRateLimit(getClientIP(r)) {
+			sendDomainError(w, ErrRateLimited)
+			return
+		}
+		done := load.begin()
+		defer done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withBodyLimit caps the request body at limit bytes. Requests that declare
+// a larger Content-Length are rejected before any of the body is read.
+func withBodyLimit(limit int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				sendDomainError(w, ErrUploadTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package followercount
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetRateLimiter() {
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+	requestTracker = make(map[string][]time.Time)
+}
+
+func TestChain_Order(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	h := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), mark("first"), mark("second"))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := strings.Join(order, ","); got != "first,second,handler" {
+		t.Fatalf("Expected first,second,handler, got %s", got)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+
+	h := withRateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < maxRequests; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status 200, got %d", i+1, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+}
+
+func TestWithBodyLimit(t *testing.T) {
+	var readErr error
+	h := withBodyLimit(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	// Without a declared length the limit is enforced while reading.
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("too long"))
+	req.ContentLength = -1
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if readErr == nil {
+		t.Fatal("Expected an error reading a body over the limit")
+	}
+}
+
+func TestWithBodyLimit_ContentLength(t *testing.T) {
+	called := false
+	h := withBodyLimit(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("too long"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if called || w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected an early 413, got %d (handler called: %v)", w.Code, called)
+	}
+}
+
+func TestWithRecovery(t *testing.T) {
+	h := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("secret_username")
+	}), withRequestID, withRecovery)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", w.Code)
+	}
+
+	var apiResponse APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&apiResponse); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if apiResponse.ErrorCode != "internal_error" {
+		t.Fatalf("Expected error_code internal_error, got %q", apiResponse.ErrorCode)
+	}
+
+	if apiResponse.RequestID == "" || apiResponse.RequestID != w.Header().Get("X-Request-ID") {
+		t.Fatalf("Expected request ID %q in body, got %q", w.Header().Get("X-Request-ID"), apiResponse.RequestID)
+	}
+
+	if strings.Contains(apiResponse.Error, "secret_username") {
+		t.Fatal("Panic value leaked into the response")
+	}
+}
+
+func TestUploadHandler_RecoversBeforeBuffering(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	h := uploadHandler("test", func(w http.ResponseWriter, r *http.Request) {
+		panic("secret_username")
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/?case=camel", nil))
+
+	var response map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	// The buffered camelCase body is dropped for the recovery's own.
+	if w.Code != http.StatusInternalServerError || response["error_code"] != "internal_error" {
+		t.Fatalf("Expected a 500 internal_error from withRecovery, got %d %v", w.Code, response)
+	}
+}
+
+func TestWithRateLimit_Exemptions(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	withEnv(t, "RATE_LIMIT_EXEMPT_CIDRS", "10.0.0.0/8, bogus")
+	withEnv(t, "RATE_LIMIT_EXEMPT_KEYS", "probe-key")
+	withEnv(t, "TRUSTED_PROXY_CIDRS", "192.0.2.0/24")
+
+	h := withRateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		apiKey     string
+		want       int
+	}{
+		{"exempt network", "10.1.2.3:1234", "", "", http.StatusOK},
+		{"exempt network behind trusted proxy", "192.0.2.1:1234", "198.51.100.1, 10.1.2.3", "", http.StatusOK},
+		{"spoofed forwarded header", "198.51.100.7:1234", "10.1.2.3", "", http.StatusTooManyRequests},
+		{"spoofed hop behind trusted proxy", "192.0.2.1:1234", "10.1.2.3, 198.51.100.8", "", http.StatusTooManyRequests},
+		{"exempt key", "192.0.2.1:1234", "", "probe-key", http.StatusOK},
+		{"wrong key", "192.0.2.1:1234", "", "other", http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i <= maxRequests; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/", nil)
+				req.RemoteAddr = tt.remoteAddr
+				if tt.forwarded != "" {
+					req.Header.Set("X-Forwarded-For", tt.forwarded)
+				}
+				if tt.apiKey != "" {
+					req.Header.Set(apiKeyHeader, tt.apiKey)
+				}
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, req)
+				if i == maxRequests && w.Code != tt.want {
+					t.Fatalf("Expected status %d after %d requests, got %d", tt.want, maxRequests+1, w.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckRateLimit_SlidingWindow(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	fake := withClock(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	// Spread the budget over the first minute of the window.
+	for i := 0; i < maxRequests; i++ {
+		if !checkRateLimit("192.0.2.1") {
+			t.Fatalf("Request %d: expected to be allowed", i+1)
+		}
+		fake.Advance(time.Minute / time.Duration(maxRequests))
+	}
+	elapsed := time.Minute
+
+	fake.Advance(windowDuration - elapsed - time.Nanosecond)
+	if checkRateLimit("192.0.2.1") {
+		t.Fatal("Expected the first request to still count just before the window ends")
+	}
+
+	// Exactly one window after the first request it no longer counts, so
+	// exactly one slot opens up.
+	fake.Advance(time.Nanosecond)
+	if !checkRateLimit("192.0.2.1") {
+		t.Fatal("Expected a slot to open once the first request left the window")
+	}
+	if checkRateLimit("192.0.2.1") {
+		t.Fatal("Expected only one slot to open")
+	}
+
+	if !checkRateLimit("192.0.2.2") {
+		t.Fatal("Expected other clients to have their own budget")
+	}
+}
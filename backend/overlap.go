@@ -0,0 +1,150 @@
+package followercount
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// SharedFollower is an account that follows both analyzed accounts.
+type SharedFollower struct {
+	Username            string `json:"username"`
+	ProfileURL          string `json:"profile_url"`
+	PrimaryFollowedAt   int64  `json:"primary_followed_at,omitempty"`
+	SecondaryFollowedAt int64  `json:"secondary_followed_at,omitempty"`
+	// FollowedFirst is "primary" or "secondary", or empty when the export
+	// timestamps don't tell the two apart.
+	FollowedFirst string `json:"followed_first,omitempty"`
+}
+
+// OverlapReport describes the audience overlap between two accounts.
+type OverlapReport struct {
+	SharedFollowers    []SharedFollower `json:"shared_followers"`
+	PrimaryOnly        []string         `json:"primary_only"`
+	SecondaryOnly      []string         `json:"secondary_only"`
+	PrimaryFollowers   int              `json:"primary_followers"`
+	SecondaryFollowers int              `json:"secondary_followers"`
+}
+
+var overlapHandler = uploadHandler("overlap", analyzeOverlap)
+
+// computeOverlap compares the follower sets of two accounts. Both lists of
+// exclusive followers are sorted so responses are stable.
+func computeOverlap(primary, secondary map[string]int64) *OverlapReport {
+	report := &OverlapReport{
+		SharedFollowers:    []SharedFollower{},
+		PrimaryOnly:        []string{},
+		SecondaryOnly:      []string{},
+		PrimaryFollowers:   len(primary),
+		SecondaryFollowers: len(secondary),
+	}
+
+	for username, primaryAt := range primary {
+		secondaryAt, shared := secondary[username]
+		if !shared {
+			report.PrimaryOnly = append(report.PrimaryOnly, username)
+			continue
+		}
+
+		entry := SharedFollower{
+			Username:            username,
+			ProfileURL:          fmt.Sprintf("https://instagram.com/%s", username),
+			PrimaryFollowedAt:   primaryAt,
+			SecondaryFollowedAt: secondaryAt,
+		}
+		if primaryAt != 0 && secondaryAt != 0 && primaryAt != secondaryAt {
+			if primaryAt < secondaryAt {
+				entry.FollowedFirst = "primary"
+			} else {
+				entry.FollowedFirst = "secondary"
+			}
+		}
+		report.SharedFollowers = append(report.SharedFollowers, entry)
+	}
+
+	for username := range secondary {
+		if _, shared := primary[username]; !shared {
+			report.SecondaryOnly = append(report.SecondaryOnly, username)
+		}
+	}
+
+	sort.Slice(report.SharedFollowers, func(i, j int) bool {
+		return report.SharedFollowers[i].Username < report.SharedFollowers[j].Username
+	})
+	sort.Strings(report.PrimaryOnly)
+	sort.Strings(report.SecondaryOnly)
+
+	return report
+}
+
+// readFormZip reads the named multipart file field as a ZIP archive, after
+// the upload scanners have checked it. An encrypted archive is opened with
+// the zip_password form field, or the X-Zip-Password header.
+func readFormZip(r *http.Request, field string) (fs.FS, error) {
+	file, _, err := r.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadBody, err)
+	}
+	password := r.FormValue("zip_password")
+	if password == "" {
+		password = r.Header.Get(zipPasswordHeader)
+	}
+	return openUpload(r.Context(), data, password, false)
+}
+
+// analyzeOverlap expects a multipart form with the two exports in the
+// "primary" and "secondary" file fields.
+func analyzeOverlap(w http.ResponseWriter, r *http.Request) {
+	// Keep the whole form in memory; uploads must never touch the disk.
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		sendDomainError(w, ErrInvalidForm)
+		return
+	}
+
+	report := &analysis.Report{}
+	followerSets := make([]map[string]int64, 0, 2)
+	for _, field := range []string{"primary", "secondary"} {
+		fsys, err := readFormZip(r, field)
+		if errors.Is(err, http.ErrMissingFile) {
+			sendDomainError(w, withDetail(ErrInvalidForm, "Missing the %s export.", field))
+			return
+		}
+		if errors.Is(err, ErrReadBody) || errors.Is(err, ErrNotZip) || errors.Is(err, ErrCorruptZip) {
+			log.Printf("Error reading %s export: %v", field, err)
+			sendDomainError(w, withDetail(err, "Failed to read the %s export. Please upload a valid ZIP file.", field))
+			return
+		}
+		if err != nil {
+			// Passwords, scanner verdicts and hook rejections.
+			sendDomainError(w, err)
+			return
+		}
+
+		followers := analysis.ReadFollowers(fsys, report)
+		if len(followers) == 0 {
+			sendDomainError(w, withDetail(ErrNoFollowers, "No followers data found in the %s export.", field))
+			return
+		}
+		followerSets = append(followerSets, followers)
+	}
+
+	sendJSON(w, http.StatusOK, APIResponse{
+		Success:  true,
+		Overlap:  computeOverlap(followerSets[0], followerSets[1]),
+		Warnings: report.Warnings,
+		Partial:  report.Partial,
+		Message:  "Overlap analysis complete",
+	})
+}
@@ -0,0 +1,105 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestComputeOverlap(t *testing.T) {
+	primary := map[string]int64{"alice": 100, "bob": 300, "carol": 50}
+	secondary := map[string]int64{"alice": 200, "bob": 250, "dave": 10}
+
+	report := computeOverlap(primary, secondary)
+
+	if len(report.SharedFollowers) != 2 {
+		t.Fatalf("Expected 2 shared followers, got %d", len(report.SharedFollowers))
+	}
+	if report.SharedFollowers[0].Username != "alice" || report.SharedFollowers[0].FollowedFirst != "primary" {
+		t.Errorf("Expected alice to follow primary first, got %+v", report.SharedFollowers[0])
+	}
+	if report.SharedFollowers[1].Username != "bob" || report.SharedFollowers[1].FollowedFirst != "secondary" {
+		t.Errorf("Expected bob to follow secondary first, got %+v", report.SharedFollowers[1])
+	}
+	if len(report.PrimaryOnly) != 1 || report.PrimaryOnly[0] != "carol" {
+		t.Errorf("Expected primary_only [carol], got %v", report.PrimaryOnly)
+	}
+	if len(report.SecondaryOnly) != 1 || report.SecondaryOnly[0] != "dave" {
+		t.Errorf("Expected secondary_only [dave], got %v", report.SecondaryOnly)
+	}
+}
+
+// overlapRequest builds an /overlap request comparing the two exports.
+func overlapRequest(t *testing.T, primary, secondary []byte) *http.Request {
+	t.Helper()
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	for field, data := range map[string][]byte{"primary": primary, "secondary": secondary} {
+		part, err := mw.CreateFormFile(field, field+".zip")
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		part.Write(data)
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/overlap", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestAnalyzeFollowers_Overlap(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+
+	primaryZip := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.json": `[
+			{"string_list_data": [{"value": "alice", "timestamp": 100}]},
+			{"string_list_data": [{"value": "carol", "timestamp": 101}]}
+		]`,
+	})
+	secondaryZip := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.json": `[
+			{"string_list_data": [{"value": "alice", "timestamp": 200}]}
+		]`,
+	})
+
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, overlapRequest(t, primaryZip, secondaryZip))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var apiResponse APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&apiResponse); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if apiResponse.Overlap == nil || len(apiResponse.Overlap.SharedFollowers) != 1 {
+		t.Fatalf("Expected 1 shared follower, got %+v", apiResponse.Overlap)
+	}
+}
+
+func TestAnalyzeFollowers_OverlapScanned(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+	withScanner(t, stubScanner{result: ScanResult{Detail: "EICAR"}})
+
+	data, err := GenerateExport(2, 4)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, overlapRequest(t, data, data))
+
+	var response APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusUnprocessableEntity || response.ErrorCode != "upload_rejected" {
+		t.Fatalf("Expected the scanners to reject the exports, got %d %q", w.Code, response.ErrorCode)
+	}
+}
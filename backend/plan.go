@@ -0,0 +1,238 @@
+package followercount
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// defaultUnfollowsPerDay keeps a cleanup comfortably below the action limits
+// Instagram enforces on accounts that unfollow in bulk. Operators can adjust
+// it with SAFE_UNFOLLOWS_PER_DAY when Instagram's limits change.
+const defaultUnfollowsPerDay = 50
+
+const maxUnfollowsPerDay = 200
+
+// safeUnfollowsPerDay reads SAFE_UNFOLLOWS_PER_DAY, the number of unfollows
+// a day considered safe from Instagram's action limits, which is the
+// default batch size of cleanup plans and the daily recommendation sent
+// with every analysis.
+func safeUnfollowsPerDay() int {
+	if n, err := strconv.Atoi(getEnv("SAFE_UNFOLLOWS_PER_DAY")); err == nil && n >= 1 && n <= maxUnfollowsPerDay {
+		return n
+	}
+	return defaultUnfollowsPerDay
+}
+
+// unfollowAdvice returns how many of count non-followers to unfollow a day
+// and, when that takes more than a day, a warning explaining why, so clients
+// don't have to hard-code Instagram's limits.
+func unfollowAdvice(count int) (perDay int, warning string) {
+	safe := safeUnfollowsPerDay()
+	if count <= safe {
+		return count, ""
+	}
+	days := (count + safe - 1) / safe
+	return safe, fmt.Sprintf("Unfollowing %d accounts at once may trigger Instagram's action limits and temporarily block your account. Unfollow at most %d a day; this takes %d days.", count, safe, days)
+}
+
+// Cleanup priorities, from most to least overdue for a follow-back.
+const (
+	priorityHigh    = "high"
+	priorityMedium  = "medium"
+	priorityLow     = "low"
+	priorityUnknown = "unknown"
+)
+
+// PlanDay is one day's batch of unfollows.
+type PlanDay struct {
+	Date      string   `json:"date"`
+	Priority  string   `json:"priority"`
+	Usernames []string `json:"usernames"`
+}
+
+// CleanupPlan spreads the non-followers over several days.
+type CleanupPlan struct {
+	PerDay     int       `json:"per_day"`
+	TotalDays  int       `json:"total_days"`
+	TotalCount int       `json:"total_count"`
+	Days       []PlanDay `json:"days"`
+}
+
+var planHandler = uploadHandler("plan", cleanupPlan)
+
+// cleanupPriority ranks a non-follower by how long they have had to follow
+// back: accounts followed more than two years ago come first.
+func cleanupPriority(followedAt int64, now time.Time) string {
+	if followedAt == 0 {
+		return priorityUnknown
+	}
+	followed := time.Unix(followedAt, 0)
+	switch {
+	case followed.Before(now.AddDate(-2, 0, 0)):
+		return priorityHigh
+	case followed.Before(now.AddDate(0, -6, 0)):
+		return priorityMedium
+	default:
+		return priorityLow
+	}
+}
+
+// buildCleanupPlan orders non-followers by priority, oldest follow first,
+// and splits them into daily batches of at most perDay starting on start.
+// A day never mixes priorities, so each batch can be reviewed as a group.
+func buildCleanupPlan(nonFollowers []analysis.NonFollower, perDay int, start, now time.Time) *CleanupPlan {
+	rank := map[string]int{priorityHigh: 0, priorityMedium: 1, priorityLow: 2, priorityUnknown: 3}
+
+	sorted := make([]analysis.NonFollower, len(nonFollowers))
+	copy(sorted, nonFollowers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := rank[cleanupPriority(sorted[i].FollowedAt, now)], rank[cleanupPriority(sorted[j].FollowedAt, now)]
+		if pi != pj {
+			return pi < pj
+		}
+		return sorted[i].FollowedAt < sorted[j].FollowedAt
+	})
+
+	plan := &CleanupPlan{PerDay: perDay, TotalCount: len(sorted), Days: []PlanDay{}}
+	var current *PlanDay
+	for _, nf := range sorted {
+		priority := cleanupPriority(nf.FollowedAt, now)
+		if current == nil || current.Priority != priority || len(current.Usernames) >= perDay {
+			plan.Days = append(plan.Days, PlanDay{
+				Date:     start.AddDate(0, 0, len(plan.Days)).Format("2006-01-02"),
+				Priority: priority,
+			})
+			current = &plan.Days[len(plan.Days)-1]
+		}
+		current.Usernames = append(current.Usernames, nf.Username)
+	}
+	plan.TotalDays = len(plan.Days)
+
+	return plan
+}
+
+// icsEscape escapes text for use in an iCalendar property value.
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsFold folds a content line at 75 octets as required by RFC 5545. The
+// leading space of each continuation line counts towards its 75 octets.
+func icsFold(line string) string {
+	var b strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		// Don't split a multi-byte UTF-8 sequence.
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+// renderICS renders the plan as an iCalendar file with one all-day event per
+// batch, so users can import it and get daily reminders.
+func renderICS(plan *CleanupPlan, stamp time.Time) string {
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(icsFold(line))
+		b.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Follower-Watch//Cleanup Plan//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	for i, day := range plan.Days {
+		date, _ := time.Parse("2006-01-02", day.Date)
+		writeLine("BEGIN:VEVENT")
+		writeLine(fmt.Sprintf("UID:cleanup-%s-%d@follower-watch", day.Date, i))
+		writeLine("DTSTAMP:" + stamp.UTC().Format("20060102T150405Z"))
+		writeLine("DTSTART;VALUE=DATE:" + date.Format("20060102"))
+		writeLine("DTEND;VALUE=DATE:" + date.AddDate(0, 0, 1).Format("20060102"))
+		writeLine("SUMMARY:" + icsEscape(fmt.Sprintf("Unfollow %d accounts (%s priority)", len(day.Usernames), day.Priority)))
+		writeLine("DESCRIPTION:" + icsEscape(strings.Join(day.Usernames, "\n")))
+		writeLine("END:VEVENT")
+	}
+	writeLine("END:VCALENDAR")
+
+	return b.String()
+}
+
+// cleanupPlan analyzes an export and returns the non-followers as a staged
+// unfollow plan. Query parameters: per_day (default SAFE_UNFOLLOWS_PER_DAY,
+// or 50), start (YYYY-MM-DD, default tomorrow) and format (json or ics).
+func cleanupPlan(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	now := clock.Now()
+
+	perDay := safeUnfollowsPerDay()
+	if v := query.Get("per_day"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxUnfollowsPerDay {
+			sendDomainError(w, withDetail(ErrInvalidOption, "per_day must be between 1 and %d", maxUnfollowsPerDay))
+			return
+		}
+		perDay = n
+	}
+
+	start := now.AddDate(0, 0, 1)
+	if v := query.Get("start"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			sendDomainError(w, withDetail(ErrInvalidOption, "start must be a date in YYYY-MM-DD format"))
+			return
+		}
+		start = parsed
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "ics" {
+		sendDomainError(w, withDetail(ErrInvalidOption, "format must be json or ics"))
+		return
+	}
+
+	fsys, err := readUpload(r)
+	if err != nil {
+		sendDomainError(w, err)
+		return
+	}
+
+	response, err := analyzeArchive(r.Context(), fsys)
+	if err != nil {
+		sendDomainError(w, err)
+		return
+	}
+
+	plan := buildCleanupPlan(response.NonFollowers, perDay, start, now)
+
+	if format == "ics" {
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="cleanup-plan.ics"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(renderICS(plan, now)))
+		return
+	}
+
+	sendJSON(w, http.StatusOK, APIResponse{
+		Success:        true,
+		TotalFollowing: response.TotalFollowing,
+		TotalFollowers: response.TotalFollowers,
+		Count:          response.Count,
+		Plan:           plan,
+		Message:        "Cleanup plan ready",
+		Budget:         debugBudget(r),
+	})
+}
@@ -0,0 +1,132 @@
+package followercount
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+func TestBuildCleanupPlan(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	nonFollowers := []analysis.NonFollower{
+		{Username: "recent", FollowedAt: now.AddDate(0, -1, 0).Unix()},
+		{Username: "old2", FollowedAt: now.AddDate(-3, 0, 0).Unix()},
+		{Username: "old1", FollowedAt: now.AddDate(-4, 0, 0).Unix()},
+		{Username: "old3", FollowedAt: now.AddDate(-2, -1, 0).Unix()},
+		{Username: "mid", FollowedAt: now.AddDate(-1, 0, 0).Unix()},
+		{Username: "nodate"},
+	}
+
+	plan := buildCleanupPlan(nonFollowers, 2, start, now)
+
+	want := []PlanDay{
+		{Date: "2024-06-02", Priority: priorityHigh, Usernames: []string{"old1", "old2"}},
+		{Date: "2024-06-03", Priority: priorityHigh, Usernames: []string{"old3"}},
+		{Date: "2024-06-04", Priority: priorityMedium, Usernames: []string{"mid"}},
+		{Date: "2024-06-05", Priority: priorityLow, Usernames: []string{"recent"}},
+		{Date: "2024-06-06", Priority: priorityUnknown, Usernames: []string{"nodate"}},
+	}
+	if plan.TotalDays != len(want) || plan.TotalCount != 6 {
+		t.Fatalf("Expected %d days and 6 accounts, got %d and %d", len(want), plan.TotalDays, plan.TotalCount)
+	}
+	for i, day := range plan.Days {
+		if day.Date != want[i].Date || day.Priority != want[i].Priority || strings.Join(day.Usernames, ",") != strings.Join(want[i].Usernames, ",") {
+			t.Errorf("Day %d: expected %+v, got %+v", i, want[i], day)
+		}
+	}
+}
+
+func TestRenderICS(t *testing.T) {
+	plan := &CleanupPlan{Days: []PlanDay{{Date: "2024-06-02", Priority: priorityHigh, Usernames: []string{"a,b", strings.Repeat("x", 80)}}}}
+
+	ics := renderICS(plan, time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	for _, want := range []string{"BEGIN:VCALENDAR\r\n", "DTSTART;VALUE=DATE:20240602\r\n", "DTEND;VALUE=DATE:20240603\r\n", `a\,b\n`, "END:VCALENDAR\r\n"} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("Expected ICS to contain %q", want)
+		}
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Line longer than 75 octets: %q", line)
+		}
+	}
+}
+
+func TestICSFold(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("Unfollow café ☕ ", 20)
+
+	folded := icsFold(line)
+
+	lines := strings.Split(folded, "\r\n")
+	if len(lines) < 3 {
+		t.Fatalf("Expected a long SUMMARY to fold over several lines, got %d", len(lines))
+	}
+	for i, l := range lines {
+		if len(l) > 75 {
+			t.Errorf("Line %d is %d octets, over 75: %q", i, len(l), l)
+		}
+		if !utf8.ValidString(l) {
+			t.Errorf("Line %d splits a UTF-8 sequence: %q", i, l)
+		}
+		if i > 0 && !strings.HasPrefix(l, " ") {
+			t.Errorf("Continuation line %d doesn't start with a space: %q", i, l)
+		}
+	}
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+		t.Fatalf("Expected unfolding to restore the line, got %q", unfolded)
+	}
+}
+
+func TestAnalyzeFollowers_PlanICS(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	data, err := GenerateExport(2, 4)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/plan?format=ics&per_day=1&start=2024-06-02", bytes.NewReader(data))
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Fatalf("Expected text/calendar, got %s", ct)
+	}
+	if n := strings.Count(w.Body.String(), "BEGIN:VEVENT"); n != 2 {
+		t.Fatalf("Expected 2 events, got %d", n)
+	}
+}
+
+func TestUnfollowAdvice(t *testing.T) {
+	if perDay, warning := unfollowAdvice(30); perDay != 30 || warning != "" {
+		t.Fatalf("Expected all 30 in one day without a warning, got %d, %q", perDay, warning)
+	}
+
+	perDay, warning := unfollowAdvice(120)
+	if perDay != defaultUnfollowsPerDay || !strings.Contains(warning, "at most 50 a day; this takes 3 days") {
+		t.Fatalf("Expected the default limit and a 3-day warning, got %d, %q", perDay, warning)
+	}
+
+	withEnv(t, "SAFE_UNFOLLOWS_PER_DAY", "100")
+	if perDay, warning := unfollowAdvice(120); perDay != 100 || !strings.Contains(warning, "2 days") {
+		t.Fatalf("Expected the configured limit, got %d, %q", perDay, warning)
+	}
+	withEnv(t, "SAFE_UNFOLLOWS_PER_DAY", "100000")
+	if perDay, _ := unfollowAdvice(120); perDay != defaultUnfollowsPerDay {
+		t.Fatalf("Expected an out-of-range limit to be ignored, got %d", perDay)
+	}
+}
@@ -0,0 +1,60 @@
+package followercount
+
+import "github.com/afaafhariri/follower-watch/backend/analysis"
+
+// previewSize is how many entries of each list a preview response keeps.
+const previewSize = 20
+
+// firstN returns at most the first n entries of list.
+func firstN[T any](list []T, n int) []T {
+	if len(list) > n {
+		return list[:n]
+	}
+	return list
+}
+
+// applyPreview trims every list in response, including those of the other
+// platforms' results, to its first previewSize entries, so clients can
+// render a first page cheaply. Counts and stats are kept whole: Count still
+// reports every non-follower.
+func applyPreview(response *APIResponse) {
+	response.Preview = true
+	response.NonFollowers = firstN(response.NonFollowers, previewSize)
+	response.Insights = firstN(response.Insights, previewSize)
+	response.Warnings = firstN(response.Warnings, previewSize)
+	response.PossibleDuplicates = firstN(response.PossibleDuplicates, previewSize)
+	response.Milestones = firstN(response.Milestones, previewSize)
+	response.OtherRelationships = firstN(response.OtherRelationships, previewSize)
+	response.PendingRequests = firstN(response.PendingRequests, previewSize)
+	response.Mutuals = firstN(response.Mutuals, previewSize)
+	response.Fans = firstN(response.Fans, previewSize)
+	response.StrongestConnections = firstN(response.StrongestConnections, previewSize)
+	response.WeakestConnections = firstN(response.WeakestConnections, previewSize)
+
+	if response.Platforms == nil {
+		return
+	}
+	platforms := make(map[string]*analysis.Result, len(response.Platforms))
+	for id, result := range response.Platforms {
+		trimmed := *result
+		previewResult(&trimmed)
+		platforms[id] = &trimmed
+	}
+	response.Platforms = platforms
+}
+
+// previewResult trims the lists of another platform's result like
+// applyPreview does the response's.
+func previewResult(result *analysis.Result) {
+	result.NonFollowers = firstN(result.NonFollowers, previewSize)
+	result.Insights = firstN(result.Insights, previewSize)
+	result.Warnings = firstN(result.Warnings, previewSize)
+	result.PossibleDuplicates = firstN(result.PossibleDuplicates, previewSize)
+	result.Milestones = firstN(result.Milestones, previewSize)
+	result.OtherRelationships = firstN(result.OtherRelationships, previewSize)
+	result.PendingRequests = firstN(result.PendingRequests, previewSize)
+	result.Mutuals = firstN(result.Mutuals, previewSize)
+	result.Fans = firstN(result.Fans, previewSize)
+	result.StrongestConnections = firstN(result.StrongestConnections, previewSize)
+	result.WeakestConnections = firstN(result.WeakestConnections, previewSize)
+}
@@ -0,0 +1,101 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+func TestFirstN(t *testing.T) {
+	if got := firstN([]int{1, 2, 3}, 2); len(got) != 2 {
+		t.Fatalf("Expected 2 entries, got %v", got)
+	}
+	if got := firstN([]int{1}, 2); len(got) != 1 {
+		t.Fatalf("Expected a short list to be kept, got %v", got)
+	}
+	if got := firstN[int](nil, 2); got != nil {
+		t.Fatalf("Expected nil to stay nil, got %v", got)
+	}
+}
+
+func TestApplyPreview(t *testing.T) {
+	long := previewSize + 5
+	threads := &analysis.Result{
+		NonFollowers: make([]analysis.NonFollower, long),
+		Warnings:     make([]analysis.Warning, long),
+	}
+	response := APIResponse{
+		Warnings:             make([]analysis.Warning, long),
+		Milestones:           make([]analysis.Milestone, long),
+		OtherRelationships:   make([]analysis.OtherRelationship, long),
+		StrongestConnections: make([]analysis.Connection, long),
+		WeakestConnections:   make([]analysis.Connection, long),
+		Platforms:            map[string]*analysis.Result{analysis.PlatformThreads: threads},
+	}
+	applyPreview(&response)
+
+	for name, n := range map[string]int{
+		"warnings":              len(response.Warnings),
+		"milestones":            len(response.Milestones),
+		"other_relationships":   len(response.OtherRelationships),
+		"strongest_connections": len(response.StrongestConnections),
+		"weakest_connections":   len(response.WeakestConnections),
+		"threads non_followers": len(response.Platforms[analysis.PlatformThreads].NonFollowers),
+		"threads warnings":      len(response.Platforms[analysis.PlatformThreads].Warnings),
+	} {
+		if n != previewSize {
+			t.Errorf("Expected %s trimmed to %d, got %d", name, previewSize, n)
+		}
+	}
+	if len(threads.NonFollowers) != long {
+		t.Fatal("Expected the platform result itself to be left whole")
+	}
+}
+
+func TestAnalyzeFollowers_Preview(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	// 10 of the 60 followed accounts follow back, leaving 50 non-followers.
+	zipBytes, err := GenerateExport(10, 60)
+	if err != nil {
+		t.Fatalf("GenerateExport failed: %v", err)
+	}
+	analyze := func(query string) (int, APIResponse) {
+		w := httptest.NewRecorder()
+		AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/"+query, bytes.NewReader(zipBytes)))
+		var apiResponse APIResponse
+		json.NewDecoder(w.Body).Decode(&apiResponse)
+		return w.Code, apiResponse
+	}
+
+	_, full := analyze("")
+	if full.Preview || len(full.NonFollowers) != 50 {
+		t.Fatalf("Expected the full list without preview, got %d entries", len(full.NonFollowers))
+	}
+
+	_, preview := analyze("?preview=true")
+	if !preview.Preview || len(preview.NonFollowers) != previewSize || preview.Count != 50 || preview.Stats == nil {
+		t.Fatalf("Expected %d of 50 non-followers with stats, got %d of %d", previewSize, len(preview.NonFollowers), preview.Count)
+	}
+	if preview.NonFollowers[0] != full.NonFollowers[0] {
+		t.Fatal("Expected the preview to start with the same entries as the full result")
+	}
+
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/?preview=true&envelope=false&section=non_followers", bytes.NewReader(zipBytes)))
+	var bare []analysis.NonFollower
+	json.NewDecoder(w.Body).Decode(&bare)
+	if len(bare) != previewSize || w.Header().Get(totalCountHeader) != "50" {
+		t.Fatalf("Expected %d entries with X-Total-Count 50, got %d with %q", previewSize, len(bare), w.Header().Get(totalCountHeader))
+	}
+
+	if code, _ := analyze("?preview=yes"); code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an invalid preview value, got %d", code)
+	}
+}
@@ -0,0 +1,234 @@
+package followercount
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// originQuota is the daily processing budget of one embedding origin. A zero
+// limit means unlimited.
+type originQuota struct {
+	Requests int
+	Bytes    int64
+}
+
+// LimiterBackend stores the counters behind the origin quotas. The default
+// backend keeps them in memory, so each instance enforces its own quotas;
+// deployments running several instances register a shared backend, e.g. on
+// Redis or Firestore, so the quotas hold across all of them.
+type LimiterBackend interface {
+	// Get returns the counter key, or 0 when it is unset or has expired.
+	Get(ctx context.Context, key string) (int64, error)
+	// Add adds n to the counter key, which expires at expires, and returns
+	// its new value.
+	Add(ctx context.Context, key string, n int64, expires time.Time) (int64, error)
+}
+
+var (
+	limiterMu sync.RWMutex
+	limiter   LimiterBackend = newMemoryLimiter()
+)
+
+// RegisterLimiterBackend replaces the in-memory limiter backend.
+// Deployments call it from an init function in their own file.
+func RegisterLimiterBackend(b LimiterBackend) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+	limiter = b
+}
+
+func limiterBackend() LimiterBackend {
+	limiterMu.RLock()
+	defer limiterMu.RUnlock()
+	return limiter
+}
+
+type memoryCounter struct {
+	value   int64
+	expires time.Time
+}
+
+// memoryLimiter is the default LimiterBackend, local to the instance.
+type memoryLimiter struct {
+	mu       sync.Mutex
+	counters map[string]memoryCounter
+}
+
+func newMemoryLimiter() *memoryLimiter {
+	return &memoryLimiter{counters: make(map[string]memoryCounter)}
+}
+
+func (m *memoryLimiter) Get(_ context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.counters[key]; ok && clock.Now().Before(c.expires) {
+		return c.value, nil
+	}
+	return 0, nil
+}
+
+func (m *memoryLimiter) Add(_ context.Context, key string, n int64, expires time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := clock.Now()
+	for k, c := range m.counters {
+		if !now.Before(c.expires) {
+			delete(m.counters, k)
+		}
+	}
+	c := m.counters[key]
+	c.value += n
+	c.expires = expires
+	m.counters[key] = c
+	return c.value, nil
+}
+
+// getOriginQuotas parses ORIGIN_QUOTAS, a comma-separated list of
+// origin=requests:megabytes entries, e.g.
+//
+//	ORIGIN_QUOTAS=https://partner.example=1000:2048,https://other.example=200:0
+//
+// Malformed entries are logged and ignored.
+func getOriginQuotas() map[string]originQuota {
+	quotas := make(map[string]originQuota)
+	for _, entry := range strings.Split(getEnv("ORIGIN_QUOTAS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		sep := strings.LastIndex(entry, "=")
+		if sep == -1 {
+			log.Printf("Warning: ignoring malformed ORIGIN_QUOTAS entry %q", entry)
+			continue
+		}
+		limits := strings.SplitN(entry[sep+1:], ":", 2)
+		requests, err := strconv.Atoi(limits[0])
+		if err != nil || len(limits) != 2 {
+			log.Printf("Warning: ignoring malformed ORIGIN_QUOTAS entry %q", entry)
+			continue
+		}
+		megabytes, err := strconv.ParseInt(limits[1], 10, 64)
+		if err != nil {
+			log.Printf("Warning: ignoring malformed ORIGIN_QUOTAS entry %q", entry)
+			continue
+		}
+
+		quotas[entry[:sep]] = originQuota{Requests: requests, Bytes: megabytes * 1024 * 1024}
+	}
+	return quotas
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// quotaKeys returns the limiter keys of origin's request and byte counters
+// for the UTC day of now, and the end of that day, when they expire.
+func quotaKeys(origin string, now time.Time) (requests, bytes string, expires time.Time) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	prefix := "origin-quota/" + origin + "/" + day.Format("2006-01-02")
+	return prefix + "/requests", prefix + "/bytes", day.Add(24 * time.Hour)
+}
+
+// checkOriginQuota counts a request from origin and reports whether it is
+// within today's quota, along with the bytes origin may still upload today
+// (-1 when unlimited).
+func checkOriginQuota(ctx context.Context, origin string, quota originQuota) (bool, int64, error) {
+	backend := limiterBackend()
+	requestsKey, bytesKey, expires := quotaKeys(origin, clock.Now())
+
+	requests, err := backend.Add(ctx, requestsKey, 1, expires)
+	if err != nil {
+		return false, 0, err
+	}
+	if quota.Requests > 0 && requests > int64(quota.Requests) {
+		return false, 0, nil
+	}
+	if quota.Bytes <= 0 {
+		return true, -1, nil
+	}
+	used, err := backend.Get(ctx, bytesKey)
+	if err != nil {
+		return false, 0, err
+	}
+	return used < quota.Bytes, quota.Bytes - used, nil
+}
+
+// chargeOriginBytes adds n uploaded bytes to origin's usage for today.
+func chargeOriginBytes(ctx context.Context, origin string, n int64) error {
+	_, bytesKey, expires := quotaKeys(origin, clock.Now())
+	_, err := limiterBackend().Add(ctx, bytesKey, n, expires)
+	return err
+}
+
+// withOriginQuota enforces the daily request and upload-volume budgets
+// configured per Origin, so a partner site embedding the API can't exhaust
+// the deployment. Requests from origins without a quota pass through. With a
+// byte quota the body, already bounded by withBodyLimit, is read here so its
+// real size is charged and no single upload can overrun the quota. A limiter
+// backend that can't be reached lets requests through rather than locking
+// partners out.
+func withOriginQuota(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		quota, ok := getOriginQuotas()[origin]
+		if origin == "" || !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, remaining, err := checkOriginQuota(r.Context(), origin, quota)
+		if err != nil {
+			log.Printf("Warning: origin quota unavailable, allowing request: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !allowed || remaining >= 0 && r.ContentLength > remaining {
+			sendDomainError(w, ErrQuotaExceeded)
+			return
+		}
+		if remaining < 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, remaining+1))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				sendDomainError(w, ErrUploadTooLarge)
+				return
+			}
+			sendDomainError(w, fmt.Errorf("%w: %w", ErrReadBody, err))
+			return
+		}
+		if int64(len(body)) > remaining {
+			sendDomainError(w, ErrQuotaExceeded)
+			return
+		}
+		if err := chargeOriginBytes(r.Context(), origin, int64(len(body))); err != nil {
+			log.Printf("Warning: could not charge origin quota: %v", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
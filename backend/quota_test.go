@@ -0,0 +1,199 @@
+package followercount
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func withEnv(t *testing.T, key, value string) {
+	old, had := envConfig[key]
+	envConfig[key] = value
+	t.Cleanup(func() {
+		if had {
+			envConfig[key] = old
+		} else {
+			delete(envConfig, key)
+		}
+	})
+}
+
+func TestGetOriginQuotas(t *testing.T) {
+	withEnv(t, "ORIGIN_QUOTAS", "https://a.example=10:1, https://b.example=0:5,broken,https://c.example=x:1")
+
+	quotas := getOriginQuotas()
+	if len(quotas) != 2 {
+		t.Fatalf("Expected 2 valid quotas, got %v", quotas)
+	}
+	if q := quotas["https://a.example"]; q.Requests != 10 || q.Bytes != 1024*1024 {
+		t.Errorf("Unexpected quota for a.example: %+v", q)
+	}
+	if q := quotas["https://b.example"]; q.Requests != 0 || q.Bytes != 5*1024*1024 {
+		t.Errorf("Unexpected quota for b.example: %+v", q)
+	}
+}
+
+// withLimiter registers b as the limiter backend for the duration of the
+// test.
+func withLimiter(t *testing.T, b LimiterBackend) {
+	old := limiterBackend()
+	RegisterLimiterBackend(b)
+	t.Cleanup(func() { RegisterLimiterBackend(old) })
+}
+
+func TestWithOriginQuota(t *testing.T) {
+	withEnv(t, "ORIGIN_QUOTAS", "https://partner.example=2:0")
+	withLimiter(t, newMemoryLimiter())
+
+	h := withOriginQuota(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("data"))
+		req.Header.Set("Origin", "https://partner.example")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("Request %d: expected status %d, got %d", i+1, want, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Origin", "https://other.example")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected origins without a quota to pass, got %d", w.Code)
+	}
+}
+
+func TestWithOriginQuota_Bytes(t *testing.T) {
+	withEnv(t, "ORIGIN_QUOTAS", "https://partner.example=0:1")
+	backend := newMemoryLimiter()
+	withLimiter(t, backend)
+
+	var read []byte
+	h := withOriginQuota(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	send := func(body string, chunked bool) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Origin", "https://partner.example")
+		if chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	_, bytesKey, _ := quotaKeys("https://partner.example", clock.Now())
+
+	if code := send(strings.Repeat("x", 600*1024), false); code != http.StatusOK || len(read) != 600*1024 {
+		t.Fatalf("Expected the first upload to pass whole, got %d with %d bytes", code, len(read))
+	}
+	if used, _ := backend.Get(context.Background(), bytesKey); used != 600*1024 {
+		t.Fatalf("Expected the real body size to be charged, got %d", used)
+	}
+
+	// A single upload can't overrun the quota, whether it declares its
+	// length or not.
+	if code := send(strings.Repeat("x", 600*1024), false); code != http.StatusTooManyRequests {
+		t.Fatalf("Expected an upload over the remaining quota to be rejected, got %d", code)
+	}
+	if code := send(strings.Repeat("x", 600*1024), true); code != http.StatusTooManyRequests {
+		t.Fatalf("Expected a chunked upload over the remaining quota to be rejected, got %d", code)
+	}
+	if used, _ := backend.Get(context.Background(), bytesKey); used != 600*1024 {
+		t.Fatalf("Expected rejected uploads not to be charged, got %d", used)
+	}
+
+	if code := send(strings.Repeat("x", 424*1024), true); code != http.StatusOK {
+		t.Fatalf("Expected an upload within the remaining quota to pass, got %d", code)
+	}
+	if code := send("x", false); code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the used up quota to reject uploads, got %d", code)
+	}
+}
+
+// sharedLimiter is a LimiterBackend standing in for a store shared by
+// several instances.
+type sharedLimiter struct {
+	*memoryLimiter
+	adds int
+}
+
+func (s *sharedLimiter) Add(ctx context.Context, key string, n int64, expires time.Time) (int64, error) {
+	s.adds++
+	return s.memoryLimiter.Add(ctx, key, n, expires)
+}
+
+func TestWithOriginQuota_RegisteredBackend(t *testing.T) {
+	withEnv(t, "ORIGIN_QUOTAS", "https://partner.example=1:0")
+	backend := &sharedLimiter{memoryLimiter: newMemoryLimiter()}
+	withLimiter(t, backend)
+
+	h := withOriginQuota(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Origin", "https://partner.example")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("Request %d: expected status %d, got %d", i+1, want, w.Code)
+		}
+	}
+	if backend.adds != 2 {
+		t.Fatalf("Expected the quota to be kept in the registered backend, got %d updates", backend.adds)
+	}
+}
+
+func TestMemoryLimiter_Expires(t *testing.T) {
+	fake := withClock(t, time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC))
+	m := newMemoryLimiter()
+	ctx := context.Background()
+
+	m.Add(ctx, "k", 5, clock.Now().Add(time.Hour))
+	if got, _ := m.Get(ctx, "k"); got != 5 {
+		t.Fatalf("Expected 5, got %d", got)
+	}
+	fake.Advance(time.Hour)
+	if got, _ := m.Get(ctx, "k"); got != 0 {
+		t.Fatalf("Expected the counter to expire, got %d", got)
+	}
+	if got, _ := m.Add(ctx, "k", 1, clock.Now().Add(time.Hour)); got != 1 {
+		t.Fatalf("Expected an expired counter to restart, got %d", got)
+	}
+}
+
+func TestCheckOriginQuota_ResetsAtUTCMidnight(t *testing.T) {
+	withLimiter(t, newMemoryLimiter())
+	fake := withClock(t, time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	quota := originQuota{Requests: 2}
+	for i := 0; i < quota.Requests; i++ {
+		if ok, _, _ := checkOriginQuota(ctx, "o", quota); !ok {
+			t.Fatalf("Request %d: expected to be within quota", i+1)
+		}
+	}
+	if ok, _, _ := checkOriginQuota(ctx, "o", quota); ok {
+		t.Fatal("Expected the quota to be used up")
+	}
+
+	fake.Advance(59 * time.Second)
+	if ok, _, _ := checkOriginQuota(ctx, "o", quota); ok {
+		t.Fatal("Expected the quota to stay used up until midnight")
+	}
+	fake.Advance(time.Second)
+	if ok, _, _ := checkOriginQuota(ctx, "o", quota); !ok {
+		t.Fatal("Expected the quota to reset at midnight UTC")
+	}
+}
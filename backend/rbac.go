@@ -0,0 +1,107 @@
+package followercount
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Roles a caller can hold within a tenant, read from the auth token.
+const (
+	RoleOwner   = "owner"
+	RoleAnalyst = "analyst"
+	RoleViewer  = "viewer"
+	// RoleGuest is held by embedded widgets using a guest token.
+	RoleGuest = "guest"
+)
+
+// Permissions granted by roles.
+const (
+	PermUpload = "upload"
+)
+
+// rolePermissions lists what each role may do: owners, analysts and guests
+// upload, while viewers, the role of callers that carry none, may not.
+var rolePermissions = map[string][]string{
+	RoleOwner:   {PermUpload},
+	RoleAnalyst: {PermUpload},
+	RoleViewer:  {},
+	RoleGuest:   {PermUpload},
+}
+
+// defaultRoleClaim is the token claim roles are read from unless
+// AUTH_ROLE_CLAIM names another one.
+const defaultRoleClaim = "roles"
+
+// principalRoles returns the roles of p: the AUTH_ROLE_CLAIM claim, either a
+// single role or a list of them. Tokens without that claim get viewer, the
+// least privilege. Only callers whose credentials carry no claims at all,
+// such as HMAC-signed requests, get AUTH_DEFAULT_ROLE, and viewer when that
+// is unset. Guests are always just guests.
+func principalRoles(p *Principal) []string {
+	if p.guest {
+		return []string{RoleGuest}
+	}
+	claim := getEnv("AUTH_ROLE_CLAIM")
+	if claim == "" {
+		claim = defaultRoleClaim
+	}
+
+	var roles []string
+	switch v := p.Claims[claim].(type) {
+	case string:
+		roles = strings.Fields(strings.ReplaceAll(v, ",", " "))
+	case []any:
+		for _, role := range v {
+			if s, ok := role.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+	}
+	if len(roles) > 0 {
+		return roles
+	}
+
+	if p.Claims == nil {
+		if role := strings.TrimSpace(getEnv("AUTH_DEFAULT_ROLE")); role != "" {
+			return []string{role}
+		}
+	}
+	return []string{RoleViewer}
+}
+
+// hasPermission reports whether any of roles grants perm. Unknown roles
+// grant nothing.
+func hasPermission(roles []string, perm string) bool {
+	for _, role := range roles {
+		for _, granted := range rolePermissions[strings.ToLower(role)] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withPermission rejects authenticated callers whose roles don't grant perm.
+// It must run after withAuth; with authentication disabled every caller is
+// allowed.
+func withPermission(perm string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := principalFromContext(r.Context())
+			if principal == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if roles := principalRoles(principal); !hasPermission(roles, perm) {
+				log.Printf("Authorization failed: roles %v lack %s", roles, perm)
+				sendDomainError(w, fmt.Errorf("%w: %s requires the %s permission", ErrForbidden, r.URL.Path, perm))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
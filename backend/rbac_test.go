@@ -0,0 +1,100 @@
+package followercount
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPrincipalRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]any
+		want   []string
+	}{
+		{"list", map[string]any{"roles": []any{"viewer", "analyst"}}, []string{"viewer", "analyst"}},
+		{"string", map[string]any{"roles": "viewer analyst"}, []string{"viewer", "analyst"}},
+		{"no claims", nil, []string{RoleViewer}},
+		{"no role claim", map[string]any{"sub": "user-1"}, []string{RoleViewer}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := principalRoles(&Principal{Claims: tt.claims})
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+
+	withEnv(t, "AUTH_ROLE_CLAIM", "https://example.com/role")
+	withEnv(t, "AUTH_DEFAULT_ROLE", "viewer")
+	if got := principalRoles(&Principal{Claims: map[string]any{"https://example.com/role": "analyst"}}); len(got) != 1 || got[0] != "analyst" {
+		t.Fatalf("Expected the configured claim to be read, got %v", got)
+	}
+	withEnv(t, "AUTH_DEFAULT_ROLE", "analyst")
+	if got := principalRoles(&Principal{Subject: "partner"}); len(got) != 1 || got[0] != "analyst" {
+		t.Fatalf("Expected AUTH_DEFAULT_ROLE for callers without claims, got %v", got)
+	}
+	if got := principalRoles(&Principal{Claims: map[string]any{"sub": "user-1"}}); len(got) != 1 || got[0] != RoleViewer {
+		t.Fatalf("Expected tokens without roles to stay viewers, got %v", got)
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		roles []string
+		perm  string
+		want  bool
+	}{
+		{[]string{RoleOwner}, PermUpload, true},
+		{[]string{RoleAnalyst}, PermUpload, true},
+		{[]string{RoleViewer}, PermUpload, false},
+		{[]string{RoleViewer, "Analyst"}, PermUpload, true},
+		{[]string{"admin"}, PermUpload, false},
+	}
+	for _, tt := range tests {
+		if got := hasPermission(tt.roles, tt.perm); got != tt.want {
+			t.Errorf("hasPermission(%v, %s) = %v, want %v", tt.roles, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestWithPermission_Upload(t *testing.T) {
+	silenceLogs(t)
+	withEnv(t, "AUTH_MODE", "jwt")
+	withEnv(t, "AUTH_JWT_SECRET", "secret")
+
+	h := withAuth(withPermission(PermUpload)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	request := func(roles any) *httptest.ResponseRecorder {
+		claims := map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+		if roles != nil {
+			claims["roles"] = roles
+		}
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signJWT(t, "secret", claims))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request([]string{"analyst"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected analysts to upload, got %d", w.Code)
+	}
+	for _, roles := range []any{[]string{"viewer"}, nil} {
+		w := request(roles)
+		var response APIResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		if w.Code != http.StatusForbidden || response.ErrorCode != "forbidden" {
+			t.Fatalf("Expected roles %v to get 403 forbidden, got %d %q", roles, w.Code, response.ErrorCode)
+		}
+	}
+}
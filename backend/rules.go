@@ -0,0 +1,37 @@
+package followercount
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// loadMatchingRules applies the file matching rules in setting, the value of
+// FILE_MATCHING_RULES, over the embedded defaults. It holds either inline
+// JSON or the path of a JSON file.
+// Operators use it to follow a new export layout before a release ships.
+func loadMatchingRules(setting string) error {
+	setting = strings.TrimSpace(setting)
+	if setting == "" {
+		return nil
+	}
+	data := []byte(setting)
+	if !strings.HasPrefix(setting, "{") {
+		var err error
+		if data, err = os.ReadFile(setting); err != nil {
+			return fmt.Errorf("reading FILE_MATCHING_RULES: %w", err)
+		}
+	}
+	rules, err := analysis.ParseRules(data)
+	if err != nil {
+		return err
+	}
+	if err := analysis.SetRules(rules); err != nil {
+		return err
+	}
+	log.Printf("Using file matching rules %q", analysis.RulesVersion())
+	return nil
+}
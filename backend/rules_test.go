@@ -0,0 +1,38 @@
+package followercount
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+func TestLoadMatchingRules(t *testing.T) {
+	silenceLogs(t)
+	t.Cleanup(analysis.ResetRules)
+
+	if err := loadMatchingRules(""); err != nil {
+		t.Fatalf("Expected no rules to be a no-op, got %v", err)
+	}
+
+	inline := `{"version": "inline", "kinds": {"likes": {"match": ["liked[^/]*\\.json$"]}}}`
+	if err := loadMatchingRules(inline); err != nil || analysis.RulesVersion() != "inline" {
+		t.Fatalf("Expected inline rules to apply, got %v, version %q", err, analysis.RulesVersion())
+	}
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(`{"version": "file", "kinds": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadMatchingRules(path); err != nil || analysis.RulesVersion() != "file" {
+		t.Fatalf("Expected rules from a file to apply, got %v, version %q", err, analysis.RulesVersion())
+	}
+
+	if err := loadMatchingRules(`{"kinds": {"likes": {"match": ["("]}}}`); err == nil {
+		t.Fatal("Expected an error for an invalid pattern")
+	}
+	if err := loadMatchingRules(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+}
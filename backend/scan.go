@@ -0,0 +1,97 @@
+package followercount
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// ScanResult is the verdict of an UploadScanner.
+type ScanResult struct {
+	Clean  bool
+	Detail string
+}
+
+// UploadScanner inspects a raw upload before it is parsed, e.g. by calling
+// ClamAV or a cloud malware-scanning API. An error means the upload could
+// not be scanned; a result with Clean set to false rejects it.
+type UploadScanner interface {
+	Name() string
+	Scan(ctx context.Context, upload []byte) (ScanResult, error)
+}
+
+var (
+	scannersMu sync.RWMutex
+	scanners   = []UploadScanner{zipAnomalyScanner{}}
+)
+
+// RegisterUploadScanner adds s to the scanners run on every upload.
+// Deployments call it from an init function in their own file.
+func RegisterUploadScanner(s UploadScanner) {
+	scannersMu.Lock()
+	defer scannersMu.Unlock()
+	scanners = append(scanners, s)
+}
+
+// scanUpload runs every registered scanner and logs each verdict. It fails
+// closed: an upload that a scanner can't check is not processed.
+func scanUpload(ctx context.Context, upload []byte) error {
+	scannersMu.RLock()
+	defer scannersMu.RUnlock()
+
+	for _, s := range scanners {
+		result, err := s.Scan(ctx, upload)
+		if err != nil {
+			log.Printf("scan: scanner=%s request=%s error=%v", s.Name(), requestIDFromContext(ctx), err)
+			return fmt.Errorf("%w: %s: %w", ErrScanFailed, s.Name(), err)
+		}
+		log.Printf("scan: scanner=%s request=%s clean=%v detail=%q", s.Name(), requestIDFromContext(ctx), result.Clean, result.Detail)
+		if !result.Clean {
+			return fmt.Errorf("%w: %s: %s", ErrUploadRejected, s.Name(), result.Detail)
+		}
+	}
+	return nil
+}
+
+// Limits applied by zipAnomalyScanner. Real exports compress JSON roughly
+// 10:1 and media barely at all, so these only trip on crafted archives.
+const (
+	maxCompressionRatio = 1000
+	maxArchiveEntries   = 100_000
+	maxUncompressedSize = 4 << 30
+)
+
+// zipAnomalyScanner rejects archives whose central directory looks like a
+// zip bomb. It never decompresses anything.
+type zipAnomalyScanner struct{}
+
+func (zipAnomalyScanner) Name() string { return "zip-anomaly" }
+
+func (zipAnomalyScanner) Scan(_ context.Context, upload []byte) (ScanResult, error) {
+	if !hasZipMagic(upload) {
+		return ScanResult{Clean: true}, nil
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(upload), int64(len(upload)))
+	if err != nil {
+		// Left to the parser, which reports corrupt archives properly.
+		return ScanResult{Clean: true}, nil
+	}
+
+	if len(zipReader.File) > maxArchiveEntries {
+		return ScanResult{Detail: fmt.Sprintf("%d entries", len(zipReader.File))}, nil
+	}
+	var total uint64
+	for _, f := range zipReader.File {
+		total += f.UncompressedSize64
+		if f.CompressedSize64 > 0 && f.UncompressedSize64/f.CompressedSize64 > maxCompressionRatio {
+			return ScanResult{Detail: "entry compression ratio too high"}, nil
+		}
+	}
+	if total > maxUncompressedSize {
+		return ScanResult{Detail: fmt.Sprintf("%d bytes uncompressed", total)}, nil
+	}
+	return ScanResult{Clean: true}, nil
+}
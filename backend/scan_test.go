@@ -0,0 +1,84 @@
+package followercount
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+type stubScanner struct {
+	result ScanResult
+	err    error
+}
+
+func (s stubScanner) Name() string { return "stub" }
+
+func (s stubScanner) Scan(context.Context, []byte) (ScanResult, error) {
+	return s.result, s.err
+}
+
+func withScanner(t *testing.T, s UploadScanner) {
+	scannersMu.Lock()
+	saved := scanners
+	scannersMu.Unlock()
+	RegisterUploadScanner(s)
+	t.Cleanup(func() {
+		scannersMu.Lock()
+		scanners = saved
+		scannersMu.Unlock()
+	})
+}
+
+func TestScanUpload(t *testing.T) {
+	silenceLogs(t)
+
+	tests := []struct {
+		name    string
+		scanner stubScanner
+		want    error
+	}{
+		{"clean", stubScanner{result: ScanResult{Clean: true}}, nil},
+		{"infected", stubScanner{result: ScanResult{Detail: "EICAR"}}, ErrUploadRejected},
+		{"unavailable", stubScanner{err: errors.New("timeout")}, ErrScanFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withScanner(t, tt.scanner)
+			err := scanUpload(context.Background(), []byte("upload"))
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestZipAnomalyScanner(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	// Claim a 1GB entry stored in 10 bytes, as a zip bomb would.
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "bomb.json",
+		Method:             zip.Deflate,
+		CompressedSize64:   10,
+		UncompressedSize64: 1 << 30,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create entry: %v", err)
+	}
+	w.Write(make([]byte, 10))
+	zw.Close()
+
+	result, err := zipAnomalyScanner{}.Scan(context.Background(), buf.Bytes())
+	if err != nil || result.Clean {
+		t.Fatalf("Expected the archive to be flagged, got %+v, %v", result, err)
+	}
+
+	export, _ := GenerateExport(10, 10)
+	result, err = zipAnomalyScanner{}.Scan(context.Background(), export)
+	if err != nil || !result.Clean {
+		t.Fatalf("Expected a normal export to be clean, got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,75 @@
+package followercount
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// maxQueryLength bounds the q option, in characters.
+const maxQueryLength = 100
+
+// foldLetters spells out letters that don't decompose into a base letter and
+// an accent, so they still match how people type them on a plain keyboard.
+var foldLetters = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "ł", "l", "đ", "d", "ð", "d",
+	"þ", "th", "ı", "i",
+)
+
+// searchSeparators are ignored when matching, so "jane doe" finds jane.doe
+// and jane_doe.
+var searchSeparators = strings.NewReplacer(" ", "", ".", "", "_", "", "-", "")
+
+// searchFolder reduces strings to the form searches compare: lowercased,
+// with accents stripped (é→e, ü→u) and separators removed. It reuses one
+// accent-stripping transformer, which transform.String resets before each
+// string, so a searchFolder must not be shared between goroutines.
+type searchFolder struct {
+	stripAccents transform.Transformer
+}
+
+func newSearchFolder() *searchFolder {
+	return &searchFolder{stripAccents: transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)}
+}
+
+func (f *searchFolder) fold(s string) string {
+	folded, _, err := transform.String(f.stripAccents, strings.ToLower(s))
+	if err != nil {
+		folded = strings.ToLower(s)
+	}
+	return searchSeparators.Replace(foldLetters.Replace(folded))
+}
+
+// foldSearch folds a single string, such as the query, with a searchFolder
+// of its own.
+func foldSearch(s string) string {
+	return newSearchFolder().fold(s)
+}
+
+// searchQuery reads the q option. It returns the folded query, or "" when
+// there is none.
+func searchQuery(q string) (string, error) {
+	if utf8.RuneCountInString(q) > maxQueryLength {
+		return "", withDetail(ErrInvalidOption, "q must be at most %d characters", maxQueryLength)
+	}
+	return foldSearch(q), nil
+}
+
+// searchNonFollowers returns the non-followers whose username or display name
+// contains the folded query, in their original order.
+func searchNonFollowers(list []analysis.NonFollower, query string) []analysis.NonFollower {
+	folder := newSearchFolder()
+	matches := []analysis.NonFollower{}
+	for _, nf := range list {
+		if strings.Contains(folder.fold(nf.Username), query) || strings.Contains(folder.fold(nf.DisplayName), query) {
+			matches = append(matches, nf)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,98 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+func TestFoldSearch(t *testing.T) {
+	tests := map[string]string{
+		"Zoë Müller":  "zoemuller",
+		"José.García": "josegarcia",
+		"Straße":      "strasse",
+		"Søren_Ærø":   "sorenaero",
+		"plain_user1": "plainuser1",
+	}
+	for in, want := range tests {
+		if got := foldSearch(in); got != want {
+			t.Errorf("foldSearch(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearchNonFollowers(t *testing.T) {
+	list := []analysis.NonFollower{
+		{Username: "zoe.mueller", DisplayName: "Zoë Müller"},
+		{Username: "jose_garcia", DisplayName: "José García"},
+		{Username: "celeb"},
+	}
+
+	tests := []struct {
+		q    string
+		want []string
+	}{
+		{"muller", []string{"zoe.mueller"}},
+		{"Zoë", []string{"zoe.mueller"}},
+		{"jose garcia", []string{"jose_garcia"}},
+		{"GARCÍA", []string{"jose_garcia"}},
+		{"e", []string{"zoe.mueller", "jose_garcia", "celeb"}},
+		{"nobody", nil},
+	}
+	for _, tt := range tests {
+		query, err := searchQuery(tt.q)
+		if err != nil {
+			t.Fatalf("searchQuery(%q): %v", tt.q, err)
+		}
+		var got []string
+		for _, nf := range searchNonFollowers(list, query) {
+			got = append(got, nf.Username)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("q=%q: got %v, want %v", tt.q, got, tt.want)
+		}
+	}
+
+	if _, err := searchQuery(strings.Repeat("é", maxQueryLength+1)); err == nil {
+		t.Fatal("Expected an error for an overlong query")
+	}
+}
+
+func TestAnalyzeFollowers_Search(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+	silenceLogs(t)
+
+	data := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "friend"}]}]`,
+		"connections/followers_and_following/following.json": `{"relationships_following": [
+			{"title": "friend"},
+			{"title": "Zoë Müller", "string_list_data": [{"href": "https://www.instagram.com/_u/zoe.m"}]},
+			{"title": "celeb"}
+		]}`,
+	})
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/?q=muller", bytes.NewReader(data)))
+
+	var response APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || len(response.NonFollowers) != 1 || response.NonFollowers[0].Username != "zoe.m" {
+		t.Fatalf("Expected only zoe.m to match, got %d %+v", w.Code, response.NonFollowers)
+	}
+	if response.Count != 2 || response.MatchedCount == nil || *response.MatchedCount != 1 {
+		t.Fatalf("Expected count to keep every non-follower and matched_count the match, got %d %v", response.Count, response.MatchedCount)
+	}
+
+	w = httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/?q=nobody", bytes.NewReader(data)))
+	response = APIResponse{}
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.MatchedCount == nil || *response.MatchedCount != 0 {
+		t.Fatalf("Expected matched_count 0 without matches, got %v", response.MatchedCount)
+	}
+}
@@ -0,0 +1,196 @@
+package followercount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SecretsProvider looks up secrets such as signing keys, client secrets and
+// API keys by their configuration name, e.g. AUTH_JWT_SECRET.
+// Implementations return an error wrapping errSecretNotFound for secrets
+// they don't hold.
+type SecretsProvider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+var errSecretNotFound = errors.New("secret not found")
+
+// envSecrets reads secrets from the environment configuration, as every
+// other setting is.
+type envSecrets struct{}
+
+func (envSecrets) Secret(_ context.Context, name string) (string, error) {
+	if value := getEnv(name); value != "" {
+		return value, nil
+	}
+	return "", fmt.Errorf("%w: %s", errSecretNotFound, name)
+}
+
+// gcpSecrets reads the latest version of each secret from GCP Secret
+// Manager, where it is stored under its configuration name.
+type gcpSecrets struct {
+	project string
+}
+
+var (
+	secretClientOnce sync.Once
+	secretClient     *secretmanager.Client
+	secretClientErr  error
+)
+
+func getSecretClient(ctx context.Context) (*secretmanager.Client, error) {
+	secretClientOnce.Do(func() {
+		secretClient, secretClientErr = secretmanager.NewClient(ctx)
+	})
+	return secretClient, secretClientErr
+}
+
+func (g gcpSecrets) Secret(ctx context.Context, name string) (string, error) {
+	client, err := getSecretClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("creating Secret Manager client: %w", err)
+	}
+	version, err := client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/latest", g.project, name),
+	})
+	if status.Code(err) == codes.NotFound {
+		return "", fmt.Errorf("%w: %s", errSecretNotFound, name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(version.GetPayload().GetData()), nil
+}
+
+// defaultSecretsCacheTTL is how long fetched secrets are reused unless
+// SECRETS_CACHE_SECONDS says otherwise. Rotated secrets are picked up once
+// it expires.
+const defaultSecretsCacheTTL = 5 * time.Minute
+
+type cachedSecret struct {
+	value     string
+	err       error
+	fetchedAt time.Time
+}
+
+// cachedSecrets keeps the secrets of a remote provider for ttl, so requests
+// don't each pay for a lookup. When a refresh fails, the previous value is
+// served until the provider is reachable again rather than locking callers
+// out. Lookups run outside the lock, so a slow one only holds up callers of
+// the same secret, who share its result.
+type cachedSecrets struct {
+	provider SecretsProvider
+	ttl      time.Duration
+	fetches  singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]cachedSecret
+}
+
+func newCachedSecrets(provider SecretsProvider, ttl time.Duration) *cachedSecrets {
+	return &cachedSecrets{provider: provider, ttl: ttl, entries: make(map[string]cachedSecret)}
+}
+
+func (c *cachedSecrets) Secret(ctx context.Context, name string) (string, error) {
+	if entry, ok := c.cached(name); ok && clock.Now().Sub(entry.fetchedAt) < c.ttl {
+		return entry.value, entry.err
+	}
+
+	fetched, _, _ := c.fetches.Do(name, func() (any, error) {
+		now := clock.Now()
+		value, err := c.provider.Secret(ctx, name)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		entry, ok := c.entries[name]
+		if err != nil && !errors.Is(err, errSecretNotFound) && ok && entry.err == nil {
+			log.Printf("Warning: could not refresh secret %s, keeping the cached value: %v", name, err)
+			return cachedSecret{value: entry.value}, nil
+		}
+		entry = cachedSecret{value: value, err: err, fetchedAt: now}
+		c.entries[name] = entry
+		return entry, nil
+	})
+	entry := fetched.(cachedSecret)
+	return entry.value, entry.err
+}
+
+func (c *cachedSecrets) cached(name string) (cachedSecret, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[name]
+	return entry, ok
+}
+
+var (
+	secretsMu    sync.Mutex
+	secretsCache *cachedSecrets
+	secretsKey   string
+)
+
+// secretsProvider returns the provider selected by SECRETS_PROVIDER: "env"
+// (the default) or "gcp" for Secret Manager in SECRETS_GCP_PROJECT. The
+// Secret Manager provider is cached and reused across requests.
+func secretsProvider() (SecretsProvider, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(getEnv("SECRETS_PROVIDER"))); mode {
+	case "", "env":
+		return envSecrets{}, nil
+	case "gcp":
+		project := getEnv("SECRETS_GCP_PROJECT")
+		if project == "" {
+			return nil, errors.New("SECRETS_GCP_PROJECT is not set")
+		}
+		ttl := defaultSecretsCacheTTL
+		if seconds, err := strconv.Atoi(getEnv("SECRETS_CACHE_SECONDS")); err == nil && seconds >= 0 {
+			ttl = time.Duration(seconds) * time.Second
+		}
+
+		secretsMu.Lock()
+		defer secretsMu.Unlock()
+		if key := project + "/" + ttl.String(); secretsCache == nil || secretsKey != key {
+			secretsCache = newCachedSecrets(gcpSecrets{project: project}, ttl)
+			secretsKey = key
+		}
+		return secretsCache, nil
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_PROVIDER %q", mode)
+	}
+}
+
+// secretLookupTimeout bounds a single lookup so a slow secrets backend can't
+// hold up requests indefinitely.
+const secretLookupTimeout = 5 * time.Second
+
+// getSecret returns the named secret from the configured provider. Secrets
+// the provider doesn't hold, or can't be read, fall back to the environment
+// configuration so deployments can move secrets over one at a time.
+func getSecret(name string) string {
+	provider, err := secretsProvider()
+	if err != nil {
+		log.Printf("Warning: secrets provider misconfigured, using environment: %v", err)
+		return getEnv(name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretLookupTimeout)
+	defer cancel()
+	value, err := provider.Secret(ctx, name)
+	if err == nil {
+		return value
+	}
+	if !errors.Is(err, errSecretNotFound) {
+		log.Printf("Warning: could not load secret %s, using environment: %v", name, err)
+	}
+	return getEnv(name)
+}
@@ -0,0 +1,155 @@
+package followercount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSecrets serves secrets from a map and counts lookups. A non-nil err is
+// returned instead of any value.
+type fakeSecrets struct {
+	values  map[string]string
+	err     error
+	lookups int
+}
+
+func (f *fakeSecrets) Secret(_ context.Context, name string) (string, error) {
+	f.lookups++
+	if f.err != nil {
+		return "", f.err
+	}
+	if value, ok := f.values[name]; ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("%w: %s", errSecretNotFound, name)
+}
+
+func TestEnvSecrets(t *testing.T) {
+	withEnv(t, "AUTH_JWT_SECRET", "from-env")
+
+	if value, err := (envSecrets{}).Secret(context.Background(), "AUTH_JWT_SECRET"); err != nil || value != "from-env" {
+		t.Fatalf("Expected the environment value, got %q, %v", value, err)
+	}
+	if _, err := (envSecrets{}).Secret(context.Background(), "MISSING_SECRET"); !errors.Is(err, errSecretNotFound) {
+		t.Fatalf("Expected errSecretNotFound, got %v", err)
+	}
+}
+
+func TestCachedSecrets(t *testing.T) {
+	silenceLogs(t)
+	fake := withClock(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	provider := &fakeSecrets{values: map[string]string{"AUTH_JWT_SECRET": "v1"}}
+	cache := newCachedSecrets(provider, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if value, err := cache.Secret(ctx, "AUTH_JWT_SECRET"); err != nil || value != "v1" {
+			t.Fatalf("Expected v1, got %q, %v", value, err)
+		}
+	}
+	if provider.lookups != 1 {
+		t.Fatalf("Expected one lookup within the TTL, got %d", provider.lookups)
+	}
+
+	// A rotated secret is picked up once the cached value expires.
+	provider.values["AUTH_JWT_SECRET"] = "v2"
+	fake.Advance(time.Minute)
+	if value, _ := cache.Secret(ctx, "AUTH_JWT_SECRET"); value != "v2" {
+		t.Fatalf("Expected the rotated value after the TTL, got %q", value)
+	}
+
+	// An unreachable provider keeps serving the last value.
+	provider.err = errors.New("unavailable")
+	fake.Advance(time.Minute)
+	if value, err := cache.Secret(ctx, "AUTH_JWT_SECRET"); err != nil || value != "v2" {
+		t.Fatalf("Expected the stale value while the provider is down, got %q, %v", value, err)
+	}
+	if _, err := cache.Secret(ctx, "OTHER_SECRET"); err == nil {
+		t.Fatal("Expected an error for a secret never fetched")
+	}
+}
+
+// slowSecrets blocks lookups of AUTH_JWT_SECRET until release is closed.
+type slowSecrets struct {
+	release chan struct{}
+}
+
+func (s *slowSecrets) Secret(_ context.Context, name string) (string, error) {
+	if name == "AUTH_JWT_SECRET" {
+		<-s.release
+	}
+	return name + "-value", nil
+}
+
+func TestCachedSecrets_SlowLookup(t *testing.T) {
+	provider := &slowSecrets{release: make(chan struct{})}
+	cache := newCachedSecrets(provider, time.Minute)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, err := cache.Secret(ctx, "AUTH_JWT_SECRET"); err != nil || value != "AUTH_JWT_SECRET-value" {
+				t.Errorf("Expected the slow secret, got %q, %v", value, err)
+			}
+		}()
+	}
+
+	// Other secrets are served while the slow lookup is in flight.
+	done := make(chan struct{})
+	go func() {
+		cache.Secret(ctx, "CAPTCHA_SECRET")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("A slow lookup blocked other secrets")
+	}
+
+	close(provider.release)
+	wg.Wait()
+}
+
+func TestSecretsProvider(t *testing.T) {
+	if provider, err := secretsProvider(); err != nil || provider != (envSecrets{}) {
+		t.Fatalf("Expected the environment provider by default, got %v, %v", provider, err)
+	}
+
+	withEnv(t, "SECRETS_PROVIDER", "gcp")
+	if _, err := secretsProvider(); err == nil {
+		t.Fatal("Expected an error without SECRETS_GCP_PROJECT")
+	}
+	withEnv(t, "SECRETS_GCP_PROJECT", "my-project")
+	first, err := secretsProvider()
+	if err != nil {
+		t.Fatalf("secretsProvider failed: %v", err)
+	}
+	if second, _ := secretsProvider(); second != first {
+		t.Fatal("Expected the Secret Manager provider to be reused")
+	}
+
+	withEnv(t, "SECRETS_PROVIDER", "vault")
+	if _, err := secretsProvider(); err == nil {
+		t.Fatal("Expected an error for an unknown provider")
+	}
+}
+
+func TestGetSecret_FallsBackToEnv(t *testing.T) {
+	silenceLogs(t)
+	withEnv(t, "AUTH_JWT_SECRET", "from-env")
+	if got := getSecret("AUTH_JWT_SECRET"); got != "from-env" {
+		t.Fatalf("Expected the environment value, got %q", got)
+	}
+
+	withEnv(t, "SECRETS_PROVIDER", "unknown")
+	if got := getSecret("AUTH_JWT_SECRET"); got != "from-env" {
+		t.Fatalf("Expected a misconfigured provider to fall back to the environment, got %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package followercount
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Headers carrying the detached signature of a response body and the key it
+// was made with.
+const (
+	responseSignatureHeader = "X-Response-Signature"
+	responseKeyIDHeader     = "X-Response-Key-Id"
+)
+
+// errSigningMisconfigured is reported, as an internal error, when the
+// signing key can't be read; unsigned responses would fail verification.
+var errSigningMisconfigured = errors.New("response signing misconfigured")
+
+// responseSigningKey reads RESPONSE_SIGNING_KEY, the base64 Ed25519 private
+// key (or its 32-byte seed) responses are signed with. It returns nil when
+// signing is disabled.
+func responseSigningKey() (ed25519.PrivateKey, error) {
+	encoded := strings.TrimSpace(getSecret("RESPONSE_SIGNING_KEY"))
+	if encoded == "" {
+		return nil, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("RESPONSE_SIGNING_KEY is not valid base64: %w", err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	}
+	return nil, fmt.Errorf("RESPONSE_SIGNING_KEY has %d bytes, want %d or %d", len(raw), ed25519.SeedSize, ed25519.PrivateKeySize)
+}
+
+// signingKeyID identifies a public key: the hex of the first 8 bytes of its
+// SHA-256, so consumers can tell keys apart across rotations.
+func signingKeyID(public ed25519.PublicKey) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:8])
+}
+
+// withSignature signs each response body with the deployment's Ed25519 key
+// when RESPONSE_SIGNING_KEY is set, so consumers such as webhook automation
+// can verify where a result came from and that it wasn't altered. The
+// signature covers the exact bytes sent, so it must wrap withKeyCase.
+// Signed responses are buffered whole, which gives up CSV streaming.
+func withSignature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := responseSigningKey()
+		if err != nil {
+			log.Printf("Response signing misconfigured: %v", err)
+			sendDomainError(w, errSigningMisconfigured)
+			return
+		}
+		if key == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+		body := buf.body.Bytes()
+		w.Header().Set(responseSignatureHeader, base64.StdEncoding.EncodeToString(ed25519.Sign(key, body)))
+		w.Header().Set(responseKeyIDHeader, signingKeyID(key.Public().(ed25519.PublicKey)))
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(buf.status)
+		w.Write(body)
+	})
+}
+
+// SigningKey is the public half of the response signing key, as served by
+// the signing-key endpoint.
+type SigningKey struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	// PublicKey is the base64 raw 32-byte Ed25519 public key.
+	PublicKey string `json:"public_key"`
+}
+
+var signingKeyHandler = chain(
+	http.HandlerFunc(serveSigningKey),
+	withRequestID,
+	withRecovery,
+	withLogging,
+	withCORS,
+	withMethod(http.MethodGet),
+)
+
+// serveSigningKey publishes the public key responses are signed with, or 404
+// when signing is disabled.
+func serveSigningKey(w http.ResponseWriter, r *http.Request) {
+	key, err := responseSigningKey()
+	if err != nil {
+		log.Printf("Response signing misconfigured: %v", err)
+		sendDomainError(w, errSigningMisconfigured)
+		return
+	}
+	if key == nil {
+		sendDomainError(w, withDetail(ErrNotEnabled, "Response signing is not enabled"))
+		return
+	}
+	public := key.Public().(ed25519.PublicKey)
+	sendJSON(w, http.StatusOK, SigningKey{
+		Algorithm: "ed25519",
+		KeyID:     signingKeyID(public),
+		PublicKey: base64.StdEncoding.EncodeToString(public),
+	})
+}
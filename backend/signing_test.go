@@ -0,0 +1,76 @@
+package followercount
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithSignature(t *testing.T) {
+	silenceLogs(t)
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	public := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+
+	h := withSignature(withKeyCase(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, http.StatusOK, APIResponse{Success: true, TotalFollowing: 2})
+	})))
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?case=camel", nil))
+		return w
+	}
+
+	withEnv(t, "RESPONSE_SIGNING_KEY", "")
+	if w := request(); w.Header().Get(responseSignatureHeader) != "" {
+		t.Fatal("Expected no signature when signing is disabled")
+	}
+
+	withEnv(t, "RESPONSE_SIGNING_KEY", base64.StdEncoding.EncodeToString(seed))
+	w := request()
+	signature, err := base64.StdEncoding.DecodeString(w.Header().Get(responseSignatureHeader))
+	if err != nil {
+		t.Fatalf("Invalid signature header: %v", err)
+	}
+	if !ed25519.Verify(public, w.Body.Bytes(), signature) {
+		t.Fatalf("Signature doesn't verify against the body sent: %s", w.Body.String())
+	}
+	if w.Header().Get(responseKeyIDHeader) != signingKeyID(public) {
+		t.Fatalf("Expected key ID %s, got %s", signingKeyID(public), w.Header().Get(responseKeyIDHeader))
+	}
+
+	withEnv(t, "RESPONSE_SIGNING_KEY", "bm90IGEga2V5")
+	if w := request(); w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500 for a malformed key, got %d", w.Code)
+	}
+}
+
+func TestServeSigningKey(t *testing.T) {
+	silenceLogs(t)
+	withEnv(t, "RESPONSE_SIGNING_KEY", "")
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodGet, "/signing-key", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 when signing is disabled, got %d", w.Code)
+	}
+
+	_, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withEnv(t, "RESPONSE_SIGNING_KEY", base64.StdEncoding.EncodeToString(private))
+	w = httptest.NewRecorder()
+	AnalyzeFollowers(w, httptest.NewRequest(http.MethodGet, "/signing-key", nil))
+
+	var key SigningKey
+	json.NewDecoder(w.Body).Decode(&key)
+	want := base64.StdEncoding.EncodeToString(private.Public().(ed25519.PublicKey))
+	if w.Code != http.StatusOK || key.Algorithm != "ed25519" || key.PublicKey != want {
+		t.Fatalf("Expected the public key, got %d %+v", w.Code, key)
+	}
+}
@@ -0,0 +1,44 @@
+package followercount
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+
+	"github.com/afaafhariri/follower-watch/backend/analysis"
+)
+
+// SlimBundle is the JSON upload format for clients that extract the
+// relationship files in the browser and send only those, instead of the
+// whole export. Files maps each file's path inside the export to its raw
+// JSON content, e.g.
+//
+//	{"files": {"connections/followers_and_following/following.json": {...}}}
+type SlimBundle struct {
+	Files map[string]json.RawMessage `json:"files"`
+}
+
+func isSlimBundle(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// slimBundleFS exposes the files of a slim bundle as an fs.FS, so they go
+// through exactly the same extraction code as a full export.
+func slimBundleFS(data []byte) (fs.FS, error) {
+	var bundle SlimBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("decoding slim bundle: %w", err)
+	}
+	if len(bundle.Files) == 0 {
+		return nil, fmt.Errorf("slim bundle contains no files")
+	}
+
+	files := make(map[string][]byte, len(bundle.Files))
+	for name, content := range bundle.Files {
+		files[name] = content
+	}
+	return analysis.MemFS(files)
+}
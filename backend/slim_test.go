@@ -0,0 +1,56 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnalyzeFollowers_SlimBundle(t *testing.T) {
+	resetRateLimiter()
+	defer resetRateLimiter()
+
+	bundle := `{"files": {
+		"connections/followers_and_following/followers_1.json": [
+			{"string_list_data": [{"value": "user1", "timestamp": 1234567890}]}
+		],
+		"connections/followers_and_following/following.json": {
+			"relationships_following": [
+				{"title": "user1", "string_list_data": [{"href": "https://instagram.com/user1", "timestamp": 1234567890}]},
+				{"title": "user2", "string_list_data": [{"href": "https://instagram.com/user2", "timestamp": 1234567891}]}
+			]
+		}
+	}}`
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(bundle)))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	AnalyzeFollowers(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var apiResponse APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&apiResponse); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if apiResponse.Count != 1 || apiResponse.NonFollowers[0].Username != "user2" {
+		t.Fatalf("Expected user2 as the only non-follower, got %+v", apiResponse.NonFollowers)
+	}
+}
+
+func TestSlimBundleFS_Invalid(t *testing.T) {
+	for _, bundle := range []string{
+		`{"files": {}}`,
+		`{"files": {"../followers_1.json": []}}`,
+	} {
+		if _, err := slimBundleFS([]byte(bundle)); err == nil {
+			t.Errorf("Expected an error for %s", bundle)
+		}
+	}
+}
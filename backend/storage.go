@@ -0,0 +1,108 @@
+package followercount
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/storage"
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+// StorageObjectData is the payload of a google.cloud.storage.object.v1.finalized
+// event. Only the fields needed to locate the object are decoded.
+type StorageObjectData struct {
+	Bucket string `json:"bucket"`
+	Name   string `json:"name"`
+}
+
+var (
+	storageClientOnce sync.Once
+	storageClient     *storage.Client
+	storageClientErr  error
+)
+
+func getStorageClient(ctx context.Context) (*storage.Client, error) {
+	storageClientOnce.Do(func() {
+		storageClient, storageClientErr = storage.NewClient(ctx)
+	})
+	return storageClient, storageClientErr
+}
+
+// resultObjectName returns where the analysis of an uploaded export is
+// written: next to the export, with the ".zip" extension replaced by
+// ".result.json".
+func resultObjectName(name string) string {
+	return name[:len(name)-len(".zip")] + ".result.json"
+}
+
+// analyzeExportBytes analyzes an export already read into memory and returns
+// the response body, turning domain errors into an unsuccessful APIResponse
+// like the HTTP handler does.
+func analyzeExportBytes(ctx context.Context, data []byte) APIResponse {
+	fsys, err := openUpload(ctx, data, "", false)
+	if err == nil {
+		var response APIResponse
+		if response, err = analyzeArchive(ctx, fsys); err == nil {
+			return response
+		}
+	}
+
+	_, response := errorResponse(err)
+	return response
+}
+
+// ProcessStorageExport is triggered when an object is finalized in a Cloud
+// Storage bucket. Export ZIPs dropped into the bucket are analyzed and the
+// result is written next to them as <name>.result.json; other objects,
+// including the results themselves, are ignored.
+func ProcessStorageExport(ctx context.Context, e event.Event) error {
+	var data StorageObjectData
+	if err := e.DataAs(&data); err != nil {
+		return fmt.Errorf("decoding storage event: %w", err)
+	}
+	if !strings.HasSuffix(strings.ToLower(data.Name), ".zip") {
+		return nil
+	}
+
+	client, err := getStorageClient(ctx)
+	if err != nil {
+		return fmt.Errorf("creating storage client: %w", err)
+	}
+	bucket := client.Bucket(data.Bucket)
+
+	reader, err := bucket.Object(data.Name).NewReader(ctx)
+	if err != nil {
+		return fmt.Errorf("opening gs://%s/%s: %w", data.Bucket, data.Name, err)
+	}
+	defer reader.Close()
+
+	var response APIResponse
+	if reader.Attrs.Size > maxUploadSize {
+		_, response = errorResponse(ErrUploadTooLarge)
+	} else {
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("reading gs://%s/%s: %w", data.Bucket, data.Name, err)
+		}
+		response = analyzeExportBytes(ctx, content)
+	}
+
+	resultName := resultObjectName(data.Name)
+	writer := bucket.Object(resultName).NewWriter(ctx)
+	writer.ContentType = "application/json"
+	if err := json.NewEncoder(writer).Encode(response); err != nil {
+		writer.Close()
+		return fmt.Errorf("writing gs://%s/%s: %w", data.Bucket, resultName, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("writing gs://%s/%s: %w", data.Bucket, resultName, err)
+	}
+
+	log.Printf("Analyzed gs://%s/%s (success=%v)", data.Bucket, data.Name, response.Success)
+	return nil
+}
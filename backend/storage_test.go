@@ -0,0 +1,99 @@
+package followercount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+func TestResultObjectName(t *testing.T) {
+	tests := map[string]string{
+		"exports/me.zip": "exports/me.result.json",
+		"exports/me.ZIP": "exports/me.result.json",
+	}
+	for name, want := range tests {
+		if got := resultObjectName(name); got != want {
+			t.Errorf("resultObjectName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestAnalyzeExportBytes(t *testing.T) {
+	silenceLogs(t)
+
+	data, err := GenerateExport(4, 6)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+	if response := analyzeExportBytes(context.Background(), data); !response.Success || response.Count != 3 {
+		t.Fatalf("Expected a successful analysis with 3 non-followers, got %+v", response)
+	}
+
+	if response := analyzeExportBytes(context.Background(), []byte("not a zip")); response.Success || response.Error == "" {
+		t.Fatalf("Expected an error response for a non-ZIP object, got %+v", response)
+	}
+
+	withScanner(t, stubScanner{result: ScanResult{Detail: "EICAR"}})
+	if response := analyzeExportBytes(context.Background(), data); response.ErrorCode != "upload_rejected" {
+		t.Fatalf("Expected the scanners to reject the object, got %+v", response)
+	}
+}
+
+func TestProcessStorageExport_IgnoresNonZip(t *testing.T) {
+	e := event.New()
+	e.SetType("google.cloud.storage.object.v1.finalized")
+	if err := e.SetData(event.ApplicationJSON, StorageObjectData{Bucket: "bucket", Name: "exports/me.result.json"}); err != nil {
+		t.Fatalf("Failed to build event: %v", err)
+	}
+
+	if err := ProcessStorageExport(context.Background(), e); err != nil {
+		t.Fatalf("Expected non-ZIP objects to be ignored, got %v", err)
+	}
+}
+
+// TestStorageHTTPParity runs the same uploads through the HTTP handler and
+// the Cloud Storage path and requires byte-identical response bodies, so the
+// two entry points can't drift apart.
+func TestStorageHTTPParity(t *testing.T) {
+	silenceLogs(t)
+
+	export, err := GenerateExport(5, 8)
+	if err != nil {
+		t.Fatalf("Failed to generate export: %v", err)
+	}
+	followersOnly := createTestZip(t, map[string]string{
+		"connections/followers_and_following/followers_1.json": `[{"string_list_data": [{"value": "user1"}]}]`,
+	})
+
+	uploads := map[string][]byte{
+		"valid export":  export,
+		"no following":  followersOnly,
+		"not a zip":     []byte("not a zip file"),
+		"corrupt zip":   []byte("PK\x03\x04 truncated"),
+		"empty archive": createTestZip(t, map[string]string{}),
+	}
+
+	for name, upload := range uploads {
+		t.Run(name, func(t *testing.T) {
+			resetRateLimiter()
+			defer resetRateLimiter()
+
+			w := httptest.NewRecorder()
+			AnalyzeFollowers(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(upload)))
+
+			storageBody := new(bytes.Buffer)
+			if err := json.NewEncoder(storageBody).Encode(analyzeExportBytes(context.Background(), upload)); err != nil {
+				t.Fatalf("Failed to encode storage response: %v", err)
+			}
+
+			if !bytes.Equal(w.Body.Bytes(), storageBody.Bytes()) {
+				t.Fatalf("Responses differ:\nHTTP:    %s\nstorage: %s", w.Body.Bytes(), storageBody.Bytes())
+			}
+		})
+	}
+}
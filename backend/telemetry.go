@@ -0,0 +1,134 @@
+package followercount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Version identifies the build in telemetry. Release builds set it with
+// -ldflags "-X github.com/afaafhariri/follower-watch/backend.Version=v1.2.3".
+var Version = "dev"
+
+const telemetryTimeout = 2 * time.Second
+
+// TelemetryEvent is everything reported about a request when telemetry is
+// enabled. It deliberately has no field that could hold user data: no IPs,
+// origins, usernames, file names or exact sizes.
+type TelemetryEvent struct {
+	Version    string `json:"version"`
+	Platform   string `json:"platform"`
+	Endpoint   string `json:"endpoint"`
+	Status     int    `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	SizeBucket string `json:"size_bucket"`
+	// MemoryBucket is the request's coarsened peak heap, when measured.
+	MemoryBucket string `json:"memory_bucket,omitempty"`
+}
+
+func telemetryEnabled() bool {
+	return getEnv("OPT_IN_TELEMETRY") == "true" && getEnv("TELEMETRY_ENDPOINT") != ""
+}
+
+// platform names the runtime without identifying the deployment.
+func platform() string {
+	if os.Getenv("K_SERVICE") != "" || os.Getenv("FUNCTION_TARGET") != "" {
+		return "cloud-functions"
+	}
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// sizeBucket coarsens an upload size so exports can't be fingerprinted.
+func sizeBucket(n int64) string {
+	const mb = 1024 * 1024
+	switch {
+	case n < mb:
+		return "<1MB"
+	case n < 10*mb:
+		return "1-10MB"
+	case n < 25*mb:
+		return "10-25MB"
+	default:
+		return "25MB+"
+	}
+}
+
+// memoryBucket coarsens a peak heap size the same way.
+func memoryBucket(n uint64) string {
+	const mb = 1024 * 1024
+	switch {
+	case n < 128*mb:
+		return "<128MB"
+	case n < 256*mb:
+		return "128-256MB"
+	case n < 512*mb:
+		return "256-512MB"
+	default:
+		return "512MB+"
+	}
+}
+
+// reportTelemetry posts the event in the background. Failures are logged and
+// otherwise ignored; telemetry must never affect a request.
+func reportTelemetry(event TelemetryEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	endpoint := getEnv("TELEMETRY_ENDPOINT")
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), telemetryTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			log.Printf("telemetry: %v", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Printf("telemetry: %v", err)
+			return
+		}
+		resp.Body.Close()
+	}()
+}
+
+// withTelemetry reports anonymous request metrics for the named endpoint when
+// the operator has set OPT_IN_TELEMETRY=true and a TELEMETRY_ENDPOINT.
+func withTelemetry(endpoint string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !telemetryEnabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			body := &countingReader{ReadCloser: r.Body}
+			r.Body = body
+			next.ServeHTTP(rec, r)
+
+			event := TelemetryEvent{
+				Version:    Version,
+				Platform:   platform(),
+				Endpoint:   endpoint,
+				Status:     rec.status,
+				DurationMS: time.Since(start).Milliseconds(),
+				SizeBucket: sizeBucket(body.n),
+			}
+			if b := budgetFromContext(r.Context()); b != nil {
+				event.MemoryBucket = memoryBucket(b.snapshot().PeakHeapBytes)
+			}
+			reportTelemetry(event)
+		})
+	}
+}
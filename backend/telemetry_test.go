@@ -0,0 +1,74 @@
+package followercount
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSizeBucket(t *testing.T) {
+	tests := map[int64]string{
+		0:                "<1MB",
+		2 * 1024 * 1024:  "1-10MB",
+		12 * 1024 * 1024: "10-25MB",
+		40 * 1024 * 1024: "25MB+",
+	}
+	for n, want := range tests {
+		if got := sizeBucket(n); got != want {
+			t.Errorf("sizeBucket(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestMemoryBucket(t *testing.T) {
+	tests := map[uint64]string{
+		0:         "<128MB",
+		200 << 20: "128-256MB",
+		300 << 20: "256-512MB",
+		2 << 30:   "512MB+",
+	}
+	for n, want := range tests {
+		if got := memoryBucket(n); got != want {
+			t.Errorf("memoryBucket(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestWithTelemetry(t *testing.T) {
+	events := make(chan TelemetryEvent, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event TelemetryEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+	}))
+	defer collector.Close()
+
+	withEnv(t, "OPT_IN_TELEMETRY", "true")
+	withEnv(t, "TELEMETRY_ENDPOINT", collector.URL)
+
+	h := withTelemetry("analyze")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("upload")))
+
+	select {
+	case event := <-events:
+		if event.Endpoint != "analyze" || event.Status != http.StatusBadRequest || event.SizeBucket != "<1MB" || event.Version == "" {
+			t.Fatalf("Unexpected event: %+v", event)
+		}
+	case <-time.After(telemetryTimeout):
+		t.Fatal("No telemetry event received")
+	}
+}
+
+func TestWithTelemetry_Disabled(t *testing.T) {
+	withEnv(t, "OPT_IN_TELEMETRY", "")
+	withEnv(t, "TELEMETRY_ENDPOINT", "http://127.0.0.1:0")
+
+	if telemetryEnabled() {
+		t.Fatal("Expected telemetry to be off unless OPT_IN_TELEMETRY=true")
+	}
+}
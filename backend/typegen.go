@@ -0,0 +1,199 @@
+package followercount
+
+//go:generate go run ./cmd/gentypes -ts ../frontend/src/types/api.ts -schema ../frontend/src/types/api.schema.json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// typeField is a JSON field of a struct in the response schema.
+type typeField struct {
+	name     string
+	typ      reflect.Type
+	optional bool
+}
+
+// schemaTypes walks the types reachable from APIResponse, the contract
+// between the backend and its clients, in the order they are first used.
+type schemaTypes struct {
+	order  []reflect.Type
+	fields map[reflect.Type][]typeField
+}
+
+func collectSchemaTypes() *schemaTypes {
+	s := &schemaTypes{fields: make(map[reflect.Type][]typeField)}
+	s.visit(reflect.TypeOf(APIResponse{}))
+	return s
+}
+
+func (s *schemaTypes) visit(t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		s.visit(t.Elem())
+		return
+	case reflect.Struct:
+	default:
+		return
+	}
+	if _, seen := s.fields[t]; seen {
+		return
+	}
+	s.order = append(s.order, t)
+	s.fields[t] = nil
+
+	var fields []typeField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, typeField{name: name, typ: f.Type, optional: strings.Contains(opts, "omitempty")})
+	}
+	s.fields[t] = fields
+	for _, f := range fields {
+		s.visit(f.typ)
+	}
+}
+
+// nullable reports whether encoding/json writes null for the zero value of
+// a field that isn't omitted when empty.
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// tsType returns the TypeScript type of t, without null.
+func tsType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return tsType(t.Elem())
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		elem := tsType(t.Elem())
+		if nullable(t.Elem()) {
+			elem = "(" + elem + " | null)"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + tsType(t.Elem()) + ">"
+	case reflect.Struct:
+		return t.Name()
+	}
+	return "unknown"
+}
+
+// GenerateTypeScript returns TypeScript interfaces for APIResponse and every
+// type it contains, named after the Go types. Fields omitted when empty are
+// optional, and fields encoded as null when empty are typed as such.
+func GenerateTypeScript() []byte {
+	s := collectSchemaTypes()
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gentypes from the Go response types; DO NOT EDIT.\n")
+	for _, t := range s.order {
+		fmt.Fprintf(&b, "\nexport interface %s {\n", t.Name())
+		for _, f := range s.fields[t] {
+			typ := tsType(f.typ)
+			if !f.optional && nullable(f.typ) {
+				typ += " | null"
+			}
+			mark := ""
+			if f.optional {
+				mark = "?"
+			}
+			fmt.Fprintf(&b, "  %s%s: %s;\n", f.name, mark, typ)
+		}
+		b.WriteString("}\n")
+	}
+	return b.Bytes()
+}
+
+// jsonSchema returns the JSON Schema of t, without null.
+func jsonSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch tsType(t) {
+	case "boolean":
+		return map[string]any{"type": "boolean"}
+	case "string":
+		return map[string]any{"type": "string"}
+	case "number":
+		if k := t.Kind(); k == reflect.Float32 || k == reflect.Float64 {
+			return map[string]any{"type": "number"}
+		}
+		return map[string]any{"type": "integer"}
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": withNull(t.Elem(), nullable(t.Elem()))}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchema(t.Elem())}
+	case reflect.Struct:
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	}
+	return map[string]any{}
+}
+
+func withNull(t reflect.Type, null bool) map[string]any {
+	schema := jsonSchema(t)
+	if !null {
+		return schema
+	}
+	return map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+}
+
+// GenerateJSONSchema returns a JSON Schema (draft 2020-12) describing
+// APIResponse, with the types it contains under $defs.
+func GenerateJSONSchema() ([]byte, error) {
+	s := collectSchemaTypes()
+	defs := make(map[string]any, len(s.order))
+	for _, t := range s.order {
+		properties := make(map[string]any)
+		required := []string{}
+		for _, f := range s.fields[t] {
+			properties[f.name] = withNull(f.typ, !f.optional && nullable(f.typ))
+			if !f.optional {
+				required = append(required, f.name)
+			}
+		}
+		defs[t.Name()] = map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+	}
+
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "APIResponse",
+		"$ref":    "#/$defs/APIResponse",
+		"$defs":   defs,
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
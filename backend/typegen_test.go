@@ -0,0 +1,85 @@
+package followercount
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestGeneratedTypesUpToDate fails when a response type changed without
+// regenerating the frontend's types; run `go generate` to fix it.
+func TestGeneratedTypesUpToDate(t *testing.T) {
+	schema, err := GenerateJSONSchema()
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema failed: %v", err)
+	}
+	for path, want := range map[string][]byte{
+		"../frontend/src/types/api.ts":          GenerateTypeScript(),
+		"../frontend/src/types/api.schema.json": schema,
+	} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Missing generated file %s (run go generate): %v", path, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date; run go generate", path)
+		}
+	}
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	ts := string(GenerateTypeScript())
+
+	for _, want := range []string{
+		"export interface APIResponse {\n  success: boolean;\n",
+		"  non_followers?: NonFollower[];\n",
+		"  platforms?: Record<string, Result>;\n",
+		"  interaction?: Interaction;\n",
+		// Non-omitempty slices and pointers encode nil as null.
+		"  quality: QualityReport | null;\n",
+		"  days: PlanDay[] | null;\n",
+	} {
+		if !strings.Contains(ts, want) {
+			t.Errorf("Expected the TypeScript to contain %q", want)
+		}
+	}
+	if strings.Count(ts, "export interface Result {") != 1 {
+		t.Error("Expected the recursive Result type to be declared once")
+	}
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	data, err := GenerateJSONSchema()
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema failed: %v", err)
+	}
+	var schema struct {
+		Ref  string `json:"$ref"`
+		Defs map[string]struct {
+			Properties map[string]map[string]any `json:"properties"`
+			Required   []string                  `json:"required"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Invalid schema JSON: %v", err)
+	}
+	if schema.Ref != "#/$defs/APIResponse" {
+		t.Fatalf("Expected the schema to describe APIResponse, got %q", schema.Ref)
+	}
+
+	response := schema.Defs["APIResponse"]
+	if len(response.Required) != 1 || response.Required[0] != "success" {
+		t.Fatalf("Expected only success to be required, got %v", response.Required)
+	}
+	if got := response.Properties["count"]["type"]; got != "integer" {
+		t.Fatalf("Expected count to be an integer, got %v", got)
+	}
+	if got := schema.Defs["QualityMetric"].Properties["percent"]["type"]; got != "number" {
+		t.Fatalf("Expected percent to be a number, got %v", got)
+	}
+	if _, ok := schema.Defs["SkippedAnalyzer"]; !ok {
+		t.Fatal("Expected the types of new sections to be included")
+	}
+}
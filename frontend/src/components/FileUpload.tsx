@@ -4,6 +4,17 @@ import CloudUploadIcon from "@mui/icons-material/CloudUpload";
 import FolderZipIcon from "@mui/icons-material/FolderZip";
 import { API_ENDPOINTS, UPLOAD_CONFIG, RETRY_CONFIG } from "../config";
 import type { AnalysisResult, ApiError } from "../types";
+import { buildSlimBundle } from "../utils/slimBundle";
+
+// UploadError is a failed upload with the backend's error_code, if any.
+class UploadError extends Error {
+  constructor(
+    message: string,
+    readonly code?: string,
+  ) {
+    super(message);
+  }
+}
 
 interface FileUploadProps {
   isUploading: boolean;
@@ -41,40 +52,86 @@ export const FileUpload = ({
     return null;
   };
 
+  // SHA-256 of the upload so the backend can detect corrupted uploads.
+  // crypto.subtle is only available in secure contexts.
+  const sha256Hex = async (body: Blob): Promise<string | null> => {
+    if (!window.crypto?.subtle) {
+      return null;
+    }
+    const digest = await window.crypto.subtle.digest(
+      "SHA-256",
+      await body.arrayBuffer(),
+    );
+    return Array.from(new Uint8Array(digest))
+      .map((b) => b.toString(16).padStart(2, "0"))
+      .join("");
+  };
+
   const uploadWithRetry = async (
-    file: File,
+    body: Blob,
+    contentType: string,
     retryCount = 0,
   ): Promise<AnalysisResult> => {
     try {
+      const headers: Record<string, string> = {
+        "Content-Type": contentType,
+      };
+      const checksum = await sha256Hex(body);
+      if (checksum) {
+        headers["X-Content-SHA256"] = checksum;
+      }
+
       const response = await fetch(API_ENDPOINTS.analyze, {
         method: "POST",
-        body: file,
-        headers: {
-          "Content-Type": "application/zip",
-        },
+        body,
+        headers,
       });
 
       const data = await response.json();
 
       if (!response.ok) {
         const errorData = data as ApiError;
-        throw new Error(errorData.error || "Upload failed");
+        throw new UploadError(
+          errorData.hint || errorData.error || "Upload failed",
+          errorData.error_code,
+        );
       }
 
       return data as AnalysisResult;
     } catch (error) {
-      if (retryCount < RETRY_CONFIG.maxRetries) {
+      const invalidBundle =
+        error instanceof UploadError && error.code === "invalid_bundle";
+      if (!invalidBundle && retryCount < RETRY_CONFIG.maxRetries) {
         const delay = Math.min(
           RETRY_CONFIG.baseDelay * Math.pow(2, retryCount),
           RETRY_CONFIG.maxDelay,
         );
         await new Promise((resolve) => setTimeout(resolve, delay));
-        return uploadWithRetry(file, retryCount + 1);
+        return uploadWithRetry(body, contentType, retryCount + 1);
       }
       throw error;
     }
   };
 
+  // Uploads only the relationship files, extracted in the browser, when
+  // possible, and the whole ZIP otherwise or when the backend can't read
+  // the slim bundle.
+  const uploadExport = async (file: File): Promise<AnalysisResult> => {
+    const bundle = await buildSlimBundle(file);
+    if (bundle) {
+      try {
+        return await uploadWithRetry(bundle, "application/json");
+      } catch (error) {
+        const invalidBundle =
+          error instanceof UploadError && error.code === "invalid_bundle";
+        if (!invalidBundle) {
+          throw error;
+        }
+      }
+    }
+    return uploadWithRetry(file, "application/zip");
+  };
+
   const handleUpload = async (file: File) => {
     const validationError = validateFile(file);
     if (validationError) {
@@ -97,7 +154,7 @@ export const FileUpload = ({
     }, 200);
 
     try {
-      const result = await uploadWithRetry(file);
+      const result = await uploadExport(file);
       clearInterval(progressInterval);
       setUploadProgress(100);
 
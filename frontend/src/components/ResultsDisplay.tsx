@@ -192,6 +192,33 @@ export const ResultsDisplay = ({ result, onReset }: ResultsDisplayProps) => {
         </Paper>
       </Stack>
 
+      {/* Insights */}
+      {result.insights && result.insights.length > 0 && (
+        <Stack
+          direction={{ xs: "column", sm: "row" }}
+          spacing={2}
+          sx={{ mb: 3 }}
+          flexWrap="wrap"
+          useFlexGap
+        >
+          {result.insights.map((insight) => (
+            <Paper
+              key={insight.code}
+              elevation={0}
+              sx={{
+                p: 2,
+                flex: "1 1 220px",
+                border: "1px solid",
+                borderColor: "grey.200",
+                borderRadius: 2,
+              }}
+            >
+              <Typography variant="body2">{insight.message}</Typography>
+            </Paper>
+          ))}
+        </Stack>
+      )}
+
       {/* Action Buttons */}
       <Stack
         direction={{ xs: "column", sm: "row" }}
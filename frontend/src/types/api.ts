@@ -0,0 +1,253 @@
+// Code generated by gentypes from the Go response types; DO NOT EDIT.
+
+export interface APIResponse {
+  success: boolean;
+  non_followers?: NonFollower[];
+  total_following?: number;
+  total_followers?: number;
+  count?: number;
+  matched_count?: number;
+  recommended_daily_unfollows?: number;
+  action_limit_warning?: string;
+  error?: string;
+  error_code?: string;
+  hint?: string;
+  locale?: string;
+  message?: string;
+  overlap?: OverlapReport;
+  plan?: CleanupPlan;
+  quality?: QualityReport;
+  stats?: Stats;
+  insights?: Insight[];
+  warnings?: Warning[];
+  possible_duplicates?: DuplicateGroup[];
+  processing_receipt?: ProcessingReceipt;
+  data_as_of?: string;
+  milestones?: Milestone[];
+  other_relationships?: OtherRelationship[];
+  pending_requests?: PendingRequest[];
+  partial?: boolean;
+  skipped_analyzers?: SkippedAnalyzer[];
+  preview?: boolean;
+  request_id?: string;
+  platforms?: Record<string, Result>;
+  mutuals?: Mutual[];
+  fans?: Fan[];
+  strongest_connections?: Connection[];
+  weakest_connections?: Connection[];
+  groups?: AlphaGroup[];
+  manifest?: Manifest;
+  budget?: RequestBudget;
+}
+
+export interface NonFollower {
+  username: string;
+  display_name?: string;
+  profile_url: string;
+  followed_at?: number;
+  source?: string;
+  interaction?: Interaction;
+}
+
+export interface Interaction {
+  likes: number;
+  comments: number;
+  last_liked_at?: number;
+  last_commented_at?: number;
+  last_interaction_at?: number;
+}
+
+export interface OverlapReport {
+  shared_followers: SharedFollower[] | null;
+  primary_only: string[] | null;
+  secondary_only: string[] | null;
+  primary_followers: number;
+  secondary_followers: number;
+}
+
+export interface SharedFollower {
+  username: string;
+  profile_url: string;
+  primary_followed_at?: number;
+  secondary_followed_at?: number;
+  followed_first?: string;
+}
+
+export interface CleanupPlan {
+  per_day: number;
+  total_days: number;
+  total_count: number;
+  days: PlanDay[] | null;
+}
+
+export interface PlanDay {
+  date: string;
+  priority: string;
+  usernames: string[] | null;
+}
+
+export interface QualityReport {
+  spam_likely: QualityMetric;
+  brand: QualityMetric;
+}
+
+export interface QualityMetric {
+  count: number;
+  percent: number;
+  baseline: number;
+  comparison: string;
+}
+
+export interface Stats {
+  timeline: ReciprocityBucket[] | null;
+  heatmap: ActivityHeatmaps;
+}
+
+export interface ReciprocityBucket {
+  period: string;
+  followed: number;
+  followed_back: number;
+  rate: number;
+}
+
+export interface ActivityHeatmaps {
+  following: number[][];
+  followers: number[][];
+}
+
+export interface Insight {
+  code: string;
+  message: string;
+  count: number;
+}
+
+export interface Warning {
+  code: string;
+  message: string;
+  file?: string;
+}
+
+export interface DuplicateGroup {
+  base: string;
+  usernames: string[] | null;
+}
+
+export interface ProcessingReceipt {
+  files: ReceiptFile[] | null;
+  bytes_processed: number;
+  parsers: Record<string, string> | null;
+  content_hash: string;
+}
+
+export interface ReceiptFile {
+  name: string;
+  kind: string;
+  bytes: number;
+  sha256: string;
+}
+
+export interface Milestone {
+  code: string;
+  message: string;
+  username?: string;
+  at?: number;
+}
+
+export interface OtherRelationship {
+  title: string;
+  count: number;
+  sample: string[] | null;
+}
+
+export interface PendingRequest {
+  username: string;
+  profile_url: string;
+  requested_at?: number;
+  pending_days?: number;
+  consider_cancelling: boolean;
+}
+
+export interface SkippedAnalyzer {
+  name: string;
+  reason: string;
+}
+
+export interface Result {
+  non_followers: NonFollower[] | null;
+  total_following: number;
+  total_followers: number;
+  quality: QualityReport | null;
+  stats: Stats | null;
+  insights: Insight[] | null;
+  warnings?: Warning[];
+  possible_duplicates?: DuplicateGroup[];
+  processing_receipt?: ProcessingReceipt;
+  data_as_of?: string;
+  milestones?: Milestone[];
+  other_relationships?: OtherRelationship[];
+  pending_requests?: PendingRequest[];
+  partial?: boolean;
+  skipped_analyzers?: SkippedAnalyzer[];
+  platforms?: Record<string, Result>;
+  mutuals?: Mutual[];
+  fans?: Fan[];
+  strongest_connections?: Connection[];
+  weakest_connections?: Connection[];
+}
+
+export interface Mutual {
+  username: string;
+  display_name?: string;
+  profile_url: string;
+  followed_at?: number;
+  followed_you_at?: number;
+}
+
+export interface Fan {
+  username: string;
+  display_name?: string;
+  profile_url: string;
+  followed_at?: number;
+  source?: string;
+}
+
+export interface Connection {
+  username: string;
+  display_name?: string;
+  profile_url: string;
+  score: number;
+  mutual_since?: number;
+  interaction?: Interaction;
+  close_friend?: boolean;
+}
+
+export interface AlphaGroup {
+  letter: string;
+  count: number;
+  non_followers: NonFollower[] | null;
+}
+
+export interface Manifest {
+  files: ManifestFile[] | null;
+  analyzers: AnalyzerStatus[] | null;
+  analyzable: boolean;
+}
+
+export interface ManifestFile {
+  name: string;
+  kind: string;
+  bytes: number;
+}
+
+export interface AnalyzerStatus {
+  name: string;
+  available: boolean;
+  reason?: string;
+}
+
+export interface RequestBudget {
+  duration_ms: number;
+  alloc_bytes: number;
+  peak_heap_bytes: number;
+  limit_bytes?: number;
+}
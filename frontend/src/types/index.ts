@@ -1,21 +1,43 @@
-export interface NonFollower {
-  username: string;
-  profile_url: string;
-  followed_at?: number;
-}
+// Response types are generated from the backend's Go structs; run
+// `go generate` in backend/ after changing them.
+import type { APIResponse, NonFollower } from "./api";
+
+export type {
+  AlphaGroup,
+  APIResponse,
+  AnalyzerStatus,
+  Connection,
+  DuplicateGroup,
+  Fan,
+  Insight,
+  Interaction,
+  Manifest,
+  ManifestFile,
+  Milestone,
+  Mutual,
+  NonFollower,
+  OtherRelationship,
+  PendingRequest,
+  Result as PlatformResult,
+  SkippedAnalyzer,
+  Warning,
+} from "./api";
 
-export interface AnalysisResult {
-  success: boolean;
+// AnalysisResult is a successful analysis response, whose counts and list
+// are always present.
+export interface AnalysisResult extends APIResponse {
   non_followers: NonFollower[];
   total_following: number;
   total_followers: number;
   count: number;
-  message?: string;
 }
 
 export interface ApiError {
   success: false;
   error: string;
+  error_code?: string;
+  hint?: string;
+  locale?: string;
 }
 
 export type AppStatus = "idle" | "uploading" | "success" | "error";
@@ -0,0 +1,161 @@
+// Builds a slim bundle, the backend's JSON upload format, from an export ZIP
+// in the browser, so only the relationship files are uploaded instead of the
+// whole export with its photos and videos. See "Slim uploads" in the README.
+
+// Files the backend reads, mirroring backend/analysis/rules.json.
+const RELEVANT_FILES = [
+  /followers(_\d+)?\.json$/i,
+  /following[^/]*\.json$/i,
+  /(^|\/)recently_unfollowed_profiles(_\d+)?\.json$/i,
+  /(^|\/)pending_follow_requests(_\d+)?\.json$/i,
+  /(^|\/)liked_(posts|comments)(_\d+)?\.json$/i,
+  /(^|\/)(post|reels)_comments(_\d+)?\.json$/i,
+  /connections\/followers_and_following\/.*\.json$/i,
+];
+
+// HTML exports carry the same lists as .html files, which a slim bundle
+// can't hold.
+const HTML_RELATIONSHIPS = /connections\/followers_and_following\/.*\.html$/i;
+
+const EOCD_SIGNATURE = 0x06054b50;
+const CENTRAL_SIGNATURE = 0x02014b50;
+const LOCAL_SIGNATURE = 0x04034b50;
+
+interface ZipEntry {
+  name: string;
+  flags: number;
+  method: number;
+  compressedSize: number;
+  localHeaderOffset: number;
+}
+
+const isRelevant = (name: string) =>
+  RELEVANT_FILES.some((pattern) => pattern.test(name));
+
+// readCentralDirectory lists the entries of a ZIP, or returns null for
+// archives it can't read, such as ZIP64 ones.
+const readCentralDirectory = async (file: File): Promise<ZipEntry[] | null> => {
+  // The end of central directory record is 22 bytes plus a comment of up
+  // to 64KB.
+  const tailStart = Math.max(0, file.size - 22 - 0xffff);
+  const tail = new DataView(await file.slice(tailStart).arrayBuffer());
+  let eocd = -1;
+  for (let i = tail.byteLength - 22; i >= 0; i--) {
+    if (tail.getUint32(i, true) === EOCD_SIGNATURE) {
+      eocd = i;
+      break;
+    }
+  }
+  if (eocd < 0) {
+    return null;
+  }
+
+  const count = tail.getUint16(eocd + 10, true);
+  const size = tail.getUint32(eocd + 12, true);
+  const offset = tail.getUint32(eocd + 16, true);
+  if (count === 0xffff || size === 0xffffffff || offset === 0xffffffff) {
+    return null;
+  }
+
+  const directory = new DataView(
+    await file.slice(offset, offset + size).arrayBuffer(),
+  );
+  const decoder = new TextDecoder();
+  const entries: ZipEntry[] = [];
+  let pos = 0;
+  for (let i = 0; i < count; i++) {
+    if (
+      pos + 46 > directory.byteLength ||
+      directory.getUint32(pos, true) !== CENTRAL_SIGNATURE
+    ) {
+      return null;
+    }
+    const nameLength = directory.getUint16(pos + 28, true);
+    const extraLength = directory.getUint16(pos + 30, true);
+    const commentLength = directory.getUint16(pos + 32, true);
+    entries.push({
+      name: decoder.decode(
+        new Uint8Array(
+          directory.buffer,
+          directory.byteOffset + pos + 46,
+          nameLength,
+        ),
+      ),
+      flags: directory.getUint16(pos + 8, true),
+      method: directory.getUint16(pos + 10, true),
+      compressedSize: directory.getUint32(pos + 20, true),
+      localHeaderOffset: directory.getUint32(pos + 42, true),
+    });
+    pos += 46 + nameLength + extraLength + commentLength;
+  }
+  return entries;
+};
+
+// readEntry returns the text of a stored or deflated entry, or null for
+// other compression methods.
+const readEntry = async (
+  file: File,
+  entry: ZipEntry,
+): Promise<string | null> => {
+  const header = new DataView(
+    await file
+      .slice(entry.localHeaderOffset, entry.localHeaderOffset + 30)
+      .arrayBuffer(),
+  );
+  if (header.getUint32(0, true) !== LOCAL_SIGNATURE) {
+    return null;
+  }
+  const start =
+    entry.localHeaderOffset +
+    30 +
+    header.getUint16(26, true) +
+    header.getUint16(28, true);
+  const data = file.slice(start, start + entry.compressedSize);
+
+  switch (entry.method) {
+    case 0:
+      return data.text();
+    case 8:
+      return new Response(
+        data.stream().pipeThrough(new DecompressionStream("deflate-raw")),
+      ).text();
+    default:
+      return null;
+  }
+};
+
+// buildSlimBundle extracts the relationship files of an export ZIP into a
+// slim bundle. It returns null whenever the full ZIP should be uploaded
+// instead: when the browser can't inflate ZIP entries, or the export is
+// encrypted, in HTML format, unreadable or lacks the relationship files.
+export const buildSlimBundle = async (file: File): Promise<Blob | null> => {
+  if (typeof DecompressionStream === "undefined") {
+    return null;
+  }
+  try {
+    const entries = await readCentralDirectory(file);
+    if (!entries || entries.some((e) => HTML_RELATIONSHIPS.test(e.name))) {
+      return null;
+    }
+    const relevant = entries.filter((e) => isRelevant(e.name));
+    if (relevant.length === 0 || relevant.some((e) => e.flags & 0x1)) {
+      return null;
+    }
+
+    const files: string[] = [];
+    for (const entry of relevant) {
+      const content = await readEntry(file, entry);
+      if (content === null) {
+        return null;
+      }
+      // The backend rejects the whole bundle if any file isn't JSON.
+      JSON.parse(content);
+      files.push(`${JSON.stringify(entry.name)}:${content}`);
+    }
+    return new Blob([`{"files":{${files.join(",")}}}`], {
+      type: "application/json",
+    });
+  } catch {
+    return null;
+  }
+};